@@ -101,16 +101,21 @@ func (s *containerRouter) getContainersStats(ctx context.Context, w http.Respons
 		return err
 	}
 
-	stream := httputils.BoolValueOrDefault(r, "stream", true)
-	if !stream {
-		w.Header().Set("Content-Type", "application/json")
-	}
-
 	config := &backend.ContainerStatsConfig{
-		Stream:    stream,
 		OutStream: w,
 		Version:   httputils.VersionFromContext(ctx),
 	}
+	if versions.GreaterThanOrEqualTo(config.Version, "1.40") {
+		config.Since = r.Form.Get("since")
+		config.Until = r.Form.Get("until")
+		config.Step = r.Form.Get("step")
+	}
+
+	// the stats history is not streamed
+	config.Stream = config.Since == "" && config.Until == "" && httputils.BoolValueOrDefault(r, "stream", true)
+	if !config.Stream {
+		w.Header().Set("Content-Type", "application/json")
+	}
 
 	return s.backend.ContainerStats(ctx, vars["name"], config)
 }
@@ -141,6 +146,13 @@ func (s *containerRouter) getContainersLogs(ctx context.Context, w http.Response
 		ShowStderr: stderr,
 		Details:    httputils.BoolValue(r, "details"),
 	}
+	if versions.GreaterThanOrEqualTo(httputils.VersionFromContext(ctx), "1.40") {
+		logFilters, err := filters.FromJSON(r.Form.Get("filters"))
+		if err != nil {
+			return err
+		}
+		logsConfig.Filters = logFilters
+	}
 
 	msgs, tty, err := s.backend.ContainerLogs(ctx, containerName, logsConfig)
 	if err != nil {
@@ -335,6 +347,11 @@ func (s *containerRouter) postContainersWait(ctx context.Context, w http.Respons
 		case container.WaitConditionRemoved:
 			waitCondition = containerpkg.WaitConditionRemoved
 			legacyRemovalWaitPre134 = versions.LessThan(version, "1.34")
+		case container.WaitConditionReady:
+			if versions.LessThan(version, "1.40") {
+				return errdefs.InvalidParameter(errors.New("wait condition \"ready\" requires API version 1.40 or later"))
+			}
+			waitCondition = containerpkg.WaitConditionReady
 		}
 	}
 
@@ -485,6 +502,9 @@ func (s *containerRouter) postContainersCreate(ctx context.Context, w http.Respo
 		// Ignore Capabilities because it was added in API 1.40.
 		hostConfig.Capabilities = nil
 
+		// Ignore AdditionalLogConfigs because it was added in API 1.40.
+		hostConfig.AdditionalLogConfigs = nil
+
 		// Older clients (API < 1.40) expects the default to be shareable, make them happy
 		if hostConfig.IpcMode.IsEmpty() {
 			hostConfig.IpcMode = container.IpcMode("shareable")
@@ -7,9 +7,9 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/docker/distribution"
 	"github.com/docker/distribution/manifest/manifestlist"
 	"github.com/docker/distribution/manifest/schema1"
-	"github.com/docker/distribution/manifest/schema2"
 	"github.com/docker/distribution/reference"
 	"github.com/docker/docker/api/server/httputils"
 	"github.com/docker/docker/api/types"
@@ -118,10 +118,19 @@ func (s *distributionRouter) getDistributionInfo(ctx context.Context, w http.Res
 	}
 
 	// retrieve platform information depending on the type of manifest
+	distributionInspect.Platforms = manifestPlatforms(ctx, blobsrvc, mnfst)
+
+	return httputils.WriteJSON(w, http.StatusOK, distributionInspect)
+}
+
+// manifestPlatforms returns the platforms of the images of a manifest. The
+// platform of an image manifest is read from the image config in blobs.
+func manifestPlatforms(ctx context.Context, blobs distribution.BlobProvider, mnfst distribution.Manifest) []v1.Platform {
+	var platforms []v1.Platform
 	switch mnfstObj := mnfst.(type) {
 	case *manifestlist.DeserializedManifestList:
 		for _, m := range mnfstObj.Manifests {
-			distributionInspect.Platforms = append(distributionInspect.Platforms, v1.Platform{
+			platforms = append(platforms, v1.Platform{
 				Architecture: m.Platform.Architecture,
 				OS:           m.Platform.OS,
 				OSVersion:    m.Platform.OSVersion,
@@ -129,22 +138,21 @@ func (s *distributionRouter) getDistributionInfo(ctx context.Context, w http.Res
 				Variant:      m.Platform.Variant,
 			})
 		}
-	case *schema2.DeserializedManifest:
-		configJSON, err := blobsrvc.Get(ctx, mnfstObj.Config.Digest)
+	case *schema1.SignedManifest:
+		platforms = append(platforms, v1.Platform{
+			Architecture: mnfstObj.Architecture,
+			OS:           "linux",
+		})
+	case interface{ Target() distribution.Descriptor }:
+		// schema2 and OCI image manifests
+		configJSON, err := blobs.Get(ctx, mnfstObj.Target().Digest)
 		var platform v1.Platform
 		if err == nil {
 			err := json.Unmarshal(configJSON, &platform)
 			if err == nil && (platform.OS != "" || platform.Architecture != "") {
-				distributionInspect.Platforms = append(distributionInspect.Platforms, platform)
+				platforms = append(platforms, platform)
 			}
 		}
-	case *schema1.SignedManifest:
-		platform := v1.Platform{
-			Architecture: mnfstObj.Architecture,
-			OS:           "linux",
-		}
-		distributionInspect.Platforms = append(distributionInspect.Platforms, platform)
 	}
-
-	return httputils.WriteJSON(w, http.StatusOK, distributionInspect)
+	return platforms
 }
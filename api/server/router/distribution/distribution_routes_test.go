@@ -0,0 +1,60 @@
+package distribution // import "github.com/docker/docker/api/server/router/distribution"
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/manifest"
+	"github.com/docker/distribution/manifest/schema2"
+	// registers the OCI image manifests
+	_ "github.com/docker/docker/distribution"
+	digest "github.com/opencontainers/go-digest"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+type testBlobs map[digest.Digest][]byte
+
+func (b testBlobs) Get(ctx context.Context, dgst digest.Digest) ([]byte, error) {
+	blob, ok := b[dgst]
+	if !ok {
+		return nil, distribution.ErrBlobUnknown
+	}
+	return blob, nil
+}
+
+func (b testBlobs) Open(ctx context.Context, dgst digest.Digest) (distribution.ReadSeekCloser, error) {
+	return nil, distribution.ErrBlobUnknown
+}
+
+func TestManifestPlatforms(t *testing.T) {
+	configJSON := []byte(`{"architecture":"arm","variant":"v7","os":"linux"}`)
+	config := distribution.Descriptor{Digest: digest.FromBytes(configJSON), Size: int64(len(configJSON))}
+	blobs := testBlobs{config.Digest: configJSON}
+
+	config.MediaType = schema2.MediaTypeImageConfig
+	schema2Manifest, err := schema2.FromStruct(schema2.Manifest{Versioned: schema2.SchemaVersion, Config: config})
+	assert.NilError(t, err)
+
+	config.MediaType = v1.MediaTypeImageConfig
+	ociJSON, err := json.Marshal(struct {
+		manifest.Versioned
+		Config distribution.Descriptor   `json:"config"`
+		Layers []distribution.Descriptor `json:"layers"`
+	}{
+		Versioned: manifest.Versioned{SchemaVersion: 2, MediaType: v1.MediaTypeImageManifest},
+		Config:    config,
+		Layers:    []distribution.Descriptor{},
+	})
+	assert.NilError(t, err)
+	ociManifest, _, err := distribution.UnmarshalManifest(v1.MediaTypeImageManifest, ociJSON)
+	assert.NilError(t, err)
+
+	expected := []v1.Platform{{Architecture: "arm", OS: "linux", Variant: "v7"}}
+	for _, m := range []distribution.Manifest{schema2Manifest, ociManifest} {
+		assert.Check(t, is.DeepEqual(manifestPlatforms(context.Background(), blobs, m), expected), "%T", m)
+	}
+}
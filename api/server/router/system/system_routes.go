@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/docker/docker/api/server/httputils"
@@ -92,12 +93,17 @@ func (s *systemRouter) getVersion(ctx context.Context, w http.ResponseWriter, r
 }
 
 func (s *systemRouter) getDiskUsage(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+	verbose := versions.GreaterThanOrEqualTo(httputils.VersionFromContext(ctx), "1.40") && httputils.BoolValue(r, "verbose")
+
 	eg, ctx := errgroup.WithContext(ctx)
 
 	var du *types.DiskUsage
 	eg.Go(func() error {
 		var err error
-		du, err = s.backend.SystemDiskUsage(ctx)
+		du, err = s.backend.SystemDiskUsage(ctx, verbose)
 		return err
 	})
 
@@ -183,6 +189,17 @@ func (s *systemRouter) getEvents(ctx context.Context, w http.ResponseWriter, r *
 		return err
 	}
 
+	if after := r.Form.Get("after"); after != "" && versions.GreaterThanOrEqualTo(httputils.VersionFromContext(ctx), "1.40") {
+		if r.Form.Get("since") != "" {
+			return invalidRequestError{pkgerrors.New("`since` and `after` cannot be used together")}
+		}
+		seq, err := strconv.ParseUint(after, 10, 64)
+		if err != nil {
+			return invalidRequestError{fmt.Errorf("invalid event sequence: %s", after)}
+		}
+		return s.getEventsAfter(ctx, w, seq, until, ef, onlyPastEvents, timeout)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	output := ioutils.NewWriteFlusher(w)
 	defer output.Close()
@@ -223,6 +240,60 @@ func (s *systemRouter) getEvents(ctx context.Context, w http.ResponseWriter, r *
 	}
 }
 
+// getEventsAfter streams the events following the given sequence. Instead of
+// forwarding the messages received by the subscriber, which are skipped
+// when the reader is too slow, it uses them as a notification to read the
+// recorded events following the last sequence that was sent, so that no
+// event is missed or sent twice.
+func (s *systemRouter) getEventsAfter(ctx context.Context, w http.ResponseWriter, after uint64, until time.Time, ef filters.Args, onlyPastEvents bool, timeout <-chan time.Time) error {
+	_, l := s.backend.SubscribeToEvents(time.Time{}, time.Time{}, filters.NewArgs())
+	defer s.backend.UnsubscribeFromEvents(l)
+
+	msgs, last, err := s.backend.EventsAfter(after, ef)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	output := ioutils.NewWriteFlusher(w)
+	defer output.Close()
+	output.Flush()
+
+	enc := json.NewEncoder(output)
+
+	for {
+		for _, ev := range msgs {
+			if !until.IsZero() && ev.TimeNano > until.UnixNano() {
+				return nil
+			}
+			if err := enc.Encode(ev); err != nil {
+				return err
+			}
+		}
+
+		if onlyPastEvents {
+			return nil
+		}
+
+		select {
+		case <-l:
+		case <-timeout:
+			return nil
+		case <-ctx.Done():
+			logrus.Debug("Client context cancelled, stop sending events")
+			return nil
+		}
+
+		msgs, last, err = s.backend.EventsAfter(last, ef)
+		if err != nil {
+			// The response is already being streamed; end it so that the
+			// client reconnects from its last sequence and gets the error.
+			logrus.WithError(err).Warn("Stop sending events")
+			return nil
+		}
+	}
+}
+
 func (s *systemRouter) postAuth(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	var config *types.AuthConfig
 	err := json.NewDecoder(r.Body).Decode(&config)
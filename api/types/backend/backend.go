@@ -75,6 +75,12 @@ type ContainerStatsConfig struct {
 	Stream    bool
 	OutStream io.Writer
 	Version   string
+
+	// Since, Until and Step query the stats history of the container
+	// instead of its current stats, when Since or Until is set.
+	Since string
+	Until string
+	Step  string
 }
 
 // ExecInspect holds information about a running process started
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"io"
 	"net"
+	"time"
 
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
@@ -79,6 +80,15 @@ type ContainerLogsOptions struct {
 	Follow     bool
 	Tail       string
 	Details    bool
+	Filters    filters.Args
+}
+
+// ContainerStatsHistoryOptions holds parameters to query the stats history
+// of a container.
+type ContainerStatsHistoryOptions struct {
+	Since string
+	Until string
+	Step  time.Duration
 }
 
 // ContainerRemoveOptions holds parameters to remove containers.
@@ -106,6 +116,10 @@ type EventsOptions struct {
 	Since   string
 	Until   string
 	Filters filters.Args
+
+	// After, when not zero, resumes the stream right after the event with
+	// this sequence. It cannot be used together with Since.
+	After uint64
 }
 
 // NetworkListOptions holds parameters to filter the list of networks with.
@@ -22,6 +22,9 @@ type HealthConfig struct {
 	// {"NONE"} : disable healthcheck
 	// {"CMD", args...} : exec arguments directly
 	// {"CMD-SHELL", command} : run command with system's default shell
+	// {"HTTP", url, options...} : send an HTTP GET request to url; options are
+	//     "status=<code>[-<code>]" (default 200-399) and "body=<text>"
+	// {"TCP", "[host]:port"} : open a TCP connection to the address
 	Test []string `json:",omitempty"`
 
 	// Zero means to inherit. Durations are expressed as integer nanoseconds.
@@ -54,6 +57,8 @@ type Config struct {
 	Env             []string            // List of environment variable to set in the container
 	Cmd             strslice.StrSlice   // Command to run when starting the container
 	Healthcheck     *HealthConfig       `json:",omitempty"` // Healthcheck describes how to check the container is healthy
+	StartupProbe    *HealthConfig       `json:",omitempty"` // StartupProbe describes how to check the container has started; other probes only run once it succeeded
+	ReadinessProbe  *HealthConfig       `json:",omitempty"` // ReadinessProbe describes how to check the container is ready to take traffic
 	ArgsEscaped     bool                `json:",omitempty"` // True if command is already escaped (meaning treat as a command line) (Windows specific).
 	Image           string              // Name of the image as it was passed by the operator (e.g. could be symbolic)
 	Volumes         map[string]struct{} // List of volumes (mounts) used for the container
@@ -2,6 +2,7 @@ package container // import "github.com/docker/docker/api/types/container"
 
 import (
 	"strings"
+	"time"
 
 	"github.com/docker/docker/api/types/blkiodev"
 	"github.com/docker/docker/api/types/mount"
@@ -261,10 +262,44 @@ type DeviceMapping struct {
 	CgroupPermissions string
 }
 
+// Metrics of the resource alerts of a container.
+const (
+	AlertMetricMemory       = "memory"        // memory usage, in percent of the memory limit
+	AlertMetricCPU          = "cpu"           // CPU usage, in percent of one CPU
+	AlertMetricCPUThrottled = "cpu-throttled" // throttled CPU periods, in percent of the CPU periods
+	AlertMetricPids         = "pids"          // number of processes, in percent of the pids limit
+)
+
+// ResourceAlert is a rule on the resource usage of a container. The daemon
+// emits a threshold-exceeded event when the metric is above the threshold for
+// the duration, and a threshold-cleared event when it goes back below it.
+type ResourceAlert struct {
+	Name      string        // Name of the alert, used in the events
+	Metric    string        // Metric is one of the AlertMetric constants
+	Threshold float64       // Threshold of the metric, in percent
+	Duration  time.Duration `json:",omitempty"` // Duration for which the threshold must be exceeded, in nanoseconds
+}
+
 // RestartPolicy represents the restart policies of the container.
 type RestartPolicy struct {
 	Name              string
 	MaximumRetryCount int
+
+	// UnhealthyPeriod is how long a container with the "on-unhealthy"
+	// policy must stay unhealthy before it is restarted. Zero restarts
+	// it as soon as it becomes unhealthy.
+	UnhealthyPeriod time.Duration `json:",omitempty"`
+
+	// The delay before a restart starts at InitialDelay and is multiplied
+	// by Multiplier after each restart, up to MaxDelay. A random delay of
+	// up to Jitter times the delay is added to it. The delay is reset to
+	// InitialDelay when the container ran for at least ResetAfter.
+	// Zero values mean the daemon's default.
+	InitialDelay time.Duration `json:",omitempty"`
+	MaxDelay     time.Duration `json:",omitempty"`
+	Multiplier   float64       `json:",omitempty"`
+	Jitter       float64       `json:",omitempty"`
+	ResetAfter   time.Duration `json:",omitempty"`
 }
 
 // IsNone indicates whether the container has the "no" restart policy.
@@ -285,6 +320,14 @@ func (rp *RestartPolicy) IsOnFailure() bool {
 	return rp.Name == "on-failure"
 }
 
+// IsOnUnhealthy indicates whether the container has the "on-unhealthy"
+// restart policy. This means the container will automatically restart if it
+// stays unhealthy for longer than UnhealthyPeriod, or if it exits with a
+// non-zero exit status.
+func (rp *RestartPolicy) IsOnUnhealthy() bool {
+	return rp.Name == "on-unhealthy"
+}
+
 // IsUnlessStopped indicates whether the container has the
 // "unless-stopped" restart policy. This means the container will
 // automatically restart unless user has put it to stopped state.
@@ -294,7 +337,9 @@ func (rp *RestartPolicy) IsUnlessStopped() bool {
 
 // IsSame compares two RestartPolicy to see if they are the same
 func (rp *RestartPolicy) IsSame(tp *RestartPolicy) bool {
-	return rp.Name == tp.Name && rp.MaximumRetryCount == tp.MaximumRetryCount
+	return rp.Name == tp.Name && rp.MaximumRetryCount == tp.MaximumRetryCount && rp.UnhealthyPeriod == tp.UnhealthyPeriod &&
+		rp.InitialDelay == tp.InitialDelay && rp.MaxDelay == tp.MaxDelay && rp.Multiplier == tp.Multiplier &&
+		rp.Jitter == tp.Jitter && rp.ResetAfter == tp.ResetAfter
 }
 
 // LogMode is a type to define the available modes for logging
@@ -378,6 +423,14 @@ type HostConfig struct {
 	VolumeDriver    string        // Name of the volume driver used to mount volumes
 	VolumesFrom     []string      // List of volumes to take from other container
 
+	// AdditionalLogConfigs are logging destinations to which the logs of the
+	// container are sent in addition to LogConfig.
+	AdditionalLogConfigs []LogConfig `json:",omitempty"`
+
+	// ResourceAlerts are rules on the resource usage of the container, for
+	// which the daemon emits events.
+	ResourceAlerts []ResourceAlert `json:",omitempty"`
+
 	// Applicable to UNIX platforms
 	CapAdd          strslice.StrSlice // List of kernel capabilities to add to the container
 	CapDrop         strslice.StrSlice // List of kernel capabilities to remove from the container
@@ -390,6 +443,7 @@ type HostConfig struct {
 	IpcMode         IpcMode           // IPC namespace to use for the container
 	Cgroup          CgroupSpec        // Cgroup to use for the container
 	Links           []string          // List of links (in the name:alias form)
+	DependsOn       []string          `json:",omitempty"` // List of containers to start before this one (in the name[:condition] form, condition being started, healthy or ready)
 	OomScoreAdj     int               // Container preference for OOM-killing
 	PidMode         PidMode           // PID namespace to use for the container
 	Privileged      bool              // Is the container in privileged mode
@@ -15,8 +15,12 @@ type WaitCondition string
 // or is removed.
 //
 // WaitConditionRemoved is used to wait for the container to be removed.
+//
+// WaitConditionReady is used to wait for the container to be running and
+// ready, that is its startup and readiness probes (if any) succeeded.
 const (
 	WaitConditionNotRunning WaitCondition = "not-running"
 	WaitConditionNextExit   WaitCondition = "next-exit"
 	WaitConditionRemoved    WaitCondition = "removed"
+	WaitConditionReady      WaitCondition = "ready"
 )
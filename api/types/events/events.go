@@ -49,4 +49,8 @@ type Message struct {
 
 	Time     int64 `json:"time,omitempty"`
 	TimeNano int64 `json:"timeNano,omitempty"`
+
+	// Sequence is a monotonically increasing number identifying the
+	// event. It can be used as a cursor to resume reading events.
+	Sequence uint64 `json:"sequence,omitempty"`
 }
@@ -69,6 +69,9 @@ type MemoryStats struct {
 	// number of times memory usage hits limits.
 	Failcnt uint64 `json:"failcnt,omitempty"`
 	Limit   uint64 `json:"limit,omitempty"`
+	// number of processes killed by the OOM killer since the container
+	// started, if the kernel provides it.
+	OOMKills uint64 `json:"oom_kills,omitempty"`
 
 	// Windows Memory Stats
 	// See https://technet.microsoft.com/en-us/magazine/ff382715.aspx
@@ -81,6 +84,32 @@ type MemoryStats struct {
 	PrivateWorkingSet uint64 `json:"privateworkingset,omitempty"`
 }
 
+// PressureValues holds the pressure stall information of a resource for the
+// tasks which are stalled, either some or all of them.
+type PressureValues struct {
+	// Share of time, in percent, during which tasks were stalled over the
+	// last 10, 60 and 300 seconds.
+	Avg10  float64 `json:"avg10"`
+	Avg60  float64 `json:"avg60"`
+	Avg300 float64 `json:"avg300"`
+	// Total time during which tasks were stalled, in microseconds.
+	Total uint64 `json:"total"`
+}
+
+// Pressure holds the pressure stall information of a resource.
+type Pressure struct {
+	Some *PressureValues `json:"some,omitempty"`
+	Full *PressureValues `json:"full,omitempty"`
+}
+
+// PressureStats holds the pressure stall information (PSI) of the cgroup of
+// a container. Linux only, when the kernel provides it.
+type PressureStats struct {
+	CPU    *Pressure `json:"cpu,omitempty"`
+	Memory *Pressure `json:"memory,omitempty"`
+	IO     *Pressure `json:"io,omitempty"`
+}
+
 // BlkioStatEntry is one small entity to store a piece of Blkio stats
 // Not used on Windows.
 type BlkioStatEntry struct {
@@ -156,8 +185,9 @@ type Stats struct {
 	PreRead time.Time `json:"preread"`
 
 	// Linux specific stats, not populated on Windows.
-	PidsStats  PidsStats  `json:"pids_stats,omitempty"`
-	BlkioStats BlkioStats `json:"blkio_stats,omitempty"`
+	PidsStats     PidsStats      `json:"pids_stats,omitempty"`
+	BlkioStats    BlkioStats     `json:"blkio_stats,omitempty"`
+	PressureStats *PressureStats `json:"pressure_stats,omitempty"`
 
 	// Windows specific stats, not populated on Linux.
 	NumProcs     uint32       `json:"num_procs"`
@@ -179,3 +209,44 @@ type StatsJSON struct {
 	// Networks request version >=1.21
 	Networks map[string]NetworkStats `json:"networks,omitempty"`
 }
+
+// StatsAggregate holds the minimum, maximum and average of a value over an
+// interval.
+type StatsAggregate struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+	Avg float64 `json:"avg"`
+}
+
+// StatsHistoryPoint aggregates the stats of a container over an interval.
+type StatsHistoryPoint struct {
+	// Start and End are the bounds of the interval.
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// Samples is the number of samples in the interval.
+	Samples int `json:"samples"`
+
+	// CPUPercent is the CPU usage of the container, in percent of one CPU.
+	CPUPercent  StatsAggregate `json:"cpu_percent"`
+	MemoryUsage StatsAggregate `json:"memory_usage"`
+	MemoryLimit uint64         `json:"memory_limit,omitempty"`
+	Pids        StatsAggregate `json:"pids"`
+
+	// Number of bytes read and written by the container during the interval.
+	BlkioReadBytes  uint64 `json:"blkio_read_bytes"`
+	BlkioWriteBytes uint64 `json:"blkio_write_bytes"`
+	// Number of bytes received and sent by the container during the interval.
+	NetworkRxBytes uint64 `json:"network_rx_bytes"`
+	NetworkTxBytes uint64 `json:"network_tx_bytes"`
+}
+
+// StatsHistory is the stats history of a container between two times,
+// aggregated in intervals of Step.
+type StatsHistory struct {
+	Name string `json:"name,omitempty"`
+	ID   string `json:"id,omitempty"`
+
+	// Step is the length of the intervals, in seconds.
+	Step   int64               `json:"step"`
+	Points []StatsHistoryPoint `json:"points"`
+}
@@ -291,11 +291,28 @@ const (
 	Unhealthy     = "unhealthy" // Unhealthy indicates that the container has a problem
 )
 
+// Startup and readiness probe states
+const (
+	Started  = "started"   // Started indicates that the startup probe succeeded
+	Ready    = "ready"     // Ready indicates that the container is ready to take traffic
+	NotReady = "not-ready" // NotReady indicates that the container is not ready to take traffic
+)
+
 // Health stores information about the container's healthcheck results
 type Health struct {
 	Status        string               // Status is one of Starting, Healthy or Unhealthy
 	FailingStreak int                  // FailingStreak is the number of consecutive failures
 	Log           []*HealthcheckResult // Log contains the last few results (oldest first)
+
+	Startup   *ProbeStatus `json:",omitempty"` // Startup is the state of the startup probe, if any
+	Readiness *ProbeStatus `json:",omitempty"` // Readiness is the state of the readiness probe, if any
+}
+
+// ProbeStatus stores information about the results of a startup or readiness probe
+type ProbeStatus struct {
+	Status        string               // Status is one of Starting, Started or Unhealthy for startup probes, and Ready or NotReady for readiness probes
+	FailingStreak int                  // FailingStreak is the number of consecutive failures
+	Log           []*HealthcheckResult // Log contains the last few results (oldest first)
 }
 
 // ContainerState stores container's running state
@@ -312,7 +329,22 @@ type ContainerState struct {
 	Error      string
 	StartedAt  string
 	FinishedAt string
-	Health     *Health `json:",omitempty"`
+	// NextRestartAt is when a restarting container is scheduled to start
+	// again (RFC 3339 with nano-seconds).
+	NextRestartAt string  `json:",omitempty"`
+	Health        *Health `json:",omitempty"`
+	// OOMKillCount is the number of processes of the container killed by
+	// the OOM killer since it was created.
+	OOMKillCount int      `json:",omitempty"`
+	LastOOMKill  *OOMKill `json:",omitempty"`
+}
+
+// OOMKill stores information about a process of a container killed by the
+// OOM killer.
+type OOMKill struct {
+	Time    string // Time of the kill (RFC 3339 with nano-seconds)
+	Pid     int    `json:",omitempty"` // Pid of the killed process, in the host PID namespace, if known
+	Process string `json:",omitempty"` // Name of the killed process, if known
 }
 
 // ContainerNode stores information about the node that a container
@@ -351,8 +383,31 @@ type ContainerJSONBase struct {
 	ExecIDs         []string
 	HostConfig      *container.HostConfig
 	GraphDriver     GraphDriverData
-	SizeRw          *int64 `json:",omitempty"`
-	SizeRootFs      *int64 `json:",omitempty"`
+	SizeRw          *int64    `json:",omitempty"`
+	SizeRootFs      *int64    `json:",omitempty"`
+	LogStats        *LogStats `json:",omitempty"`
+}
+
+// LogStats contains the number of log messages of a running container which
+// were dropped before reaching the logging driver, since it was started.
+type LogStats struct {
+	RateLimited uint64 // Number of messages dropped because of the rate limits
+	SampledOut  uint64 // Number of messages dropped by sampling
+
+	// Buffer is the state of the buffers of the logging drivers of the
+	// container, when it uses the non-blocking log mode.
+	Buffer *LogBufferStats `json:",omitempty"`
+}
+
+// LogBufferStats contains the state of the buffers of the logging drivers of
+// a container using the non-blocking log mode.
+type LogBufferStats struct {
+	Enqueued     uint64 // Number of messages added to the buffers
+	Dropped      uint64 // Number of messages dropped because a buffer was full
+	DroppedBytes uint64 // Size of the messages dropped because a buffer was full
+	Messages     int    // Number of messages in the buffers
+	Size         int64  // Size of the messages in the buffers
+	MaxSize      int64  // Size of the buffers
 }
 
 // ContainerJSON is newly used struct along with MountPoint
@@ -519,6 +574,28 @@ type DiskUsage struct {
 	Volumes     []*Volume
 	BuildCache  []*BuildCache
 	BuilderSize int64 // deprecated
+
+	// Set in verbose mode only
+	ContainersUsage []*ContainerDiskUsage `json:",omitempty"`
+	VolumesUsage    []*VolumeDiskUsage    `json:",omitempty"`
+}
+
+// ContainerDiskUsage is the disk usage of a container outside of its
+// filesystem, returned by GET "/system/df" in verbose mode.
+type ContainerDiskUsage struct {
+	ID             string
+	LogSize        int64    // LogSize is the size of the log files written by the logging drivers
+	CheckpointSize int64    // CheckpointSize is the size of the checkpoints
+	VolumesSize    int64    // VolumesSize is the share of the container in the size of the local volumes it uses
+	Volumes        []string // Volumes are the names of the local volumes used by the container
+}
+
+// VolumeDiskUsage lists the containers using a local volume, returned by
+// GET "/system/df" in verbose mode.
+type VolumeDiskUsage struct {
+	Name       string
+	Size       int64
+	Containers []string // Containers are the IDs of the containers using the volume
 }
 
 // ContainersPruneReport contains the response for Engine API:
@@ -2,9 +2,14 @@ package client // import "github.com/docker/docker/client"
 
 import (
 	"context"
+	"encoding/json"
 	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/docker/docker/api/types"
+	timetypes "github.com/docker/docker/api/types/time"
+	"github.com/pkg/errors"
 )
 
 // ContainerStats returns near realtime stats for a given container.
@@ -24,3 +29,49 @@ func (cli *Client) ContainerStats(ctx context.Context, containerID string, strea
 	osType := getDockerOS(resp.header.Get("Server"))
 	return types.ContainerStats{Body: resp.body, OSType: osType}, err
 }
+
+// ContainerStatsHistory returns the stats history of a given container,
+// aggregated in intervals of options.Step.
+func (cli *Client) ContainerStatsHistory(ctx context.Context, containerID string, options types.ContainerStatsHistoryOptions) (types.StatsHistory, error) {
+	var history types.StatsHistory
+	if err := cli.NewVersionError("1.40", "stats history"); err != nil {
+		return history, err
+	}
+
+	// The daemon returns the history when since or until is set.
+	since := options.Since
+	if since == "" && options.Until == "" {
+		since = "0"
+	}
+	query := url.Values{}
+	if since != "" {
+		ts, err := timetypes.GetTimestamp(since, time.Now())
+		if err != nil {
+			return history, errors.Wrap(err, `invalid value for "since"`)
+		}
+		query.Set("since", ts)
+	}
+	if options.Until != "" {
+		ts, err := timetypes.GetTimestamp(options.Until, time.Now())
+		if err != nil {
+			return history, errors.Wrap(err, `invalid value for "until"`)
+		}
+		query.Set("until", ts)
+	}
+	if options.Step > 0 {
+		step := int64(options.Step / time.Second)
+		if step < 1 {
+			step = 1
+		}
+		query.Set("step", strconv.FormatInt(step, 10))
+	}
+
+	resp, err := cli.get(ctx, "/containers/"+containerID+"/stats", query, nil)
+	defer ensureReaderClosed(resp)
+	if err != nil {
+		return history, err
+	}
+
+	err = json.NewDecoder(resp.body).Decode(&history)
+	return history, err
+}
@@ -3,12 +3,15 @@ package client // import "github.com/docker/docker/client"
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/errdefs"
 )
 
@@ -72,3 +75,42 @@ func TestContainerStats(t *testing.T) {
 		}
 	}
 }
+
+func TestContainerStatsHistory(t *testing.T) {
+	expectedURL := "/containers/container_id/stats"
+	client := &Client{
+		client: newMockClient(func(r *http.Request) (*http.Response, error) {
+			if !strings.HasPrefix(r.URL.Path, expectedURL) {
+				return nil, fmt.Errorf("Expected URL '%s', got '%s'", expectedURL, r.URL)
+			}
+			query := r.URL.Query()
+			if since := query.Get("since"); since != "1500000000" {
+				return nil, fmt.Errorf("since not set in URL query properly. Expected '1500000000', got %s", since)
+			}
+			if until := query.Get("until"); until != "" {
+				return nil, fmt.Errorf("until should not be set in URL query, got %s", until)
+			}
+			if step := query.Get("step"); step != "60" {
+				return nil, fmt.Errorf("step not set in URL query properly. Expected '60', got %s", step)
+			}
+			b, err := json.Marshal(types.StatsHistory{ID: "container_id", Step: 60, Points: []types.StatsHistoryPoint{{Samples: 6}}})
+			if err != nil {
+				return nil, err
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewReader(b)),
+			}, nil
+		}),
+	}
+	history, err := client.ContainerStatsHistory(context.Background(), "container_id", types.ContainerStatsHistoryOptions{
+		Since: "1500000000",
+		Step:  time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if history.Step != 60 || len(history.Points) != 1 || history.Points[0].Samples != 6 {
+		t.Fatalf("unexpected stats history: %+v", history)
+	}
+}
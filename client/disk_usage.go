@@ -4,15 +4,31 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
 
 	"github.com/docker/docker/api/types"
 )
 
 // DiskUsage requests the current data usage from the daemon
 func (cli *Client) DiskUsage(ctx context.Context) (types.DiskUsage, error) {
+	return cli.diskUsage(ctx, nil)
+}
+
+// DiskUsageVerbose requests the current data usage from the daemon, including
+// the log, checkpoint and volume usage of each container.
+func (cli *Client) DiskUsageVerbose(ctx context.Context) (types.DiskUsage, error) {
+	if err := cli.NewVersionError("1.40", "verbose disk usage"); err != nil {
+		return types.DiskUsage{}, err
+	}
+	query := url.Values{}
+	query.Set("verbose", "1")
+	return cli.diskUsage(ctx, query)
+}
+
+func (cli *Client) diskUsage(ctx context.Context, query url.Values) (types.DiskUsage, error) {
 	var du types.DiskUsage
 
-	serverResp, err := cli.get(ctx, "/system/df", nil, nil)
+	serverResp, err := cli.get(ctx, "/system/df", query, nil)
 	defer ensureReaderClosed(serverResp)
 	if err != nil {
 		return du, err
@@ -57,3 +57,34 @@ func TestDiskUsage(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestDiskUsageVerbose(t *testing.T) {
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			if verbose := req.URL.Query().Get("verbose"); verbose != "1" {
+				return nil, fmt.Errorf("verbose not set in URL query properly. Expected '1', got %s", verbose)
+			}
+
+			du := types.DiskUsage{
+				ContainersUsage: []*types.ContainerDiskUsage{{ID: "container_id", LogSize: 100}},
+			}
+
+			b, err := json.Marshal(du)
+			if err != nil {
+				return nil, err
+			}
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewReader(b)),
+			}, nil
+		}),
+	}
+	du, err := client.DiskUsageVerbose(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(du.ContainersUsage) != 1 || du.ContainersUsage[0].LogSize != 100 {
+		t.Fatalf("expected the usage of the container, got %v", du.ContainersUsage)
+	}
+}
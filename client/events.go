@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/docker/docker/api/types"
@@ -25,6 +26,14 @@ func (cli *Client) Events(ctx context.Context, options types.EventsOptions) (<-c
 	go func() {
 		defer close(errs)
 
+		if options.After != 0 {
+			if err := cli.NewVersionError("1.40", "events after"); err != nil {
+				close(started)
+				errs <- err
+				return
+			}
+		}
+
 		query, err := buildEventsQueryParams(cli.version, options)
 		if err != nil {
 			close(started)
@@ -89,6 +98,10 @@ func buildEventsQueryParams(cliVersion string, options types.EventsOptions) (url
 		query.Set("until", ts)
 	}
 
+	if options.After != 0 {
+		query.Set("after", strconv.FormatUint(options.After, 10))
+	}
+
 	if options.Filters.Len() > 0 {
 		filterJSON, err := filters.ToParamWithVersion(cliVersion, options.Filters)
 		if err != nil {
@@ -85,6 +85,26 @@ func TestEvents(t *testing.T) {
 			events:         []events.Message{},
 			expectedEvents: make(map[string]bool),
 		},
+		{
+			options: types.EventsOptions{
+				After: 42,
+			},
+			expectedQueryParams: map[string]string{
+				"after": "42",
+				"since": "",
+			},
+			events: []events.Message{
+				{
+					Type:     "container",
+					ID:       "43",
+					Action:   "create",
+					Sequence: 43,
+				},
+			},
+			expectedEvents: map[string]bool{
+				"43": true,
+			},
+		},
 		{
 			options: types.EventsOptions{
 				Filters: filters,
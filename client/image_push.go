@@ -16,6 +16,43 @@ import (
 // and it tries one more time.
 // It's up to the caller to handle the io.ReadCloser and close it properly.
 func (cli *Client) ImagePush(ctx context.Context, image string, options types.ImagePushOptions) (io.ReadCloser, error) {
+	return cli.imagePush(ctx, image, "push", url.Values{}, options)
+}
+
+// ImagePushWithFormat requests the docker host to push an image to a remote
+// registry, with a manifest in the given format: "docker" for a Docker
+// schema2 manifest, or "oci" for an OCI image manifest.
+// It's up to the caller to handle the io.ReadCloser and close it properly.
+func (cli *Client) ImagePushWithFormat(ctx context.Context, image, format string, options types.ImagePushOptions) (io.ReadCloser, error) {
+	if err := cli.NewVersionError("1.40", "image push format"); err != nil {
+		return nil, err
+	}
+	query := url.Values{}
+	query.Set("format", format)
+	return cli.imagePush(ctx, image, "push", query, options)
+}
+
+// ImagePushManifestList requests the docker host to push the local images to
+// a remote registry, with a manifest list referencing them. The images must
+// have different platforms. format is the format of the manifests: "docker"
+// for a Docker manifest list, or "oci" for an OCI image index.
+// It's up to the caller to handle the io.ReadCloser and close it properly.
+func (cli *Client) ImagePushManifestList(ctx context.Context, ref string, images []string, format string, options types.ImagePushOptions) (io.ReadCloser, error) {
+	if err := cli.NewVersionError("1.40", "manifest list push"); err != nil {
+		return nil, err
+	}
+	if len(images) == 0 {
+		return nil, errors.New("no images specified for the manifest list")
+	}
+	query := url.Values{}
+	query["images"] = images
+	if format != "" {
+		query.Set("format", format)
+	}
+	return cli.imagePush(ctx, ref, "push-manifest-list", query, options)
+}
+
+func (cli *Client) imagePush(ctx context.Context, image, action string, query url.Values, options types.ImagePushOptions) (io.ReadCloser, error) {
 	ref, err := reference.ParseNormalizedNamed(image)
 	if err != nil {
 		return nil, err
@@ -32,16 +69,15 @@ func (cli *Client) ImagePush(ctx context.Context, image string, options types.Im
 		tag = nameTaggedRef.Tag()
 	}
 
-	query := url.Values{}
 	query.Set("tag", tag)
 
-	resp, err := cli.tryImagePush(ctx, name, query, options.RegistryAuth)
+	resp, err := cli.tryImagePush(ctx, name, action, query, options.RegistryAuth)
 	if errdefs.IsUnauthorized(err) && options.PrivilegeFunc != nil {
 		newAuthHeader, privilegeErr := options.PrivilegeFunc()
 		if privilegeErr != nil {
 			return nil, privilegeErr
 		}
-		resp, err = cli.tryImagePush(ctx, name, query, newAuthHeader)
+		resp, err = cli.tryImagePush(ctx, name, action, query, newAuthHeader)
 	}
 	if err != nil {
 		return nil, err
@@ -49,7 +85,7 @@ func (cli *Client) ImagePush(ctx context.Context, image string, options types.Im
 	return resp.body, nil
 }
 
-func (cli *Client) tryImagePush(ctx context.Context, imageID string, query url.Values, registryAuth string) (serverResponse, error) {
+func (cli *Client) tryImagePush(ctx context.Context, imageID, action string, query url.Values, registryAuth string) (serverResponse, error) {
 	headers := map[string][]string{"X-Registry-Auth": {registryAuth}}
-	return cli.post(ctx, "/images/"+imageID+"/push", query, nil, headers)
+	return cli.post(ctx, "/images/"+imageID+"/"+action, query, nil, headers)
 }
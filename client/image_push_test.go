@@ -181,3 +181,60 @@ func TestImagePushWithoutErrors(t *testing.T) {
 		}
 	}
 }
+
+func TestImagePushWithFormat(t *testing.T) {
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			query := req.URL.Query()
+			if format := query.Get("format"); format != "oci" {
+				return nil, fmt.Errorf("format not set in URL query properly. Expected 'oci', got %s", format)
+			}
+			if tag := query.Get("tag"); tag != "tag" {
+				return nil, fmt.Errorf("tag not set in URL query properly. Expected 'tag', got %s", tag)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte("hello world"))),
+			}, nil
+		}),
+	}
+	resp, err := client.ImagePushWithFormat(context.Background(), "myimage:tag", "oci", types.ImagePushOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Close()
+}
+
+func TestImagePushManifestList(t *testing.T) {
+	expectedURL := "/images/myname/push-manifest-list"
+	client := &Client{
+		client: newMockClient(func(req *http.Request) (*http.Response, error) {
+			if !strings.HasPrefix(req.URL.Path, expectedURL) {
+				return nil, fmt.Errorf("Expected URL '%s', got '%s'", expectedURL, req.URL)
+			}
+			query := req.URL.Query()
+			if images := query["images"]; len(images) != 2 || images[0] != "image-amd64" || images[1] != "image-arm64" {
+				return nil, fmt.Errorf("images not set in URL query properly. Expected [image-amd64 image-arm64], got %v", images)
+			}
+			if format := query.Get("format"); format != "oci" {
+				return nil, fmt.Errorf("format not set in URL query properly. Expected 'oci', got %s", format)
+			}
+			if tag := query.Get("tag"); tag != "tag" {
+				return nil, fmt.Errorf("tag not set in URL query properly. Expected 'tag', got %s", tag)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte("hello world"))),
+			}, nil
+		}),
+	}
+	resp, err := client.ImagePushManifestList(context.Background(), "myname:tag", []string{"image-amd64", "image-arm64"}, "oci", types.ImagePushOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Close()
+
+	if _, err := client.ImagePushManifestList(context.Background(), "myname:tag", nil, "oci", types.ImagePushOptions{}); err == nil {
+		t.Fatal("expected an error without images")
+	}
+}
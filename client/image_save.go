@@ -9,9 +9,24 @@ import (
 // ImageSave retrieves one or more images from the docker host as an io.ReadCloser.
 // It's up to the caller to store the images and close the stream.
 func (cli *Client) ImageSave(ctx context.Context, imageIDs []string) (io.ReadCloser, error) {
-	query := url.Values{
-		"names": imageIDs,
+	return cli.imageSave(ctx, imageIDs, url.Values{})
+}
+
+// ImageSaveWithFormat retrieves one or more images from the docker host as an
+// io.ReadCloser, in the given archive format: "docker" for the legacy Docker
+// layout, or "oci" for the OCI image layout.
+// It's up to the caller to store the images and close the stream.
+func (cli *Client) ImageSaveWithFormat(ctx context.Context, imageIDs []string, format string) (io.ReadCloser, error) {
+	if err := cli.NewVersionError("1.40", "image save format"); err != nil {
+		return nil, err
 	}
+	query := url.Values{}
+	query.Set("format", format)
+	return cli.imageSave(ctx, imageIDs, query)
+}
+
+func (cli *Client) imageSave(ctx context.Context, imageIDs []string, query url.Values) (io.ReadCloser, error) {
+	query["names"] = imageIDs
 
 	resp, err := cli.get(ctx, "/images/get", query, nil)
 	if err != nil {
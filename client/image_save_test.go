@@ -59,3 +59,27 @@ func TestImageSave(t *testing.T) {
 		t.Fatalf("expected response to contain 'response', got %s", string(response))
 	}
 }
+
+func TestImageSaveWithFormat(t *testing.T) {
+	client := &Client{
+		version: "1.40",
+		client: newMockClient(func(r *http.Request) (*http.Response, error) {
+			query := r.URL.Query()
+			if format := query.Get("format"); format != "oci" {
+				return nil, fmt.Errorf("format not set in URL query properly. Expected 'oci', got %s", format)
+			}
+			if names := query["names"]; !reflect.DeepEqual(names, []string{"image_id1"}) {
+				return nil, fmt.Errorf("names not set in URL query properly. Expected [image_id1], got %v", names)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte("response"))),
+			}, nil
+		}),
+	}
+	saveResponse, err := client.ImageSaveWithFormat(context.Background(), []string{"image_id1"}, "oci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	saveResponse.Close()
+}
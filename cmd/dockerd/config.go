@@ -69,6 +69,8 @@ func installCommonConfigFlags(conf *config.Config, flags *pflag.FlagSet) error {
 	flags.StringVar(&conf.CorsHeaders, "api-cors-header", "", "Set CORS headers in the Engine API")
 	flags.IntVar(&maxConcurrentDownloads, "max-concurrent-downloads", config.DefaultMaxConcurrentDownloads, "Set the max concurrent downloads for each pull")
 	flags.IntVar(&maxConcurrentUploads, "max-concurrent-uploads", config.DefaultMaxConcurrentUploads, "Set the max concurrent uploads for each push")
+	flags.StringVar(&conf.LayerCompression, "layer-compression", "", "Compression of the pushed layers (gzip or zstd, zstd only applies to the oci format)")
+	flags.StringVar(&conf.TrustPolicy, "trust-policy", "", "Path to the trust policy of the pulled and run images")
 	flags.IntVar(&conf.ShutdownTimeout, "shutdown-timeout", defaultShutdownTimeout, "Set the default shutdown timeout")
 	flags.IntVar(&conf.NetworkDiagnosticPort, "network-diagnostic-port", 0, "TCP port number of the network diagnostic server")
 	flags.MarkHidden("network-diagnostic-port")
@@ -76,6 +78,15 @@ func installCommonConfigFlags(conf *config.Config, flags *pflag.FlagSet) error {
 	flags.StringVar(&conf.SwarmDefaultAdvertiseAddr, "swarm-default-advertise-addr", "", "Set default address or interface for swarm advertised address")
 	flags.BoolVar(&conf.Experimental, "experimental", false, "Enable experimental features")
 	flags.StringVar(&conf.MetricsAddress, "metrics-addr", "", "Set default address and port to serve the metrics api on")
+	flags.Var(&conf.EventsJournalMaxSize, "events-journal-max-size", "Maximum size of the on-disk events journal (0 disables the journal)")
+	flags.StringVar(&conf.EventsJournalMaxAge, "events-journal-max-age", "", "Maximum age of the events kept in the events journal")
+	flags.BoolVar(&conf.ContainerMetrics, "container-metrics", false, "Export per-container resource metrics on the metrics address")
+	flags.Var(opts.NewNamedListOptsRef("container-metrics-labels", &conf.ContainerMetricsLabels, nil), "container-metrics-label", "Container label to add to the per-container metrics")
+	flags.IntVar(&conf.ContainerMetricsMaxContainers, "container-metrics-max-containers", 0, "Maximum number of containers with per-container metrics (0 is unlimited)")
+	flags.StringVar(&conf.StatsHistoryRetention, "stats-history-retention", "", "How long the stats history of the containers is kept (disabled if empty)")
+	flags.StringVar(&conf.StatsHistoryResolution, "stats-history-resolution", "", "Interval between two samples of the stats history")
+	flags.BoolVar(&conf.StatsHistoryPersist, "stats-history-persist", false, "Keep the stats history on disk")
+	flags.Var(opts.NewNamedMapOpts("restart-policy-opts", conf.RestartPolicyOpts, nil), "restart-policy-opt", "Default restart backoff options for containers")
 
 	flags.Var(opts.NewNamedListOptsRef("node-generic-resources", &conf.NodeGenericResources, opts.ValidateSingleGenericResource), "node-generic-resource", "Advertise user-defined resource")
 
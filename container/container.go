@@ -23,6 +23,7 @@ import (
 	"github.com/docker/docker/daemon/logger"
 	"github.com/docker/docker/daemon/logger/jsonfilelog"
 	"github.com/docker/docker/daemon/logger/local"
+	"github.com/docker/docker/daemon/logger/loggerutils/cache"
 	"github.com/docker/docker/daemon/network"
 	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/image"
@@ -88,6 +89,7 @@ type Container struct {
 	DependencyStore        agentexec.DependencyGetter `json:"-"`
 	SecretReferences       []*swarmtypes.SecretReference
 	ConfigReferences       []*swarmtypes.ConfigReference
+	LogFilePaths           []string `json:",omitempty"` // files written by the logging drivers when the container was last started
 	// logDriver for closing
 	LogDriver      logger.Logger  `json:"-"`
 	LogCopier      *logger.Copier `json:"-"`
@@ -355,13 +357,107 @@ func (container *Container) CheckpointDir() string {
 	return filepath.Join(container.Root, "checkpoints")
 }
 
+// LogFiles returns the files written by the logging drivers of the
+// container, including the rotated files and the local cache of the drivers
+// which can't read logs back. The drivers which don't write to local files
+// have none.
+func (container *Container) LogFiles() ([]string, error) {
+	paths := container.LogFilePaths
+	if paths == nil && container.LogPath != "" {
+		// the container was last started before the log file paths were
+		// recorded
+		paths = []string{container.LogPath}
+	}
+	var files []string
+	for _, p := range paths {
+		matches, err := filepath.Glob(p + "*")
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
 // StartLogger starts a new logger driver for the container.
 func (container *Container) StartLogger() (logger.Logger, error) {
 	cfg := container.HostConfig.LogConfig
-	initDriver, err := logger.GetLogDriver(cfg.Type)
+	l, info, err := container.startLogDriver(cfg, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(container.HostConfig.AdditionalLogConfigs) > 0 {
+		// Each destination gets its own ring buffer, so that a slow one
+		// doesn't block the others, nor the container.
+		loggers := make([]logger.Logger, 0, len(container.HostConfig.AdditionalLogConfigs)+1)
+		closeLoggers := func() {
+			for _, l := range loggers {
+				l.Close()
+			}
+		}
+		rl, err := withRingBuffer(l, info, cfg)
+		if err != nil {
+			l.Close()
+			return nil, err
+		}
+		loggers = append(loggers, rl)
+		for i, extraCfg := range container.HostConfig.AdditionalLogConfigs {
+			el, extraInfo, err := container.startLogDriver(extraCfg, i+1)
+			if err != nil {
+				closeLoggers()
+				return nil, errors.Wrapf(err, "failed to start additional log driver %s", extraCfg.Type)
+			}
+			rl, err := withRingBuffer(el, extraInfo, extraCfg)
+			if err != nil {
+				el.Close()
+				closeLoggers()
+				return nil, err
+			}
+			loggers = append(loggers, rl)
+		}
+		l = logger.NewMultiLogger(loggers...)
+	} else if containertypes.LogMode(cfg.Config["mode"]) == containertypes.LogModeNonBlock {
+		if l, err = withRingBuffer(l, info, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	// Keep a local copy of the logs of the drivers which can't read them
+	// back, so that they can still be read with "docker logs".
+	if _, ok := l.(logger.LogReader); !ok && cache.ShouldUseCache(cfg.Config) {
+		logPath, err := container.GetRootResourcePath("container-cached.log")
+		if err != nil {
+			l.Close()
+			return nil, err
+		}
+		info.LogPath = logPath
+		cached, err := cache.WithLocalCache(l, info)
+		if err != nil {
+			l.Close()
+			return nil, err
+		}
+		logrus.WithField("container", container.ID).WithField("driver", cfg.Type).Debug("log driver does not support reads, enabling local file cache for container logs")
+		l = cached
+	}
+
+	// Rate limits and sampling apply before the messages reach any of the
+	// drivers, their buffers or the cache.
+	limit, err := logger.ParseLimitConfig(cfg.Config)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to get logging factory")
+		l.Close()
+		return nil, err
+	}
+	if limit != nil {
+		l = logger.NewLimitedLogger(l, limit)
 	}
+	return l, nil
+}
+
+// startLogDriver starts the log driver of the given configuration. index is
+// the position of the configuration in the logging destinations of the
+// container, 0 being HostConfig.LogConfig, and is used to name log files.
+func (container *Container) startLogDriver(cfg containertypes.LogConfig, index int) (logger.Logger, logger.Info, error) {
 	info := logger.Info{
 		Config:              cfg.Config,
 		ContainerID:         container.ID,
@@ -375,47 +471,63 @@ func (container *Container) StartLogger() (logger.Logger, error) {
 		ContainerLabels:     container.Config.Labels,
 		DaemonName:          "docker",
 	}
+	initDriver, err := logger.GetLogDriver(cfg.Type)
+	if err != nil {
+		return nil, info, errors.Wrap(err, "failed to get logging factory")
+	}
 
 	// Set logging file for "json-logger"
 	// TODO(@cpuguy83): Setup here based on log driver is a little weird.
 	switch cfg.Type {
 	case jsonfilelog.Name:
-		info.LogPath, err = container.GetRootResourcePath(fmt.Sprintf("%s-json.log", container.ID))
+		name := fmt.Sprintf("%s-json.log", container.ID)
+		if index > 0 {
+			name = fmt.Sprintf("%s-json.%d.log", container.ID, index)
+		}
+		info.LogPath, err = container.GetRootResourcePath(name)
 		if err != nil {
-			return nil, err
+			return nil, info, err
 		}
 
-		container.LogPath = info.LogPath
+		if index == 0 {
+			container.LogPath = info.LogPath
+		}
 	case local.Name:
 		// Do not set container.LogPath for the local driver
 		// This would expose the value to the API, which should not be done as it means
 		// that the log file implementation would become a stable API that cannot change.
 		logDir, err := container.GetRootResourcePath("local-logs")
 		if err != nil {
-			return nil, err
+			return nil, info, err
 		}
 		if err := os.MkdirAll(logDir, 0700); err != nil {
-			return nil, errdefs.System(errors.Wrap(err, "error creating local logs dir"))
+			return nil, info, errdefs.System(errors.Wrap(err, "error creating local logs dir"))
 		}
 		info.LogPath = filepath.Join(logDir, "container.log")
+		if index > 0 {
+			info.LogPath = filepath.Join(logDir, fmt.Sprintf("container.%d.log", index))
+		}
 	}
 
 	l, err := initDriver(info)
 	if err != nil {
-		return nil, err
+		return nil, info, err
 	}
+	return l, info, nil
+}
 
-	if containertypes.LogMode(cfg.Config["mode"]) == containertypes.LogModeNonBlock {
-		bufferSize := int64(-1)
-		if s, exists := cfg.Config["max-buffer-size"]; exists {
-			bufferSize, err = units.RAMInBytes(s)
-			if err != nil {
-				return nil, err
-			}
+// withRingBuffer wraps the logger in a ring buffer of the size configured
+// with the "max-buffer-size" log option.
+func withRingBuffer(l logger.Logger, info logger.Info, cfg containertypes.LogConfig) (logger.Logger, error) {
+	bufferSize := int64(-1)
+	if s, exists := cfg.Config["max-buffer-size"]; exists {
+		var err error
+		bufferSize, err = units.RAMInBytes(s)
+		if err != nil {
+			return nil, err
 		}
-		l = logger.NewRingLogger(l, info, bufferSize)
 	}
-	return l, nil
+	return logger.NewRingLogger(l, info, bufferSize), nil
 }
 
 // GetProcessLabel returns the process label for the container.
@@ -616,10 +728,21 @@ func (container *Container) startLogging() error {
 		return fmt.Errorf("failed to initialize logging driver: %v", err)
 	}
 
+	multiline, err := logger.ParseMultilineConfig(container.HostConfig.LogConfig.Config)
+	if err != nil {
+		l.Close()
+		return fmt.Errorf("failed to initialize logging driver: %v", err)
+	}
+
 	copier := logger.NewCopier(map[string]io.Reader{"stdout": container.StdoutPipe(), "stderr": container.StderrPipe()}, l)
+	copier.SetMultiline(multiline)
 	container.LogCopier = copier
 	copier.Run()
 	container.LogDriver = l
+	container.LogFilePaths = nil
+	if lr, ok := l.(logger.LogFilesReporter); ok {
+		container.LogFilePaths = lr.LogFiles()
+	}
 
 	return nil
 }
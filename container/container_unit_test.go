@@ -9,9 +9,12 @@ import (
 
 	"github.com/docker/docker/api/types/container"
 	swarmtypes "github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/daemon/logger"
 	"github.com/docker/docker/daemon/logger/jsonfilelog"
+	"github.com/docker/docker/daemon/logger/local"
 	"github.com/docker/docker/pkg/signal"
 	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
 )
 
 func TestContainerStopSignal(t *testing.T) {
@@ -97,6 +100,40 @@ func TestContainerLogPathSetForJSONFileLogger(t *testing.T) {
 	assert.Equal(t, c.LogPath, expectedLogPath)
 }
 
+func TestContainerLogFiles(t *testing.T) {
+	containerRoot, err := ioutil.TempDir("", "TestContainerLogFiles")
+	assert.NilError(t, err)
+	defer os.RemoveAll(containerRoot)
+
+	c := &Container{
+		Config: &container.Config{},
+		HostConfig: &container.HostConfig{
+			LogConfig:            container.LogConfig{Type: jsonfilelog.Name},
+			AdditionalLogConfigs: []container.LogConfig{{Type: local.Name}},
+		},
+		ID:   "TestContainerLogFiles",
+		Root: containerRoot,
+	}
+
+	l, err := c.StartLogger()
+	assert.NilError(t, err)
+	defer l.Close()
+
+	lr, ok := l.(logger.LogFilesReporter)
+	assert.Assert(t, ok)
+	jsonLogPath := filepath.Join(containerRoot, c.ID+"-json.log")
+	localLogPath := filepath.Join(containerRoot, "local-logs", "container.1.log")
+	assert.Check(t, is.DeepEqual(lr.LogFiles(), []string{jsonLogPath, localLogPath}))
+
+	c.LogFilePaths = lr.LogFiles()
+	assert.NilError(t, ioutil.WriteFile(jsonLogPath+".1", nil, 0600))
+	files, err := c.LogFiles()
+	assert.NilError(t, err)
+	for _, f := range []string{jsonLogPath, jsonLogPath + ".1", localLogPath} {
+		assert.Check(t, is.Contains(files, f))
+	}
+}
+
 func TestContainerLogPathSetForRingLogger(t *testing.T) {
 	containerRoot, err := ioutil.TempDir("", "TestContainerLogPathSetForRingLogger")
 	assert.NilError(t, err)
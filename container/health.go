@@ -2,6 +2,7 @@ package container // import "github.com/docker/docker/container"
 
 import (
 	"sync"
+	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/sirupsen/logrus"
@@ -12,6 +13,8 @@ type Health struct {
 	types.Health
 	stop chan struct{} // Write struct{} to stop the monitor
 	mu   sync.Mutex
+
+	unhealthySince time.Time // When the status last changed to unhealthy
 }
 
 // String returns a human-readable description of the health-check state
@@ -21,6 +24,12 @@ func (s *Health) String() string {
 	switch status {
 	case types.Starting:
 		return "health: starting"
+	case types.NoHealthcheck:
+		// only startup or readiness probes are configured
+		if readiness := s.ReadinessStatus(); readiness != "" {
+			return readiness
+		}
+		return "startup: " + s.StartupStatus()
 	default: // Healthy and Unhealthy are clear on their own
 		return s.Health.Status
 	}
@@ -49,9 +58,78 @@ func (s *Health) SetStatus(new string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if new == types.Unhealthy {
+		if s.Health.Status != types.Unhealthy || s.unhealthySince.IsZero() {
+			s.unhealthySince = time.Now()
+		}
+	} else {
+		s.unhealthySince = time.Time{}
+	}
 	s.Health.Status = new
 }
 
+// UnhealthySince returns the time at which the status was set to unhealthy,
+// or the zero time if the container is not unhealthy.
+func (s *Health) UnhealthySince() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.unhealthySince
+}
+
+// StartupStatus returns the current status of the startup probe, or an empty
+// string if the container has no startup probe.
+func (s *Health) StartupStatus() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Health.Startup == nil {
+		return ""
+	}
+	return s.Health.Startup.Status
+}
+
+// SetStartupStatus writes the current status of the startup probe.
+func (s *Health) SetStartupStatus(new string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Health.Startup == nil {
+		s.Health.Startup = &types.ProbeStatus{}
+	}
+	s.Health.Startup.Status = new
+}
+
+// Started returns whether the startup probe succeeded, or true if the
+// container has no startup probe.
+func (s *Health) Started() bool {
+	status := s.StartupStatus()
+	return status == "" || status == types.Started
+}
+
+// ReadinessStatus returns the current status of the readiness probe, or an
+// empty string if the container has no readiness probe.
+func (s *Health) ReadinessStatus() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Health.Readiness == nil {
+		return ""
+	}
+	return s.Health.Readiness.Status
+}
+
+// SetReadinessStatus writes the current status of the readiness probe.
+func (s *Health) SetReadinessStatus(new string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Health.Readiness == nil {
+		s.Health.Readiness = &types.ProbeStatus{}
+	}
+	s.Health.Readiness.Status = new
+}
+
 // OpenMonitorChannel creates and returns a new monitor channel. If there
 // already is one, it returns nil.
 func (s *Health) OpenMonitorChannel() chan struct{} {
@@ -66,7 +144,8 @@ func (s *Health) OpenMonitorChannel() chan struct{} {
 	return nil
 }
 
-// CloseMonitorChannel closes any existing monitor channel.
+// CloseMonitorChannel closes any existing monitor channel. The readiness of
+// the container must be updated by the caller, see State.SetReady.
 func (s *Health) CloseMonitorChannel() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -76,7 +155,12 @@ func (s *Health) CloseMonitorChannel() {
 		close(s.stop)
 		s.stop = nil
 		// unhealthy when the monitor has stopped for compatibility reasons
-		s.Health.Status = types.Unhealthy
+		if s.Health.Status != types.NoHealthcheck {
+			s.Health.Status = types.Unhealthy
+		}
+		if s.Health.Readiness != nil {
+			s.Health.Readiness.Status = types.NotReady
+		}
 		logrus.Debug("CloseMonitorChannel done")
 	}
 }
@@ -31,10 +31,23 @@ type State struct {
 	ErrorMsg          string `json:"Error"` // contains last known error during container start, stop, or remove
 	StartedAt         time.Time
 	FinishedAt        time.Time
+	NextRestartAt     time.Time // when a restarting container is scheduled to start again
 	Health            *Health
+	OOMKillCount      int      // number of processes killed by the OOM killer since the container was created
+	LastOOMKill       *OOMKill // last process killed by the OOM killer
 
 	waitStop   chan struct{}
 	waitRemove chan struct{}
+	waitReady  chan struct{}
+	ready      bool
+}
+
+// OOMKill stores information about a process of the container killed by the
+// OOM killer. Pid and Process are not set if they are not known.
+type OOMKill struct {
+	Time    time.Time
+	Pid     int
+	Process string
 }
 
 // StateStatus is used to return container wait results.
@@ -62,6 +75,7 @@ func NewState() *State {
 	return &State{
 		waitStop:   make(chan struct{}),
 		waitRemove: make(chan struct{}),
+		waitReady:  make(chan struct{}),
 	}
 }
 
@@ -164,10 +178,14 @@ type WaitCondition int
 // or is removed.
 //
 // WaitConditionRemoved is used to wait for the container to be removed.
+//
+// WaitConditionReady is used to wait for the container to be ready. Wait()
+// returns an error if the container stops or is removed before it is ready.
 const (
 	WaitConditionNotRunning WaitCondition = iota
 	WaitConditionNextExit
 	WaitConditionRemoved
+	WaitConditionReady
 )
 
 // Wait waits until the container is in a certain state indicated by the given
@@ -195,13 +213,25 @@ func (s *State) Wait(ctx context.Context, condition WaitCondition) <-chan StateS
 		return resultC
 	}
 
+	if condition == WaitConditionReady && s.ready {
+		resultC := make(chan StateStatus, 1)
+		resultC <- StateStatus{exitCode: s.ExitCode()}
+		return resultC
+	}
+
 	// If we are waiting only for removal, the waitStop channel should
 	// remain nil and block forever.
 	var waitStop chan struct{}
-	if condition < WaitConditionRemoved {
+	if condition < WaitConditionRemoved || condition == WaitConditionReady {
 		waitStop = s.waitStop
 	}
 
+	// Only wait for readiness if it was requested.
+	var waitReady chan struct{}
+	if condition == WaitConditionReady {
+		waitReady = s.waitReady
+	}
+
 	// Always wait for removal, just in case the container gets removed
 	// while it is still in a "created" state, in which case it is never
 	// actually stopped.
@@ -210,6 +240,7 @@ func (s *State) Wait(ctx context.Context, condition WaitCondition) <-chan StateS
 	resultC := make(chan StateStatus)
 
 	go func() {
+		ready := false
 		select {
 		case <-ctx.Done():
 			// Context timeout or cancellation.
@@ -220,6 +251,8 @@ func (s *State) Wait(ctx context.Context, condition WaitCondition) <-chan StateS
 			return
 		case <-waitStop:
 		case <-waitRemove:
+		case <-waitReady:
+			ready = true
 		}
 
 		s.Lock()
@@ -228,6 +261,9 @@ func (s *State) Wait(ctx context.Context, condition WaitCondition) <-chan StateS
 			err:      s.Err(),
 		}
 		s.Unlock()
+		if condition == WaitConditionReady && !ready {
+			result.err = errors.New("container stopped before it was ready")
+		}
 
 		resultC <- result
 	}()
@@ -274,6 +310,7 @@ func (s *State) SetRunning(pid int, initial bool) {
 	}
 	s.ExitCodeValue = 0
 	s.Pid = pid
+	s.NextRestartAt = time.Time{}
 	if initial {
 		s.StartedAt = time.Now().UTC()
 	}
@@ -285,6 +322,8 @@ func (s *State) SetStopped(exitStatus *ExitStatus) {
 	s.Paused = false
 	s.Restarting = false
 	s.Pid = 0
+	s.NextRestartAt = time.Time{}
+	s.ready = false
 	if exitStatus.ExitedAt.IsZero() {
 		s.FinishedAt = time.Now().UTC()
 	} else {
@@ -305,6 +344,7 @@ func (s *State) SetRestarting(exitStatus *ExitStatus) {
 	s.Restarting = true
 	s.Paused = false
 	s.Pid = 0
+	s.ready = false
 	s.FinishedAt = time.Now().UTC()
 	s.ExitCodeValue = exitStatus.ExitCode
 	s.OOMKilled = exitStatus.OOMKilled
@@ -312,6 +352,25 @@ func (s *State) SetRestarting(exitStatus *ExitStatus) {
 	s.waitStop = make(chan struct{})
 }
 
+// SetReady sets whether the container is ready to take traffic, and fires the
+// waiters for readiness when it becomes ready. Take lock before if state may
+// be shared.
+func (s *State) SetReady(ready bool) {
+	if ready && !s.ready {
+		if s.waitReady != nil {
+			close(s.waitReady)
+		}
+		s.waitReady = make(chan struct{})
+	}
+	s.ready = ready
+}
+
+// IsReady returns whether the container is ready to take traffic. Take lock
+// before if state may be shared.
+func (s *State) IsReady() bool {
+	return s.ready
+}
+
 // SetError sets the container's error state. This is useful when we want to
 // know the error that occurred when container transits to another state
 // when inspecting it
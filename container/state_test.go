@@ -167,6 +167,61 @@ func TestStateTimeoutWait(t *testing.T) {
 	}
 }
 
+func TestStateWaitReady(t *testing.T) {
+	s := NewState()
+
+	s.Lock()
+	s.SetRunning(0, true)
+	s.Unlock()
+
+	waitC := s.Wait(context.Background(), WaitConditionReady)
+	select {
+	case <-waitC:
+		t.Fatal("Wait for ready returned before the container was ready")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	s.Lock()
+	s.SetReady(true)
+	s.Unlock()
+
+	select {
+	case <-time.After(200 * time.Millisecond):
+		t.Fatal("Ready callback doesn't fire in 200 milliseconds")
+	case status := <-waitC:
+		if status.Err() != nil {
+			t.Fatalf("expected no error, got %v", status.Err())
+		}
+	}
+
+	// Already ready, return immediately.
+	status := <-s.Wait(context.Background(), WaitConditionReady)
+	if status.Err() != nil {
+		t.Fatalf("expected no error, got %v", status.Err())
+	}
+
+	s.Lock()
+	s.SetReady(false)
+	s.Unlock()
+	waitC = s.Wait(context.Background(), WaitConditionReady)
+
+	s.Lock()
+	s.SetStopped(&ExitStatus{ExitCode: 1})
+	s.Unlock()
+
+	select {
+	case <-time.After(200 * time.Millisecond):
+		t.Fatal("Stop callback doesn't fire in 200 milliseconds")
+	case status := <-waitC:
+		if status.Err() == nil {
+			t.Fatal("expected an error when the container stops before being ready")
+		}
+		if status.ExitCode() != 1 {
+			t.Fatalf("expected exit code %v, got %v", 1, status.ExitCode())
+		}
+	}
+}
+
 func TestIsValidStateString(t *testing.T) {
 	states := []struct {
 		state    string
@@ -8,11 +8,15 @@ import (
 	"io/ioutil"
 	"os"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
+	containertypes "github.com/docker/docker/api/types/container"
 	daemondiscovery "github.com/docker/docker/daemon/discovery"
 	"github.com/docker/docker/opts"
+	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/authorization"
 	"github.com/docker/docker/pkg/discovery"
 	"github.com/docker/docker/registry"
@@ -42,6 +46,12 @@ const (
 	DisableNetworkBridge = "none"
 	// DefaultInitBinary is the name of the default init binary
 	DefaultInitBinary = "docker-init"
+	// DefaultStatsHistoryResolution is the default interval between two
+	// samples of the stats history.
+	DefaultStatsHistoryResolution = 10 * time.Second
+	// MaxStatsHistorySamples is the maximum number of samples of the stats
+	// history kept for each container, i.e. retention / resolution.
+	MaxStatsHistorySamples = 100000
 )
 
 // flatOptions contains configuration keys
@@ -49,12 +59,13 @@ const (
 // Use this to differentiate these options
 // with others like the ones in CommonTLSOptions.
 var flatOptions = map[string]bool{
-	"cluster-store-opts": true,
-	"log-opts":           true,
-	"runtimes":           true,
-	"default-ulimits":    true,
-	"features":           true,
-	"builder":            true,
+	"cluster-store-opts":  true,
+	"log-opts":            true,
+	"restart-policy-opts": true,
+	"runtimes":            true,
+	"default-ulimits":     true,
+	"features":            true,
+	"builder":             true,
 }
 
 // skipValidateOptions contains configuration keys
@@ -160,6 +171,15 @@ type CommonConfig struct {
 	// may take place at a time for each push.
 	MaxConcurrentUploads *int `json:"max-concurrent-uploads,omitempty"`
 
+	// LayerCompression is the compression of the layers pushed to the
+	// registries, either "gzip" (the default) or "zstd". zstd only applies
+	// to the images pushed in the oci format.
+	LayerCompression string `json:"layer-compression,omitempty"`
+
+	// TrustPolicy is the path of the trust policy the pulled images, and
+	// the images of the created containers, are checked against.
+	TrustPolicy string `json:"trust-policy,omitempty"`
+
 	// ShutdownTimeout is the timeout value (in seconds) the daemon will wait for the container
 	// to stop when daemon is being shutdown
 	ShutdownTimeout int `json:"shutdown-timeout,omitempty"`
@@ -192,6 +212,44 @@ type CommonConfig struct {
 
 	MetricsAddress string `json:"metrics-addr"`
 
+	// EventsJournalMaxSize is the maximum size of the on-disk events journal.
+	// The journal is disabled when it is zero.
+	EventsJournalMaxSize opts.MemBytes `json:"events-journal-max-size,omitempty"`
+
+	// EventsJournalMaxAge is the maximum age of the events kept in the
+	// on-disk events journal, as a duration string (e.g. "168h").
+	EventsJournalMaxAge string `json:"events-journal-max-age,omitempty"`
+
+	// ContainerMetrics enables the per-container resource metrics on the
+	// metrics address.
+	ContainerMetrics bool `json:"container-metrics,omitempty"`
+
+	// ContainerMetricsLabels are the container labels added as labels to the
+	// per-container metrics.
+	ContainerMetricsLabels []string `json:"container-metrics-labels,omitempty"`
+
+	// ContainerMetricsMaxContainers is the maximum number of containers for
+	// which per-container metrics are exported, 0 meaning unlimited.
+	ContainerMetricsMaxContainers int `json:"container-metrics-max-containers,omitempty"`
+
+	// StatsHistoryRetention is how long the stats history of the containers
+	// is kept, as a duration string (e.g. "1h"). The history is disabled
+	// when it is empty.
+	StatsHistoryRetention string `json:"stats-history-retention,omitempty"`
+
+	// StatsHistoryResolution is the interval between two samples of the
+	// stats history, as a duration string (e.g. "10s").
+	StatsHistoryResolution string `json:"stats-history-resolution,omitempty"`
+
+	// StatsHistoryPersist keeps the stats history on disk, so that it
+	// survives daemon restarts.
+	StatsHistoryPersist bool `json:"stats-history-persist,omitempty"`
+
+	// RestartPolicyOpts are the default restart backoff options for
+	// containers (initial-delay, max-delay, multiplier, jitter and
+	// reset-after).
+	RestartPolicyOpts map[string]string `json:"restart-policy-opts,omitempty"`
+
 	LogConfig
 	BridgeConfig // bridgeConfig holds bridge network specific configuration.
 	NetworkConfig
@@ -239,6 +297,7 @@ func New() *Config {
 	config := Config{}
 	config.LogConfig.Config = make(map[string]string)
 	config.ClusterOpts = make(map[string]string)
+	config.RestartPolicyOpts = make(map[string]string)
 
 	return &config
 }
@@ -550,6 +609,53 @@ func Validate(config *Config) error {
 		return fmt.Errorf("invalid max concurrent uploads: %d", *config.MaxConcurrentUploads)
 	}
 
+	// validate LayerCompression
+	switch config.LayerCompression {
+	case "", "gzip":
+	case "zstd":
+		if err := archive.CheckZstd(); err != nil {
+			return fmt.Errorf("invalid layer compression: %v", err)
+		}
+	default:
+		return fmt.Errorf("invalid layer compression: %q, must be gzip or zstd", config.LayerCompression)
+	}
+
+	// validate EventsJournalMaxSize
+	if config.EventsJournalMaxSize < 0 {
+		return fmt.Errorf("invalid events journal max size: %d", config.EventsJournalMaxSize)
+	}
+	// validate EventsJournalMaxAge
+	if config.EventsJournalMaxAge != "" {
+		if d, err := time.ParseDuration(config.EventsJournalMaxAge); err != nil || d < 0 {
+			return fmt.Errorf("invalid events journal max age: %q", config.EventsJournalMaxAge)
+		}
+	}
+
+	// validate ContainerMetricsMaxContainers
+	if config.ContainerMetricsMaxContainers < 0 {
+		return fmt.Errorf("invalid container metrics max containers: %d", config.ContainerMetricsMaxContainers)
+	}
+
+	// validate StatsHistoryRetention and StatsHistoryResolution
+	if config.StatsHistoryRetention != "" {
+		if d, err := time.ParseDuration(config.StatsHistoryRetention); err != nil || d < 0 {
+			return fmt.Errorf("invalid stats history retention: %q", config.StatsHistoryRetention)
+		}
+	}
+	if config.StatsHistoryResolution != "" {
+		if d, err := time.ParseDuration(config.StatsHistoryResolution); err != nil || d < time.Second {
+			return fmt.Errorf("invalid stats history resolution: %q, must be at least 1s", config.StatsHistoryResolution)
+		}
+	}
+	if retention, resolution := config.GetStatsHistoryRetention(), config.GetStatsHistoryResolution(); retention/resolution > MaxStatsHistorySamples {
+		return fmt.Errorf("invalid stats history retention: %s with a resolution of %s keeps more than %d samples per container", retention, resolution, MaxStatsHistorySamples)
+	}
+
+	// validate RestartPolicyOpts
+	if _, err := ParseRestartPolicyOpts(config.RestartPolicyOpts); err != nil {
+		return err
+	}
+
 	// validate that "default" runtime is not reset
 	if runtimes := config.GetAllRuntimes(); len(runtimes) > 0 {
 		if _, ok := runtimes[StockRuntimeName]; ok {
@@ -572,6 +678,90 @@ func Validate(config *Config) error {
 	return config.ValidatePlatformConfig()
 }
 
+// GetEventsJournalMaxAge returns the maximum age of the events kept in the
+// events journal. Zero means that events are not expired by age.
+func (conf *Config) GetEventsJournalMaxAge() time.Duration {
+	d, err := time.ParseDuration(conf.EventsJournalMaxAge)
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
+}
+
+// GetStatsHistoryRetention returns how long the stats history of the
+// containers is kept. Zero means that the history is disabled.
+func (conf *Config) GetStatsHistoryRetention() time.Duration {
+	d, err := time.ParseDuration(conf.StatsHistoryRetention)
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
+}
+
+// GetLayerCompression returns the compression of the pushed layers.
+func (conf *Config) GetLayerCompression() archive.Compression {
+	if conf.LayerCompression == "zstd" {
+		return archive.Zstd
+	}
+	return archive.Gzip
+}
+
+// GetStatsHistoryResolution returns the interval between two samples of the
+// stats history.
+func (conf *Config) GetStatsHistoryResolution() time.Duration {
+	d, err := time.ParseDuration(conf.StatsHistoryResolution)
+	if err != nil || d <= 0 {
+		return DefaultStatsHistoryResolution
+	}
+	return d
+}
+
+// ParseRestartPolicyOpts parses the default restart backoff options into a
+// restart policy. Options that are not set are left to zero.
+func ParseRestartPolicyOpts(opts map[string]string) (containertypes.RestartPolicy, error) {
+	var (
+		policy containertypes.RestartPolicy
+		err    error
+	)
+	for k, v := range opts {
+		switch k {
+		case "initial-delay", "max-delay", "reset-after":
+			var d time.Duration
+			d, err = time.ParseDuration(v)
+			if err == nil && d < 0 {
+				err = errors.New("must not be negative")
+			}
+			switch k {
+			case "initial-delay":
+				policy.InitialDelay = d
+			case "max-delay":
+				policy.MaxDelay = d
+			default:
+				policy.ResetAfter = d
+			}
+		case "multiplier":
+			policy.Multiplier, err = strconv.ParseFloat(v, 64)
+			if err == nil && policy.Multiplier < 1 {
+				err = errors.New("must be at least 1")
+			}
+		case "jitter":
+			policy.Jitter, err = strconv.ParseFloat(v, 64)
+			if err == nil && (policy.Jitter < 0 || policy.Jitter > 1) {
+				err = errors.New("must be between 0 and 1")
+			}
+		default:
+			return policy, fmt.Errorf("unknown restart policy option: %s", k)
+		}
+		if err != nil {
+			return policy, errors.Wrapf(err, "invalid restart policy option %s=%s", k, v)
+		}
+	}
+	if policy.MaxDelay != 0 && policy.InitialDelay > policy.MaxDelay {
+		return policy, errors.New("invalid restart policy options: initial-delay must not be greater than max-delay")
+	}
+	return policy, nil
+}
+
 // ModifiedDiscoverySettings returns whether the discovery configuration has been modified or not.
 func ModifiedDiscoverySettings(config *Config, backendType, advertise string, clusterOpts map[string]string) bool {
 	if config.ClusterStore != backendType || config.ClusterAdvertise != advertise {
@@ -3,6 +3,7 @@ package config // import "github.com/docker/docker/daemon/config"
 import (
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"strings"
 	"testing"
 
@@ -291,6 +292,28 @@ func TestValidateConfigurationErrors(t *testing.T) {
 				},
 			},
 		},
+		{
+			config: &Config{
+				CommonConfig: CommonConfig{
+					LayerCompression: "xz",
+				},
+			},
+		},
+		{
+			config: &Config{
+				CommonConfig: CommonConfig{
+					StatsHistoryRetention:  "720h",
+					StatsHistoryResolution: "1s",
+				},
+			},
+		},
+		{
+			config: &Config{
+				CommonConfig: CommonConfig{
+					StatsHistoryRetention: "720h",
+				},
+			},
+		},
 		{
 			config: &Config{
 				CommonConfig: CommonConfig{
@@ -314,11 +337,42 @@ func TestValidateConfigurationErrors(t *testing.T) {
 	}
 }
 
+func TestValidateLayerCompressionZstd(t *testing.T) {
+	config := &Config{
+		CommonConfig: CommonConfig{
+			LayerCompression: "zstd",
+		},
+	}
+	if _, err := exec.LookPath("zstd"); err == nil {
+		assert.Check(t, Validate(config))
+	}
+
+	// the daemon fails to start if the zstd binary is missing
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", "")
+	assert.Check(t, is.ErrorContains(Validate(config), "requires the zstd binary"))
+}
+
 func TestValidateConfiguration(t *testing.T) {
 	minusNumber := 4
 	testCases := []struct {
 		config *Config
 	}{
+		{
+			config: &Config{
+				CommonConfig: CommonConfig{
+					LayerCompression: "gzip",
+				},
+			},
+		},
+		{
+			config: &Config{
+				CommonConfig: CommonConfig{
+					StatsHistoryRetention:  "24h",
+					StatsHistoryResolution: "1s",
+				},
+			},
+		},
 		{
 			config: &Config{
 				CommonConfig: CommonConfig{
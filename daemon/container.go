@@ -12,6 +12,7 @@ import (
 	containertypes "github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/strslice"
 	"github.com/docker/docker/container"
+	"github.com/docker/docker/daemon/config"
 	"github.com/docker/docker/daemon/network"
 	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/image"
@@ -124,6 +125,7 @@ func (daemon *Daemon) Register(c *container.Container) error {
 
 	daemon.containers.Add(c.ID, c)
 	daemon.idIndex.Add(c.ID)
+	daemon.trackResourceAlerts(c)
 	return c.CheckpointTo(daemon.containersReplica)
 }
 
@@ -228,6 +230,7 @@ func (daemon *Daemon) setHostConfig(container *container.Container, hostConfig *
 
 	runconfig.SetDefaultNetModeIfBlank(hostConfig)
 	container.HostConfig = hostConfig
+	daemon.trackResourceAlerts(container)
 	return container.CheckpointTo(daemon.containersReplica)
 }
 
@@ -268,7 +271,16 @@ func validateContainerConfig(config *containertypes.Config, platform string) err
 			return err
 		}
 	}
-	return validateHealthCheck(config.Healthcheck)
+	if err := validateHealthCheck(config.Healthcheck); err != nil {
+		return err
+	}
+	if err := validateHealthCheck(config.StartupProbe); err != nil {
+		return errors.Wrap(err, "invalid StartupProbe")
+	}
+	if err := validateHealthCheck(config.ReadinessProbe); err != nil {
+		return errors.Wrap(err, "invalid ReadinessProbe")
+	}
+	return nil
 }
 
 func validateHostConfig(hostConfig *containertypes.HostConfig, platform string) error {
@@ -296,9 +308,17 @@ func validateHostConfig(hostConfig *containertypes.HostConfig, platform string)
 	if err := validateRestartPolicy(hostConfig.RestartPolicy); err != nil {
 		return err
 	}
+	for _, dep := range hostConfig.DependsOn {
+		if _, _, err := parseDependency(dep); err != nil {
+			return err
+		}
+	}
 	if err := validateCapabilities(hostConfig); err != nil {
 		return err
 	}
+	if err := validateResourceAlerts(hostConfig.ResourceAlerts); err != nil {
+		return err
+	}
 	if !hostConfig.Isolation.IsValid() {
 		return errors.Errorf("invalid isolation '%s' on %s", hostConfig.Isolation, runtime.GOOS)
 	}
@@ -325,6 +345,31 @@ func validateCapabilities(hostConfig *containertypes.HostConfig) error {
 	return nil
 }
 
+func validateResourceAlerts(alerts []containertypes.ResourceAlert) error {
+	names := make(map[string]bool, len(alerts))
+	for _, alert := range alerts {
+		if alert.Name == "" {
+			return errors.Errorf("resource alert name cannot be empty")
+		}
+		if names[alert.Name] {
+			return errors.Errorf("duplicate resource alert '%s'", alert.Name)
+		}
+		names[alert.Name] = true
+		switch alert.Metric {
+		case containertypes.AlertMetricMemory, containertypes.AlertMetricCPU, containertypes.AlertMetricCPUThrottled, containertypes.AlertMetricPids:
+		default:
+			return errors.Errorf("invalid metric '%s' for resource alert '%s'", alert.Metric, alert.Name)
+		}
+		if alert.Threshold <= 0 {
+			return errors.Errorf("threshold of resource alert '%s' must be positive", alert.Name)
+		}
+		if alert.Duration < 0 {
+			return errors.Errorf("duration of resource alert '%s' cannot be negative", alert.Name)
+		}
+	}
+	return nil
+}
+
 // validateHealthCheck validates the healthcheck params of Config
 func validateHealthCheck(healthConfig *containertypes.HealthConfig) error {
 	if healthConfig == nil {
@@ -342,6 +387,18 @@ func validateHealthCheck(healthConfig *containertypes.HealthConfig) error {
 	if healthConfig.StartPeriod != 0 && healthConfig.StartPeriod < containertypes.MinimumDuration {
 		return errors.Errorf("StartPeriod in Healthcheck cannot be less than %s", containertypes.MinimumDuration)
 	}
+	if len(healthConfig.Test) > 0 {
+		switch healthConfig.Test[0] {
+		case "HTTP":
+			if _, err := newHTTPProbe(healthConfig.Test[1:]); err != nil {
+				return err
+			}
+		case "TCP":
+			if _, err := newTCPProbe(healthConfig.Test[1:]); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
@@ -362,6 +419,19 @@ func validatePortBindings(ports nat.PortMap) error {
 }
 
 func validateRestartPolicy(policy containertypes.RestartPolicy) error {
+	if policy.InitialDelay < 0 || policy.MaxDelay < 0 || policy.ResetAfter < 0 {
+		return errors.Errorf("restart delays cannot be negative")
+	}
+	if policy.MaxDelay != 0 && policy.InitialDelay > policy.MaxDelay {
+		return errors.Errorf("restart initial delay cannot be greater than max delay")
+	}
+	if policy.Multiplier != 0 && policy.Multiplier < 1 {
+		return errors.Errorf("restart delay multiplier must be at least 1")
+	}
+	if policy.Jitter < 0 || policy.Jitter > 1 {
+		return errors.Errorf("restart delay jitter must be between 0 and 1")
+	}
+
 	switch policy.Name {
 	case "always", "unless-stopped", "no":
 		if policy.MaximumRetryCount != 0 {
@@ -371,15 +441,60 @@ func validateRestartPolicy(policy containertypes.RestartPolicy) error {
 		if policy.MaximumRetryCount < 0 {
 			return errors.Errorf("maximum retry count cannot be negative")
 		}
+	case "on-unhealthy":
+		if policy.MaximumRetryCount < 0 {
+			return errors.Errorf("maximum retry count cannot be negative")
+		}
+		if policy.UnhealthyPeriod < 0 {
+			return errors.Errorf("unhealthy period cannot be negative")
+		}
+		return nil
 	case "":
 		// do nothing
 		return nil
 	default:
 		return errors.Errorf("invalid restart policy '%s'", policy.Name)
 	}
+	if policy.UnhealthyPeriod != 0 {
+		return errors.Errorf("unhealthy period cannot be used with restart policy '%s'", policy.Name)
+	}
 	return nil
 }
 
+// mergeRestartPolicy fills the backoff settings of the restart policy that
+// are not set with the daemon defaults, so they are persisted with the
+// container.
+func (daemon *Daemon) mergeRestartPolicy(policy *containertypes.RestartPolicy) {
+	if policy.Name == "" || policy.IsNone() || daemon.configStore == nil {
+		return
+	}
+	defaults, err := config.ParseRestartPolicyOpts(daemon.configStore.RestartPolicyOpts)
+	if err != nil {
+		// already validated when the configuration was loaded
+		logrus.WithError(err).Warn("ignoring invalid default restart policy options")
+		return
+	}
+	if policy.InitialDelay == 0 {
+		policy.InitialDelay = defaults.InitialDelay
+	}
+	if policy.MaxDelay == 0 {
+		// the default doesn't cap a longer initial delay of the container
+		policy.MaxDelay = defaults.MaxDelay
+		if policy.MaxDelay != 0 && policy.MaxDelay < policy.InitialDelay {
+			policy.MaxDelay = policy.InitialDelay
+		}
+	}
+	if policy.Multiplier == 0 {
+		policy.Multiplier = defaults.Multiplier
+	}
+	if policy.Jitter == 0 {
+		policy.Jitter = defaults.Jitter
+	}
+	if policy.ResetAfter == 0 {
+		policy.ResetAfter = defaults.ResetAfter
+	}
+}
+
 // translateWorkingDir translates the working-dir for the target platform,
 // and returns an error if the given path is not an absolute path.
 func translateWorkingDir(config *containertypes.Config, platform string) error {
@@ -118,6 +118,9 @@ func (daemon *Daemon) create(opts createOpts) (retC *container.Container, retErr
 		if err != nil {
 			return nil, err
 		}
+		if err := daemon.imageService.CheckTrustPolicy(opts.params.Config.Image, img); err != nil {
+			return nil, err
+		}
 		if img.OS != "" {
 			os = img.OS
 		} else {
@@ -152,6 +155,10 @@ func (daemon *Daemon) create(opts createOpts) (retC *container.Container, retErr
 	if err := daemon.mergeAndVerifyLogConfig(&opts.params.HostConfig.LogConfig); err != nil {
 		return nil, errdefs.InvalidParameter(err)
 	}
+	if err := verifyAdditionalLogConfigs(opts.params.HostConfig); err != nil {
+		return nil, errdefs.InvalidParameter(err)
+	}
+	daemon.mergeRestartPolicy(&opts.params.HostConfig.RestartPolicy)
 
 	if container, err = daemon.newContainer(opts.params.Name, os, opts.params.Config, opts.params.HostConfig, imgID, opts.managed); err != nil {
 		return nil, err
@@ -164,6 +171,10 @@ func (daemon *Daemon) create(opts createOpts) (retC *container.Container, retErr
 		}
 	}()
 
+	if err := daemon.verifyDependencies(container); err != nil {
+		return nil, errdefs.InvalidParameter(err)
+	}
+
 	if err := daemon.setSecurityOptions(container, opts.params.HostConfig); err != nil {
 		return nil, err
 	}
@@ -48,6 +48,7 @@ import (
 	_ "github.com/docker/docker/daemon/graphdriver/register"
 	"github.com/docker/docker/daemon/stats"
 	dmetadata "github.com/docker/docker/distribution/metadata"
+	"github.com/docker/docker/distribution/trust"
 	"github.com/docker/docker/dockerversion"
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/layer"
@@ -121,10 +122,17 @@ type Daemon struct {
 	seccompProfilePath string
 
 	diskUsageRunning int32
+	diskUsageSizes   sizeCache
 	pruneRunning     int32
 	hosts            map[string]bool // hosts stores the addresses the daemon is listening on
 	startupDone      chan struct{}
 
+	// oomKills holds, by container ID, a channel closed once the last OOM
+	// kill of the container was looked up and logged.
+	oomKills sync.Map
+	// alertContainers holds, by ID, the containers with resource alerts.
+	alertContainers sync.Map
+
 	attachmentStore       network.AttachmentStore
 	attachableNetworkLock *locker.Locker
 }
@@ -405,6 +413,13 @@ func (daemon *Daemon) restore() error {
 				}
 
 				c.ResetRestartManager(false)
+				if c.IsRunning() {
+					// Whether the container is ready is not persisted, but
+					// the state of the probes it derives from is.
+					c.Lock()
+					updateReadiness(c)
+					c.Unlock()
+				}
 				if !c.HostConfig.NetworkMode.IsContainer() && c.IsRunning() {
 					options, err := daemon.buildSandboxOptions(c)
 					if err != nil {
@@ -477,33 +492,53 @@ func (daemon *Daemon) restore() error {
 	}
 	group.Wait()
 
-	for c, notifier := range restartContainers {
-		group.Add(1)
-		go func(c *container.Container, chNotify chan struct{}) {
-			_ = sem.Acquire(context.Background(), 1)
-			logrus.Debugf("Starting container %s", c.ID)
-
-			// ignore errors here as this is a best effort to wait for children to be
-			//   running before we try to start the container
-			children := daemon.children(c)
-			timeout := time.After(5 * time.Second)
-			for _, child := range children {
-				if notifier, exists := restartContainers[child]; exists {
-					select {
-					case <-notifier:
-					case <-timeout:
-					}
-				}
-			}
+	restart := func(c *container.Container, chNotify chan struct{}) {
+		defer close(chNotify)
+		// Wait for the dependencies before acquiring the semaphore, as
+		// they may need it to be started.
+		if err := daemon.checkDependencyCycle(c, nil); err != nil {
+			logrus.Errorf("Failed to start container %s: %v", c.ID, err)
+			return
+		}
+		if err := daemon.waitForDependencies(c, restartContainers); err != nil {
+			logrus.Errorf("Failed to start container %s: %v", c.ID, err)
+			return
+		}
 
-			// Make sure networks are available before starting
-			daemon.waitForNetworks(c)
-			if err := daemon.containerStart(c, "", "", true); err != nil {
-				logrus.Errorf("Failed to start container %s: %s", c.ID, err)
+		_ = sem.Acquire(context.Background(), 1)
+		defer sem.Release(1)
+		logrus.Debugf("Starting container %s", c.ID)
+
+		// ignore errors here as this is a best effort to wait for children to be
+		//   running before we try to start the container
+		children := daemon.children(c)
+		timeout := time.After(5 * time.Second)
+		for _, child := range children {
+			if notifier, exists := restartContainers[child]; exists {
+				select {
+				case <-notifier:
+				case <-timeout:
+				}
 			}
-			close(chNotify)
+		}
 
-			sem.Release(1)
+		// Make sure networks are available before starting
+		daemon.waitForNetworks(c)
+		if err := daemon.containerStart(c, "", "", true); err != nil {
+			logrus.Errorf("Failed to start container %s: %s", c.ID, err)
+		}
+	}
+	for c, notifier := range restartContainers {
+		if len(c.HostConfig.DependsOn) > 0 {
+			// Waiting for the dependencies may take up to
+			// dependencyTimeout, so it must not delay the startup of
+			// the daemon.
+			go restart(c, notifier)
+			continue
+		}
+		group.Add(1)
+		go func(c *container.Container, chNotify chan struct{}) {
+			restart(c, chNotify)
 			group.Done()
 		}(c, notifier)
 	}
@@ -858,6 +893,7 @@ func NewDaemon(ctx context.Context, config *config.Config, pluginStore *plugin.S
 
 	d.RegistryService = registryService
 	logger.RegisterPluginGetter(d.PluginStore)
+	logger.RegisterBufferEventFunc(d.logBufferEvent)
 
 	metricsSockPath, err := d.listenMetricsSock()
 	if err != nil {
@@ -1013,9 +1049,18 @@ func NewDaemon(ctx context.Context, config *config.Config, pluginStore *plugin.S
 	}
 	d.execCommands = exec.NewStore()
 	d.idIndex = truncindex.NewTruncIndex([]string{})
-	d.statsCollector = d.newStatsCollector(1 * time.Second)
+	if d.statsCollector, err = d.newStatsCollector(1*time.Second, config); err != nil {
+		return nil, err
+	}
 
-	d.EventsService = events.New()
+	if config.EventsJournalMaxSize > 0 {
+		d.EventsService, err = events.NewWithJournal(filepath.Join(config.Root, "events"), config.EventsJournalMaxSize.Value(), config.GetEventsJournalMaxAge())
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		d.EventsService = events.New()
+	}
 	d.root = config.Root
 	d.idMapping = idMapping
 	d.seccompEnabled = sysInfo.Seccomp
@@ -1026,16 +1071,26 @@ func NewDaemon(ctx context.Context, config *config.Config, pluginStore *plugin.S
 	// TODO: imageStore, distributionMetadataStore, and ReferenceStore are only
 	// used above to run migration. They could be initialized in ImageService
 	// if migration is called from daemon/images. layerStore might move as well.
+	var trustPolicy *trust.Policy
+	if config.TrustPolicy != "" {
+		trustPolicy, err = trust.LoadPolicy(config.TrustPolicy)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	d.imageService = images.NewImageService(images.ImageServiceConfig{
 		ContainerStore:            d.containers,
 		DistributionMetadataStore: distributionMetadataStore,
 		EventsService:             d.EventsService,
 		ImageStore:                imageStore,
+		LayerCompression:          config.GetLayerCompression(),
 		LayerStores:               layerStores,
 		MaxConcurrentDownloads:    *config.MaxConcurrentDownloads,
 		MaxConcurrentUploads:      *config.MaxConcurrentUploads,
 		ReferenceStore:            rs,
 		RegistryService:           registryService,
+		TrustPolicy:               trustPolicy,
 	})
 
 	go d.execCommandGC()
@@ -1201,6 +1256,12 @@ func (daemon *Daemon) Shutdown() error {
 		daemon.containerdCli.Close()
 	}
 
+	if daemon.EventsService != nil {
+		if err := daemon.EventsService.Close(); err != nil {
+			logrus.Errorf("Error closing events journal: %v", err)
+		}
+	}
+
 	return daemon.cleanupMounts()
 }
 
@@ -1435,6 +1435,8 @@ func (daemon *Daemon) stats(c *container.Container) (*types.StatsJSON, error) {
 		}
 	}
 
+	setPressureAndOOMStats(c.GetPID(), s)
+
 	return s, nil
 }
 
@@ -1,7 +1,32 @@
 package daemon // import "github.com/docker/docker/daemon"
 
 import (
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/container"
 	"github.com/docker/swarmkit/agent/exec"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Conditions a dependency of a container must meet before the container is
+// started by the daemon.
+const (
+	dependencyStarted = "started"
+	dependencyHealthy = "healthy"
+	dependencyReady   = "ready"
+)
+
+const (
+	// dependencyTimeout is how long to wait for the dependencies of a
+	// container to meet their condition before giving up starting it.
+	dependencyTimeout = 2 * time.Minute
+
+	// dependencyPollInterval is the interval at which the state of the
+	// dependencies is checked.
+	dependencyPollInterval = 250 * time.Millisecond
 )
 
 // SetContainerDependencyStore sets the dependency store backend for the container
@@ -15,3 +40,179 @@ func (daemon *Daemon) SetContainerDependencyStore(name string, store exec.Depend
 
 	return nil
 }
+
+// parseDependency parses a DependsOn entry of the form name[:condition].
+func parseDependency(dep string) (name, condition string, err error) {
+	name, condition = dep, dependencyStarted
+	if i := strings.LastIndex(dep, ":"); i >= 0 {
+		name, condition = dep[:i], dep[i+1:]
+	}
+	name = strings.TrimPrefix(name, "/")
+	if name == "" {
+		return "", "", errors.Errorf("invalid dependency %q: missing container name", dep)
+	}
+	switch condition {
+	case dependencyStarted, dependencyHealthy, dependencyReady:
+	default:
+		return "", "", errors.Errorf("invalid dependency %q: unknown condition %q (expected %q, %q or %q)", dep, condition, dependencyStarted, dependencyHealthy, dependencyReady)
+	}
+	return name, condition, nil
+}
+
+// resolveDependency returns the container a dependency refers to. The
+// container being created is passed as pending, as it is not registered yet.
+func (daemon *Daemon) resolveDependency(name string, pending *container.Container) (*container.Container, error) {
+	if pending != nil && strings.TrimPrefix(pending.Name, "/") == name {
+		return pending, nil
+	}
+	return daemon.GetContainer(name)
+}
+
+// verifyDependencies checks that the dependencies of a container being
+// created exist, can meet their condition, and don't form a cycle.
+func (daemon *Daemon) verifyDependencies(c *container.Container) error {
+	for _, dep := range c.HostConfig.DependsOn {
+		name, condition, err := parseDependency(dep)
+		if err != nil {
+			return err
+		}
+		d, err := daemon.resolveDependency(name, c)
+		if err != nil {
+			return errors.Wrapf(err, "invalid dependency %q", dep)
+		}
+		if d == c {
+			return errors.Errorf("invalid dependency %q: a container cannot depend on itself", dep)
+		}
+		if condition == dependencyHealthy && getProbe(d) == nil {
+			return errors.Errorf("invalid dependency %q: container %s has no healthcheck", dep, name)
+		}
+		if condition == dependencyReady && d.Config.StartupProbe == nil && d.Config.ReadinessProbe == nil {
+			return errors.Errorf("invalid dependency %q: container %s has no startup or readiness probe", dep, name)
+		}
+	}
+	return daemon.checkDependencyCycle(c, c)
+}
+
+// checkDependencyCycle returns an error if the dependencies of a container
+// form a cycle.
+func (daemon *Daemon) checkDependencyCycle(c, pending *container.Container) error {
+	const (
+		visiting = iota + 1
+		visited
+	)
+	state := make(map[string]int)
+	var path []string
+
+	var visit func(c *container.Container) error
+	visit = func(c *container.Container) error {
+		switch state[c.ID] {
+		case visiting:
+			return errors.Errorf("dependency cycle detected: %s", strings.Join(append(path, c.Name), " -> "))
+		case visited:
+			return nil
+		}
+		state[c.ID] = visiting
+		path = append(path, c.Name)
+		for _, dep := range c.HostConfig.DependsOn {
+			name, _, err := parseDependency(dep)
+			if err != nil {
+				continue
+			}
+			d, err := daemon.resolveDependency(name, pending)
+			if err != nil {
+				// missing dependencies are reported when starting the container
+				continue
+			}
+			if err := visit(d); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[c.ID] = visited
+		return nil
+	}
+	return visit(c)
+}
+
+// waitForDependencies waits until the dependencies of a container meet their
+// condition, so that the container can be started. pending holds a channel
+// per container that is being started, which is closed once it was started.
+//
+// A dependency which is being started or restarted is waited for, up to
+// dependencyTimeout. A dependency which is stopped, and is not going to be
+// restarted, fails the wait immediately, as nothing would start it. In both
+// cases a "dependency_unmet" event naming the dependency is logged for the
+// container.
+func (daemon *Daemon) waitForDependencies(c *container.Container, pending map[*container.Container]chan struct{}) error {
+	deadline := time.Now().Add(dependencyTimeout)
+	for _, dep := range c.HostConfig.DependsOn {
+		name, condition, err := parseDependency(dep)
+		if err != nil {
+			return err
+		}
+		d, err := daemon.GetContainer(name)
+		if err != nil {
+			return daemon.dependencyUnmet(c, name, condition, errors.Wrapf(err, "dependency %q", dep))
+		}
+
+		notifier, starting := pending[d]
+		if starting {
+			select {
+			case <-notifier:
+			case <-time.After(time.Until(deadline)):
+				return daemon.dependencyUnmet(c, name, condition, errors.Errorf("timeout waiting for dependency %s to start", name))
+			}
+		}
+
+		for waiting := false; ; waiting = true {
+			met, running := dependencyState(d, condition)
+			if met {
+				break
+			}
+			if !running {
+				return daemon.dependencyUnmet(c, name, condition, errors.Errorf("dependency %s is not running", name))
+			}
+			if time.Now().After(deadline) {
+				return daemon.dependencyUnmet(c, name, condition, errors.Errorf("timeout waiting for dependency %s to be %s", name, condition))
+			}
+			if !waiting {
+				logrus.WithField("container", c.ID).WithField("dependency", name).Infof("Waiting up to %s for dependency to be %s", time.Until(deadline).Round(time.Second), condition)
+			}
+			time.Sleep(dependencyPollInterval)
+		}
+	}
+	return nil
+}
+
+// dependencyUnmet reports that a dependency of the container didn't meet its
+// condition, and returns err.
+func (daemon *Daemon) dependencyUnmet(c *container.Container, name, condition string, err error) error {
+	logrus.WithError(err).WithField("container", c.ID).WithField("dependency", name).Warn("Container dependency is not met")
+	daemon.LogContainerEventWithAttributes(c, "dependency_unmet", map[string]string{
+		"dependency": name,
+		"condition":  condition,
+	})
+	return err
+}
+
+// dependencyState returns whether a dependency meets its condition, and
+// whether it is running or about to be restarted.
+func dependencyState(d *container.Container, condition string) (met bool, running bool) {
+	d.Lock()
+	defer d.Unlock()
+
+	if !d.Running {
+		return false, false
+	}
+	if d.Restarting {
+		return false, true
+	}
+	switch condition {
+	case dependencyHealthy:
+		return d.State.Health != nil && d.State.Health.Status() == types.Healthy, true
+	case dependencyReady:
+		return d.State.IsReady(), true
+	default:
+		return true, true
+	}
+}
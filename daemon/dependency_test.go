@@ -0,0 +1,114 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	containertypes "github.com/docker/docker/api/types/container"
+	eventtypes "github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/daemon/events"
+	"github.com/docker/docker/pkg/truncindex"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestParseDependency(t *testing.T) {
+	for _, tc := range []struct {
+		dep       string
+		name      string
+		condition string
+	}{
+		{dep: "db", name: "db", condition: "started"},
+		{dep: "/db", name: "db", condition: "started"},
+		{dep: "db:healthy", name: "db", condition: "healthy"},
+		{dep: "db:ready", name: "db", condition: "ready"},
+	} {
+		name, condition, err := parseDependency(tc.dep)
+		assert.NilError(t, err, tc.dep)
+		assert.Check(t, is.Equal(tc.name, name))
+		assert.Check(t, is.Equal(tc.condition, condition))
+	}
+
+	for _, dep := range []string{"", ":healthy", "db:alive"} {
+		_, _, err := parseDependency(dep)
+		assert.Check(t, err != nil, dep)
+	}
+}
+
+func newDependencyTestDaemon(t *testing.T, containers ...*container.Container) *Daemon {
+	store := container.NewMemoryStore()
+	index := truncindex.NewTruncIndex([]string{})
+	containersReplica, err := container.NewViewDB()
+	assert.NilError(t, err)
+
+	daemon := &Daemon{
+		containers:        store,
+		containersReplica: containersReplica,
+		idIndex:           index,
+		EventsService:     events.New(),
+	}
+	for _, c := range containers {
+		store.Add(c.ID, c)
+		index.Add(c.ID)
+		daemon.reserveName(c.ID, c.Name)
+	}
+	return daemon
+}
+
+func dependencyTestContainer(id, name string, dependsOn ...string) *container.Container {
+	return &container.Container{
+		ID:         id,
+		Name:       name,
+		Config:     &containertypes.Config{},
+		HostConfig: &containertypes.HostConfig{DependsOn: dependsOn},
+		State:      container.NewState(),
+	}
+}
+
+func TestVerifyDependencies(t *testing.T) {
+	db := dependencyTestContainer("1a", "/db")
+	cache := dependencyTestContainer("2b", "/cache", "web")
+	daemon := newDependencyTestDaemon(t, db, cache)
+
+	web := dependencyTestContainer("3c", "/web", "db")
+	assert.NilError(t, daemon.verifyDependencies(web))
+
+	web = dependencyTestContainer("3c", "/web", "missing")
+	assert.Check(t, daemon.verifyDependencies(web) != nil)
+
+	web = dependencyTestContainer("3c", "/web", "db:healthy")
+	assert.ErrorContains(t, daemon.verifyDependencies(web), "no healthcheck")
+
+	web = dependencyTestContainer("3c", "/web", "web")
+	assert.ErrorContains(t, daemon.verifyDependencies(web), "itself")
+
+	// cache already depends on a container named web
+	web = dependencyTestContainer("3c", "/web", "cache")
+	err := daemon.verifyDependencies(web)
+	assert.ErrorContains(t, err, "dependency cycle")
+	assert.Check(t, strings.Contains(err.Error(), "/web -> /cache -> /web"), err.Error())
+}
+
+func TestWaitForDependencies(t *testing.T) {
+	db := dependencyTestContainer("1a", "/db")
+	web := dependencyTestContainer("2b", "/web", "db")
+	daemon := newDependencyTestDaemon(t, db, web)
+
+	_, l, _ := daemon.EventsService.Subscribe()
+	defer daemon.EventsService.Evict(l)
+	assert.ErrorContains(t, daemon.waitForDependencies(web, nil), "is not running")
+	select {
+	case ev := <-l:
+		msg := ev.(eventtypes.Message)
+		assert.Check(t, is.Equal(msg.Action, "dependency_unmet"))
+		assert.Check(t, is.Equal(msg.Actor.ID, web.ID))
+		assert.Check(t, is.Equal(msg.Actor.Attributes["dependency"], "db"))
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for the dependency_unmet event")
+	}
+
+	db.SetRunning(1, true)
+	assert.NilError(t, daemon.waitForDependencies(web, nil))
+}
@@ -3,14 +3,31 @@ package daemon // import "github.com/docker/docker/daemon"
 import (
 	"context"
 	"fmt"
+	"os"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/filters"
+	mounttypes "github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/pkg/directory"
+	"golang.org/x/sync/errgroup"
 )
 
-// SystemDiskUsage returns information about the daemon data disk usage
-func (daemon *Daemon) SystemDiskUsage(ctx context.Context) (*types.DiskUsage, error) {
+const (
+	// diskUsageCacheTTL is how long the sizes computed for the verbose disk
+	// usage are reused.
+	diskUsageCacheTTL = 30 * time.Second
+	// diskUsageWorkers is the number of sizes computed in parallel.
+	diskUsageWorkers = 8
+)
+
+// SystemDiskUsage returns information about the daemon data disk usage. In
+// verbose mode, the disk usage of the containers outside of their filesystem
+// and the containers using the local volumes are included.
+func (daemon *Daemon) SystemDiskUsage(ctx context.Context, verbose bool) (*types.DiskUsage, error) {
 	if !atomic.CompareAndSwapInt32(&daemon.diskUsageRunning, 0, 1) {
 		return nil, fmt.Errorf("a disk usage operation is already running")
 	}
@@ -41,10 +58,149 @@ func (daemon *Daemon) SystemDiskUsage(ctx context.Context) (*types.DiskUsage, er
 		return nil, err
 	}
 
-	return &types.DiskUsage{
+	du := &types.DiskUsage{
 		LayersSize: allLayersSize,
 		Containers: allContainers,
 		Volumes:    localVolumes,
 		Images:     allImages,
-	}, nil
+	}
+	if verbose {
+		du.ContainersUsage, du.VolumesUsage, err = daemon.verboseDiskUsage(ctx, localVolumes)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return du, nil
+}
+
+// verboseDiskUsage computes the sizes of the log files and checkpoints of the
+// containers in parallel, and attributes the sizes of the local volumes to
+// the containers using them.
+func (daemon *Daemon) verboseDiskUsage(ctx context.Context, volumes []*types.Volume) ([]*types.ContainerDiskUsage, []*types.VolumeDiskUsage, error) {
+	daemon.diskUsageSizes.expire()
+
+	containers := daemon.List()
+	containersUsage, volumesUsage := volumesDiskUsage(containers, volumes)
+
+	eg, ctx := errgroup.WithContext(ctx)
+	workers := make(chan struct{}, diskUsageWorkers)
+	for i, c := range containers {
+		c, u := c, containersUsage[i]
+		eg.Go(func() error {
+			workers <- struct{}{}
+			defer func() { <-workers }()
+
+			logFiles, err := c.LogFiles()
+			if err != nil {
+				return err
+			}
+			for _, f := range logFiles {
+				size, err := daemon.diskUsageSizes.size(ctx, f)
+				if err != nil {
+					return err
+				}
+				u.LogSize += size
+			}
+			u.CheckpointSize, err = daemon.diskUsageSizes.size(ctx, c.CheckpointDir())
+			return err
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, nil, err
+	}
+	return containersUsage, volumesUsage, nil
+}
+
+// volumesDiskUsage returns the disk usage of the containers, in the same
+// order, with the local volumes they use, and the containers using each
+// volume. The size of a volume is divided evenly between the containers
+// using it.
+func volumesDiskUsage(containers []*container.Container, volumes []*types.Volume) ([]*types.ContainerDiskUsage, []*types.VolumeDiskUsage) {
+	volumesUsage := make([]*types.VolumeDiskUsage, 0, len(volumes))
+	byName := make(map[string]*types.VolumeDiskUsage, len(volumes))
+	for _, v := range volumes {
+		u := &types.VolumeDiskUsage{Name: v.Name, Size: -1, Containers: []string{}}
+		if v.UsageData != nil {
+			u.Size = v.UsageData.Size
+		}
+		byName[v.Name] = u
+		volumesUsage = append(volumesUsage, u)
+	}
+
+	containersUsage := make([]*types.ContainerDiskUsage, 0, len(containers))
+	for _, c := range containers {
+		u := &types.ContainerDiskUsage{ID: c.ID, Volumes: []string{}}
+		c.Lock()
+		for _, mp := range c.MountPoints {
+			if mp.Type != mounttypes.TypeVolume {
+				continue
+			}
+			v, ok := byName[mp.Name]
+			if !ok || len(v.Containers) > 0 && v.Containers[len(v.Containers)-1] == c.ID {
+				// not a local volume, or mounted several times
+				continue
+			}
+			v.Containers = append(v.Containers, c.ID)
+			u.Volumes = append(u.Volumes, v.Name)
+		}
+		c.Unlock()
+		containersUsage = append(containersUsage, u)
+	}
+
+	for _, u := range containersUsage {
+		for _, name := range u.Volumes {
+			if v := byName[name]; v.Size > 0 {
+				u.VolumesSize += v.Size / int64(len(v.Containers))
+			}
+		}
+	}
+	return containersUsage, volumesUsage
+}
+
+// sizeCache caches the sizes of files and directories for diskUsageCacheTTL.
+type sizeCache struct {
+	mu      sync.Mutex
+	entries map[string]sizeCacheEntry
+}
+
+type sizeCacheEntry struct {
+	size    int64
+	created time.Time
+}
+
+// size returns the size of a file or directory, 0 if it doesn't exist.
+func (c *sizeCache) size(ctx context.Context, path string) (int64, error) {
+	c.mu.Lock()
+	e, ok := c.entries[path]
+	c.mu.Unlock()
+	if ok && time.Since(e.created) < diskUsageCacheTTL {
+		return e.size, nil
+	}
+
+	size, err := directory.Size(ctx, path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return 0, err
+		}
+		size = 0
+	}
+	c.mu.Lock()
+	if c.entries == nil {
+		c.entries = make(map[string]sizeCacheEntry)
+	}
+	c.entries[path] = sizeCacheEntry{size: size, created: time.Now()}
+	c.mu.Unlock()
+	return size, nil
+}
+
+// expire removes the entries older than diskUsageCacheTTL, such as the ones
+// of the containers which were removed.
+func (c *sizeCache) expire() {
+	c.mu.Lock()
+	for path, e := range c.entries {
+		if time.Since(e.created) >= diskUsageCacheTTL {
+			delete(c.entries, path)
+		}
+	}
+	c.mu.Unlock()
 }
@@ -0,0 +1,74 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	mounttypes "github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/container"
+	volumemounts "github.com/docker/docker/volume/mounts"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestVolumesDiskUsage(t *testing.T) {
+	newContainer := func(id string, volumes ...string) *container.Container {
+		c := container.NewBaseContainer(id, "")
+		for i, v := range volumes {
+			c.MountPoints[filepath.Join("/data", string(rune('a'+i)))] = &volumemounts.MountPoint{Type: mounttypes.TypeVolume, Name: v}
+		}
+		return c
+	}
+	containers := []*container.Container{
+		newContainer("c1", "shared", "own"),
+		newContainer("c2", "shared", "remote"),
+		newContainer("c3"),
+	}
+	volumes := []*types.Volume{
+		{Name: "shared", UsageData: &types.VolumeUsageData{Size: 100}},
+		{Name: "own", UsageData: &types.VolumeUsageData{Size: 10}},
+		{Name: "unused", UsageData: &types.VolumeUsageData{Size: 1}},
+	}
+
+	containersUsage, volumesUsage := volumesDiskUsage(containers, volumes)
+	assert.Assert(t, is.Len(containersUsage, 3))
+	assert.Check(t, is.Equal(containersUsage[0].VolumesSize, int64(60)))
+	assert.Check(t, is.Len(containersUsage[0].Volumes, 2))
+	assert.Check(t, is.Equal(containersUsage[1].VolumesSize, int64(50)))
+	assert.Check(t, is.DeepEqual(containersUsage[1].Volumes, []string{"shared"}))
+	assert.Check(t, is.Equal(containersUsage[2].VolumesSize, int64(0)))
+
+	assert.Check(t, is.DeepEqual(volumesUsage, []*types.VolumeDiskUsage{
+		{Name: "shared", Size: 100, Containers: []string{"c1", "c2"}},
+		{Name: "own", Size: 10, Containers: []string{"c1"}},
+		{Name: "unused", Size: 1, Containers: []string{}},
+	}))
+}
+
+func TestSizeCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "size-cache")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "container.log")
+	assert.NilError(t, ioutil.WriteFile(path, make([]byte, 10), 0600))
+
+	var c sizeCache
+	size, err := c.size(context.Background(), path)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(size, int64(10)))
+
+	// the size is cached
+	assert.NilError(t, ioutil.WriteFile(path, make([]byte, 20), 0600))
+	size, err = c.size(context.Background(), path)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(size, int64(10)))
+
+	size, err = c.size(context.Background(), filepath.Join(dir, "checkpoints"))
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(size, int64(0)))
+}
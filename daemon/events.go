@@ -10,6 +10,7 @@ import (
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/container"
 	daemonevents "github.com/docker/docker/daemon/events"
+	"github.com/docker/docker/daemon/logger"
 	"github.com/docker/libnetwork"
 	swarmapi "github.com/docker/swarmkit/api"
 	gogotypes "github.com/gogo/protobuf/types"
@@ -44,6 +45,24 @@ func (daemon *Daemon) LogContainerEventWithAttributes(container *container.Conta
 	daemon.EventsService.Log(action, events.ContainerEventType, actor)
 }
 
+// logBufferEvent generates an event when the log buffer of a container in
+// non-blocking log mode starts dropping messages, and when it is drained.
+func (daemon *Daemon) logBufferEvent(info logger.Info, driver string, dropping bool, stats logger.BufferStats) {
+	c := daemon.containers.Get(info.ContainerID)
+	if c == nil {
+		return
+	}
+	action := "log_drop_stop"
+	if dropping {
+		action = "log_drop_start"
+	}
+	daemon.LogContainerEventWithAttributes(c, action, map[string]string{
+		"driver":       driver,
+		"dropped":      strconv.FormatUint(stats.Dropped, 10),
+		"droppedBytes": strconv.FormatUint(stats.DroppedBytes, 10),
+	})
+}
+
 // LogPluginEvent generates an event related to a plugin with only the default attributes.
 func (daemon *Daemon) LogPluginEvent(pluginID, refName, action string) {
 	daemon.LogPluginEventWithAttributes(pluginID, refName, action, map[string]string{})
@@ -104,6 +123,13 @@ func (daemon *Daemon) SubscribeToEvents(since, until time.Time, filter filters.A
 	return daemon.EventsService.SubscribeTopic(since, until, ef)
 }
 
+// EventsAfter returns the recorded events with a sequence greater than after
+// that match the filter, and the sequence of the last recorded event.
+func (daemon *Daemon) EventsAfter(after uint64, filter filters.Args) ([]events.Message, uint64, error) {
+	ef := daemonevents.NewFilter(filter)
+	return daemon.EventsService.After(after, ef)
+}
+
 // UnsubscribeFromEvents stops the event subscription for a client by closing the
 // channel where the daemon sends events to.
 func (daemon *Daemon) UnsubscribeFromEvents(listener chan interface{}) {
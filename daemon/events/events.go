@@ -5,7 +5,10 @@ import (
 	"time"
 
 	eventtypes "github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/pkg/pubsub"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 )
 
 const (
@@ -15,17 +18,107 @@ const (
 
 // Events is pubsub channel for events generated by the engine.
 type Events struct {
-	mu     sync.Mutex
-	events []eventtypes.Message
-	pub    *pubsub.Publisher
+	mu      sync.Mutex
+	events  []eventtypes.Message
+	pub     *pubsub.Publisher
+	journal *journal
+
+	// seq is the sequence of the last published event.
+	seq uint64
+	// evicted is the sequence of the last event discarded from events.
+	evicted uint64
+
+	// journalMu protects the queue of events waiting to be written to the
+	// journal. Events are queued in sequence order while holding mu, and
+	// written by writeJournal so that publishing never waits for the disk.
+	journalMu     sync.Mutex
+	journalCond   *sync.Cond
+	pending       []eventtypes.Message
+	written       uint64
+	journalClosed bool
+	journalDone   chan struct{}
 }
 
 // New returns new *Events instance
 func New() *Events {
+	// Sequences start from the current time so that they keep increasing
+	// across daemon restarts and cursors from a previous run are never
+	// mistaken for events of this one.
+	seq := uint64(time.Now().UnixNano())
 	return &Events{
-		events: make([]eventtypes.Message, 0, eventsLimit),
-		pub:    pubsub.NewPublisher(100*time.Millisecond, bufferSize),
+		events:  make([]eventtypes.Message, 0, eventsLimit),
+		pub:     pubsub.NewPublisher(100*time.Millisecond, bufferSize),
+		seq:     seq,
+		evicted: seq,
+	}
+}
+
+// NewWithJournal returns new *Events instance which also persists events
+// to an on-disk journal stored in root. Events requested with a `since`
+// or `until` time are read back from the journal instead of the in-memory
+// buffer, so they survive daemon restarts. The journal keeps at most
+// maxSize bytes and, if maxAge is not zero, drops events older than maxAge.
+func NewWithJournal(root string, maxSize int64, maxAge time.Duration) (*Events, error) {
+	j, err := newJournal(root, maxSize, maxAge)
+	if err != nil {
+		return nil, err
+	}
+	e := New()
+	e.journal = j
+	// Also start after the sequences used by the previous runs, in case
+	// the clock went backwards.
+	for _, seq := range []uint64{j.lastSequence(), j.evictedSequence()} {
+		if seq > e.seq {
+			e.seq, e.evicted = seq, seq
+		}
+	}
+	if j.lastSequence() == 0 {
+		j.evicted = e.seq
+	}
+	e.written = e.seq
+	e.journalCond = sync.NewCond(&e.journalMu)
+	e.journalDone = make(chan struct{})
+	go e.writeJournal()
+	return e, nil
+}
+
+// writeJournal appends the queued events to the journal until it is closed.
+func (e *Events) writeJournal() {
+	defer close(e.journalDone)
+
+	e.journalMu.Lock()
+	defer e.journalMu.Unlock()
+	for {
+		for len(e.pending) == 0 && !e.journalClosed {
+			e.journalCond.Wait()
+		}
+		if len(e.pending) == 0 {
+			return
+		}
+		pending := e.pending
+		e.pending = nil
+
+		e.journalMu.Unlock()
+		for _, jm := range pending {
+			if err := e.journal.append(jm); err != nil {
+				logrus.WithError(err).Warn("failed to write event to journal")
+			}
+		}
+		e.journalMu.Lock()
+
+		e.written = pending[len(pending)-1].Sequence
+		e.journalCond.Broadcast()
+	}
+}
+
+// waitJournal waits until the events up to seq were written to the journal,
+// or the journal is closed.
+func (e *Events) waitJournal(seq uint64) {
+	e.journalMu.Lock()
+	for e.written < seq && !e.journalClosed {
+		e.journalCond.Wait()
 	}
+	e.journalMu.Unlock()
 }
 
 // Subscribe adds new listener to events, returns slice of 256 stored
@@ -58,7 +151,9 @@ func (e *Events) SubscribeTopic(since, until time.Time, ef *Filter) ([]eventtype
 		topic = func(m interface{}) bool { return ef.Include(m.(eventtypes.Message)) }
 	}
 
-	buffered := e.loadBufferedEvents(since, until, topic)
+	current := make([]eventtypes.Message, len(e.events))
+	copy(current, e.events)
+	head := e.evicted + 1
 
 	var ch chan interface{}
 	if topic != nil {
@@ -67,9 +162,61 @@ func (e *Events) SubscribeTopic(since, until time.Time, ef *Filter) ([]eventtype
 		// Subscribe to all events if there are no filters
 		ch = e.pub.Subscribe()
 	}
+	e.mu.Unlock()
+
+	return e.loadBufferedEvents(since, until, topic, current, head), ch
+}
 
+// After returns the events with a sequence greater than after that match
+// the filter, along with the sequence of the last event published so far.
+// Events that are filtered out still advance the returned sequence, so it
+// can be passed back as after to continue reading without gaps. An error
+// is returned if some of the events following after were already
+// discarded.
+func (e *Events) After(after uint64, ef *Filter) ([]eventtypes.Message, uint64, error) {
+	e.mu.Lock()
+	if after > e.seq {
+		e.mu.Unlock()
+		return nil, 0, errdefs.InvalidParameter(errors.Errorf("unknown event sequence %d", after))
+	}
+	current := make([]eventtypes.Message, len(e.events))
+	copy(current, e.events)
+	seq, evicted := e.seq, e.evicted
 	e.mu.Unlock()
-	return buffered, ch
+
+	var msgs []eventtypes.Message
+	include := func(ev eventtypes.Message) bool {
+		if ef == nil || ef.filter.Len() == 0 || ef.Include(ev) {
+			msgs = append(msgs, ev)
+		}
+		return true
+	}
+
+	if after < evicted {
+		// The events up to evicted are only in the journal, and the
+		// following ones are in current.
+		if e.journal == nil || after < e.journal.evictedSequence() {
+			return nil, 0, errdefs.InvalidParameter(errors.Errorf("events after sequence %d are no longer available", after))
+		}
+		e.waitJournal(evicted)
+		err := e.journal.readAfter(after, func(ev eventtypes.Message) bool {
+			if ev.Sequence > evicted {
+				return false
+			}
+			return include(ev)
+		})
+		if err != nil {
+			return nil, 0, errdefs.System(errors.Wrap(err, "error reading events journal"))
+		}
+		after = evicted
+	}
+
+	for _, ev := range current {
+		if ev.Sequence > after {
+			include(ev)
+		}
+	}
+	return msgs, seq, nil
 }
 
 // Evict evicts listener from pubsub
@@ -110,8 +257,19 @@ func (e *Events) PublishMessage(jm eventtypes.Message) {
 	eventsCounter.Inc()
 
 	e.mu.Lock()
+	e.seq++
+	jm.Sequence = e.seq
+	if e.journal != nil {
+		e.journalMu.Lock()
+		if !e.journalClosed {
+			e.pending = append(e.pending, jm)
+			e.journalCond.Broadcast()
+		}
+		e.journalMu.Unlock()
+	}
 	if len(e.events) == cap(e.events) {
 		// discard oldest event
+		e.evicted = e.events[0].Sequence
 		copy(e.events, e.events[1:])
 		e.events[len(e.events)-1] = jm
 	} else {
@@ -121,16 +279,34 @@ func (e *Events) PublishMessage(jm eventtypes.Message) {
 	e.pub.Publish(jm)
 }
 
+// Close writes the pending events to the journal, if any, and closes it.
+func (e *Events) Close() error {
+	if e.journal == nil {
+		return nil
+	}
+	e.journalMu.Lock()
+	e.journalClosed = true
+	e.journalCond.Broadcast()
+	e.journalMu.Unlock()
+
+	<-e.journalDone
+	return e.journal.close()
+}
+
 // SubscribersCount returns number of event listeners
 func (e *Events) SubscribersCount() int {
 	return e.pub.Len()
 }
 
-// loadBufferedEvents iterates over the cached events in the buffer
-// and returns those that were emitted between two specific dates.
+// loadBufferedEvents iterates over the cached events in the journal, or in
+// the buffer if there is no journal, and returns those that were emitted
+// between two specific dates.
 // It uses `time.Unix(seconds, nanoseconds)` to generate valid dates with those arguments.
 // It filters those buffered messages with a topic function if it's not nil, otherwise it adds all messages.
-func (e *Events) loadBufferedEvents(since, until time.Time, topic func(interface{}) bool) []eventtypes.Message {
+// current is a copy of the buffer, whose first event has the sequence head;
+// the journal is only read up to the event preceding it, as the following
+// ones may not have been written yet.
+func (e *Events) loadBufferedEvents(since, until time.Time, topic func(interface{}) bool, current []eventtypes.Message, head uint64) []eventtypes.Message {
 	var buffered []eventtypes.Message
 	if since.IsZero() && until.IsZero() {
 		return buffered
@@ -146,8 +322,34 @@ func (e *Events) loadBufferedEvents(since, until time.Time, topic func(interface
 		untilNanoUnix = until.UnixNano()
 	}
 
-	for i := len(e.events) - 1; i >= 0; i-- {
-		ev := e.events[i]
+	if e.journal != nil {
+		e.waitJournal(head - 1)
+		err := e.journal.read(sinceNanoUnix, untilNanoUnix, func(ev eventtypes.Message) bool {
+			if ev.Sequence >= head {
+				return false
+			}
+			if topic == nil || topic(ev) {
+				buffered = append(buffered, ev)
+			}
+			return true
+		})
+		if err == nil {
+			for _, ev := range current {
+				if ev.TimeNano < sinceNanoUnix || (untilNanoUnix > 0 && ev.TimeNano > untilNanoUnix) {
+					continue
+				}
+				if topic == nil || topic(ev) {
+					buffered = append(buffered, ev)
+				}
+			}
+			return buffered
+		}
+		logrus.WithError(err).Warn("failed to read events journal, falling back to in-memory events")
+		buffered = nil
+	}
+
+	for i := len(current) - 1; i >= 0; i-- {
+		ev := current[i]
 
 		if ev.TimeNano < sinceNanoUnix {
 			break
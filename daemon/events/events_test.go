@@ -2,12 +2,16 @@ package events // import "github.com/docker/docker/daemon/events"
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 	"testing"
 	"time"
 
 	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
 	timetypes "github.com/docker/docker/api/types/time"
 	eventstestutils "github.com/docker/docker/daemon/events/testutils"
+	"github.com/docker/docker/errdefs"
 )
 
 func TestEventsLog(t *testing.T) {
@@ -197,7 +201,7 @@ func TestLoadBufferedEvents(t *testing.T) {
 	since := time.Unix(s, sNano)
 	until := time.Time{}
 
-	out := events.loadBufferedEvents(since, until, nil)
+	out := events.loadBufferedEvents(since, until, nil, events.events, events.evicted+1)
 	if len(out) != 1 {
 		t.Fatalf("expected 1 message, got %d: %v", len(out), out)
 	}
@@ -243,7 +247,7 @@ func TestLoadBufferedEventsOnlyFromPast(t *testing.T) {
 	since := time.Unix(s, sNano)
 	until := time.Unix(u, uNano)
 
-	out := events.loadBufferedEvents(since, until, nil)
+	out := events.loadBufferedEvents(since, until, nil, events.events, events.evicted+1)
 	if len(out) != 1 {
 		t.Fatalf("expected 1 message, got %d: %v", len(out), out)
 	}
@@ -275,8 +279,93 @@ func TestIgnoreBufferedWhenNoTimes(t *testing.T) {
 	since := time.Time{}
 	until := time.Time{}
 
-	out := events.loadBufferedEvents(since, until, nil)
+	out := events.loadBufferedEvents(since, until, nil, events.events, events.evicted+1)
 	if len(out) != 0 {
 		t.Fatalf("expected 0 buffered events, got %q", out)
 	}
 }
+
+func TestEventsAfter(t *testing.T) {
+	e := New()
+
+	var seqs []uint64
+	for i := 0; i < eventsLimit+16; i++ {
+		e.Log(fmt.Sprintf("action_%d", i), events.ContainerEventType, events.Actor{ID: fmt.Sprintf("cont_%d", i%2)})
+		seqs = append(seqs, e.events[len(e.events)-1].Sequence)
+	}
+	for i := 1; i < len(seqs); i++ {
+		if seqs[i] != seqs[i-1]+1 {
+			t.Fatalf("expected contiguous sequences, got %d after %d", seqs[i], seqs[i-1])
+		}
+	}
+
+	after := seqs[len(seqs)-10]
+	out, last, err := e.After(after, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 9 || out[0].Sequence != after+1 {
+		t.Fatalf("expected 9 events following %d, got %v", after, out)
+	}
+	if last != seqs[len(seqs)-1] {
+		t.Fatalf("expected last sequence %d, got %d", seqs[len(seqs)-1], last)
+	}
+
+	// filtered events still advance the cursor
+	f := filters.NewArgs(filters.Arg("container", "cont_0"))
+	out, last, err = e.After(after, NewFilter(f))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 4 || last != seqs[len(seqs)-1] {
+		t.Fatalf("expected 4 filtered events up to %d, got %d up to %d", seqs[len(seqs)-1], len(out), last)
+	}
+
+	// the first 16 events were discarded from the buffer
+	if _, _, err := e.After(seqs[0], nil); !errdefs.IsInvalidParameter(err) {
+		t.Fatalf("expected an invalid parameter error, got %v", err)
+	}
+	if _, _, err := e.After(last+1, nil); !errdefs.IsInvalidParameter(err) {
+		t.Fatalf("expected an invalid parameter error, got %v", err)
+	}
+}
+
+func TestEventsAfterWithJournal(t *testing.T) {
+	root, err := ioutil.TempDir("", "events-journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	e, err := NewWithJournal(root, 1024*1024, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < eventsLimit+16; i++ {
+		e.Log(fmt.Sprintf("action_%d", i), events.ContainerEventType, events.Actor{ID: "cont"})
+	}
+	first := e.events[0].Sequence - 16
+	e.Close()
+
+	// sequences keep increasing after a restart
+	e, err = NewWithJournal(root, 1024*1024, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+	e.Log("restarted", events.ContainerEventType, events.Actor{ID: "cont"})
+
+	out, last, err := e.After(first, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != eventsLimit+16 {
+		t.Fatalf("expected %d events, got %d", eventsLimit+16, len(out))
+	}
+	if out[0].Action != "action_1" || out[len(out)-1].Action != "restarted" {
+		t.Fatalf("unexpected events %s to %s", out[0].Action, out[len(out)-1].Action)
+	}
+	if last != out[len(out)-1].Sequence || last <= first+eventsLimit+15 {
+		t.Fatalf("expected last sequence after %d, got %d", first+eventsLimit+15, last)
+	}
+}
@@ -31,6 +31,7 @@ func (ef *Filter) Include(ev events.Message) bool {
 		ef.matchService(ev) &&
 		ef.matchSecret(ev) &&
 		ef.matchConfig(ev) &&
+		ef.matchAlert(ev) &&
 		ef.matchLabels(ev.Actor.Attributes)
 }
 
@@ -102,6 +103,12 @@ func (ef *Filter) matchConfig(ev events.Message) bool {
 	return ef.fuzzyMatchName(ev, events.ConfigEventType)
 }
 
+// matchAlert matches against the name of the resource alert of the
+// threshold-exceeded and threshold-cleared container events.
+func (ef *Filter) matchAlert(ev events.Message) bool {
+	return ef.filter.ExactMatch("alert", ev.Actor.Attributes["alert"])
+}
+
 func (ef *Filter) fuzzyMatchName(ev events.Message, eventType string) bool {
 	return ef.filter.FuzzyMatch(eventType, ev.Actor.ID) ||
 		ef.filter.FuzzyMatch(eventType, ev.Actor.Attributes["name"])
@@ -0,0 +1,523 @@
+package events // import "github.com/docker/docker/daemon/events"
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	eventtypes "github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/pkg/ioutils"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// journalSegments is the number of segments the journal size limit is
+	// divided into. Retention is enforced by removing whole segments, so
+	// this also determines the granularity of the size limit.
+	journalSegments = 8
+	// journalIndexInterval is the number of messages between two entries
+	// of the sparse timestamp index kept for each segment.
+	journalIndexInterval = 64
+	// journalMaxMessageSize is the largest encoded message the journal
+	// will read back.
+	journalMaxMessageSize = 1024 * 1024
+
+	segmentExt = ".log"
+	indexExt   = ".idx"
+	// sequenceFile stores the evicted sequence, so that sequences keep
+	// increasing even when all the messages were removed from the journal.
+	sequenceFile = "sequence"
+
+	// indexEntrySize is the on-disk size of an indexEntry.
+	indexEntrySize = 24
+)
+
+// indexEntry maps the timestamp and sequence of a message to its offset in
+// a segment.
+type indexEntry struct {
+	TimeNano int64
+	Sequence uint64
+	Offset   int64
+}
+
+// segment is a single file of the journal. Segments are named after the
+// timestamp of the first message they contain, so the list of segments
+// is itself an index by timestamp.
+type segment struct {
+	path     string
+	first    int64
+	last     int64
+	firstSeq uint64
+	lastSeq  uint64
+	size     int64
+	count    int
+	index    []indexEntry
+}
+
+// journal is an append-only, size and age bounded on-disk log of event
+// messages. Appends must be serialized by the caller, but they can run
+// concurrently with reads: mu only guards the list of segments, and
+// readers scan a snapshot of it.
+type journal struct {
+	root        string
+	maxSize     int64
+	maxAge      time.Duration
+	segmentSize int64
+
+	mu       sync.Mutex
+	segments []*segment
+	size     int64
+	f        *os.File
+	idx      *os.File
+
+	// evicted is the sequence of the last message that was removed from
+	// the journal. Messages up to this sequence can no longer be read.
+	evicted uint64
+}
+
+// newJournal opens the journal stored in root, creating it if needed.
+// maxSize is the maximum total size of the journal in bytes, maxAge is
+// the maximum age of the messages it retains. A zero maxAge disables the
+// age limit.
+func newJournal(root string, maxSize int64, maxAge time.Duration) (*journal, error) {
+	if maxSize <= 0 {
+		return nil, errors.New("events journal size must be greater than zero")
+	}
+	if err := os.MkdirAll(root, 0700); err != nil {
+		return nil, errors.Wrap(err, "error creating events journal directory")
+	}
+	j := &journal{
+		root:        root,
+		maxSize:     maxSize,
+		maxAge:      maxAge,
+		segmentSize: maxSize / journalSegments,
+	}
+	if j.segmentSize == 0 {
+		j.segmentSize = maxSize
+	}
+
+	if err := j.load(); err != nil {
+		return nil, err
+	}
+	j.prune(time.Now())
+	return j, nil
+}
+
+// load reads the segments found in the journal directory.
+func (j *journal) load() error {
+	files, err := ioutil.ReadDir(j.root)
+	if err != nil {
+		return errors.Wrap(err, "error reading events journal directory")
+	}
+	for _, fi := range files {
+		name := fi.Name()
+		if fi.IsDir() || filepath.Ext(name) != segmentExt {
+			continue
+		}
+		first, err := strconv.ParseInt(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			logrus.WithField("file", name).Warn("Ignoring unknown file in events journal")
+			continue
+		}
+		s := &segment{
+			path:  filepath.Join(j.root, strings.TrimSuffix(name, segmentExt)),
+			first: first,
+		}
+		if err := s.load(); err != nil {
+			return errors.Wrapf(err, "error loading events journal segment %s", name)
+		}
+		j.segments = append(j.segments, s)
+		j.size += s.size
+	}
+	sort.Slice(j.segments, func(i, k int) bool { return j.segments[i].first < j.segments[k].first })
+	if len(j.segments) > 0 && j.segments[0].firstSeq > 0 {
+		j.evicted = j.segments[0].firstSeq - 1
+	}
+	if data, err := ioutil.ReadFile(filepath.Join(j.root, sequenceFile)); err == nil {
+		if seq, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64); err == nil && seq > j.evicted {
+			j.evicted = seq
+		} else if err != nil {
+			logrus.WithError(err).Warn("Ignoring invalid events journal sequence")
+		}
+	} else if !os.IsNotExist(err) {
+		return errors.Wrap(err, "error reading events journal sequence")
+	}
+	return nil
+}
+
+// lastSequence returns the sequence of the last message in the journal, or
+// zero if the journal is empty.
+func (j *journal) lastSequence() uint64 {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for i := len(j.segments) - 1; i >= 0; i-- {
+		if j.segments[i].count > 0 {
+			return j.segments[i].lastSeq
+		}
+	}
+	return 0
+}
+
+// evictedSequence returns the sequence of the last message that was removed
+// from the journal.
+func (j *journal) evictedSequence() uint64 {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.evicted
+}
+
+// append writes a message to the journal, creating a new segment if the
+// current one is full, and removes segments that are out of bounds.
+func (j *journal) append(m eventtypes.Message) error {
+	buf, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	buf = append(buf, '\n')
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.f == nil || j.current().size >= j.segmentSize {
+		if err := j.roll(m.TimeNano); err != nil {
+			return err
+		}
+	}
+
+	s := j.current()
+	if s.count%journalIndexInterval == 0 {
+		e := indexEntry{TimeNano: m.TimeNano, Sequence: m.Sequence, Offset: s.size}
+		if err := binary.Write(j.idx, binary.LittleEndian, e); err != nil {
+			return errors.Wrap(err, "error writing events journal index")
+		}
+		s.index = append(s.index, e)
+	}
+	n, err := j.f.Write(buf)
+	s.size += int64(n)
+	j.size += int64(n)
+	if err != nil {
+		return errors.Wrap(err, "error writing events journal")
+	}
+	if s.count == 0 {
+		s.firstSeq = m.Sequence
+	}
+	s.count++
+	s.last = m.TimeNano
+	s.lastSeq = m.Sequence
+
+	j.prune(time.Now())
+	return nil
+}
+
+// roll closes the active segment and starts a new one.
+func (j *journal) roll(first int64) error {
+	if err := j.closeFiles(); err != nil {
+		return err
+	}
+	if len(j.segments) > 0 && j.segments[len(j.segments)-1].first >= first {
+		// keep segment names unique and ordered even if the clock moved back
+		first = j.segments[len(j.segments)-1].first + 1
+	}
+	s := &segment{
+		path:  filepath.Join(j.root, fmt.Sprintf("%020d", first)),
+		first: first,
+		last:  first,
+	}
+	f, err := os.OpenFile(s.path+segmentExt, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.Wrap(err, "error creating events journal segment")
+	}
+	idx, err := os.OpenFile(s.path+indexExt, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		f.Close()
+		os.Remove(s.path + segmentExt)
+		return errors.Wrap(err, "error creating events journal index")
+	}
+	j.f, j.idx = f, idx
+	j.segments = append(j.segments, s)
+	return nil
+}
+
+// prune removes the oldest segments until the journal is within its size
+// and age limits. The active segment is never removed.
+func (j *journal) prune(now time.Time) {
+	evicted := j.evicted
+	defer func() {
+		if j.evicted != evicted {
+			j.writeSequence()
+		}
+	}()
+	for len(j.segments) > 1 {
+		oldest := j.segments[0]
+		if j.size <= j.maxSize && (j.maxAge == 0 || oldest.last >= now.Add(-j.maxAge).UnixNano()) {
+			return
+		}
+		if j.f != nil && oldest == j.current() {
+			return
+		}
+		if err := oldest.remove(); err != nil {
+			logrus.WithError(err).WithField("segment", oldest.path).Warn("Failed to remove events journal segment")
+		}
+		j.size -= oldest.size
+		j.evicted = oldest.lastSeq
+		j.segments = j.segments[1:]
+	}
+}
+
+// writeSequence stores the evicted sequence next to the segments.
+func (j *journal) writeSequence() {
+	data := []byte(strconv.FormatUint(j.evicted, 10))
+	if err := ioutils.AtomicWriteFile(filepath.Join(j.root, sequenceFile), data, 0600); err != nil {
+		logrus.WithError(err).Warn("Failed to write events journal sequence")
+	}
+}
+
+// snapshot returns a copy of the segments of the journal, which can be read
+// without holding mu. Messages appended after the snapshot was taken are
+// not visible through it.
+func (j *journal) snapshot() []segment {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	segments := make([]segment, len(j.segments))
+	for i, s := range j.segments {
+		segments[i] = *s
+	}
+	return segments
+}
+
+// read calls fn for every message in the journal whose timestamp is within
+// since and until, in the order they were written. A zero until means no
+// upper bound. Reading stops if fn returns false.
+func (j *journal) read(since, until int64, fn func(eventtypes.Message) bool) error {
+	segments := j.snapshot()
+	for i := range segments {
+		s := &segments[i]
+		if s.last < since {
+			continue
+		}
+		if until > 0 && s.first > until {
+			break
+		}
+		more, err := s.read(since, until, fn)
+		if os.IsNotExist(errors.Cause(err)) {
+			// the segment was pruned since the snapshot was taken
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !more {
+			break
+		}
+	}
+	return nil
+}
+
+// readAfter calls fn for every message in the journal with a sequence
+// greater than after, in the order they were written. Reading stops if fn
+// returns false.
+func (j *journal) readAfter(after uint64, fn func(eventtypes.Message) bool) error {
+	segments := j.snapshot()
+	for i := range segments {
+		s := &segments[i]
+		if s.count == 0 || s.lastSeq <= after {
+			continue
+		}
+		i := sort.Search(len(s.index), func(i int) bool { return s.index[i].Sequence > after })
+		var offset int64
+		if i > 0 {
+			offset = s.index[i-1].Offset
+		}
+		more, err := s.scan(offset, func(m eventtypes.Message) bool {
+			if m.Sequence <= after {
+				return true
+			}
+			return fn(m)
+		})
+		if os.IsNotExist(errors.Cause(err)) {
+			// the segment was pruned since the snapshot was taken
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !more {
+			break
+		}
+	}
+	return nil
+}
+
+// current returns the segment being written to.
+func (j *journal) current() *segment {
+	return j.segments[len(j.segments)-1]
+}
+
+func (j *journal) closeFiles() error {
+	if j.f == nil {
+		return nil
+	}
+	err := j.f.Close()
+	if err2 := j.idx.Close(); err == nil {
+		err = err2
+	}
+	j.f, j.idx = nil, nil
+	return err
+}
+
+// close closes the files of the active segment.
+func (j *journal) close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.closeFiles()
+}
+
+// load restores the state of a segment from its index and data files.
+// Messages written after the last index entry are re-read to find the
+// timestamp of the last message, and a partially written trailing
+// message is discarded.
+func (s *segment) load() error {
+	if err := s.loadIndex(); err != nil {
+		logrus.WithError(err).WithField("segment", s.path).Warn("Rebuilding events journal index")
+		s.index = nil
+	}
+
+	f, err := os.OpenFile(s.path+segmentExt, os.O_RDWR, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var offset int64
+	if len(s.index) > 0 {
+		offset = s.index[len(s.index)-1].Offset
+		s.count = (len(s.index) - 1) * journalIndexInterval
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	rebuild := len(s.index) == 0
+	s.last = s.first
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		var m eventtypes.Message
+		if err := json.Unmarshal(line, &m); err != nil {
+			break
+		}
+		if rebuild && s.count%journalIndexInterval == 0 {
+			s.index = append(s.index, indexEntry{TimeNano: m.TimeNano, Sequence: m.Sequence, Offset: offset})
+		}
+		offset += int64(len(line))
+		s.count++
+		s.last = m.TimeNano
+		s.lastSeq = m.Sequence
+	}
+
+	if err := f.Truncate(offset); err != nil {
+		return err
+	}
+	s.size = offset
+	if len(s.index) > 0 {
+		s.firstSeq = s.index[0].Sequence
+	}
+	if rebuild {
+		return s.writeIndex()
+	}
+	return nil
+}
+
+func (s *segment) loadIndex() error {
+	buf, err := ioutil.ReadFile(s.path + indexExt)
+	if err != nil {
+		return err
+	}
+	if len(buf)%indexEntrySize != 0 {
+		return errors.New("invalid index size")
+	}
+	index := make([]indexEntry, len(buf)/indexEntrySize)
+	if err := binary.Read(bytes.NewReader(buf), binary.LittleEndian, index); err != nil {
+		return err
+	}
+	s.index = index
+	return nil
+}
+
+func (s *segment) writeIndex() error {
+	var buf bytes.Buffer
+	if err := binary.Write(&buf, binary.LittleEndian, s.index); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.path+indexExt, buf.Bytes(), 0600)
+}
+
+// read scans the segment starting at the closest indexed position before
+// since. It returns false if fn asked to stop reading.
+func (s *segment) read(since, until int64, fn func(eventtypes.Message) bool) (bool, error) {
+	i := sort.Search(len(s.index), func(i int) bool { return s.index[i].TimeNano >= since })
+	var offset int64
+	if i > 0 {
+		offset = s.index[i-1].Offset
+	}
+	return s.scan(offset, func(m eventtypes.Message) bool {
+		if m.TimeNano < since || (until > 0 && m.TimeNano > until) {
+			return true
+		}
+		return fn(m)
+	})
+}
+
+// scan calls fn for every message of the segment starting at offset. It
+// returns false if fn asked to stop reading.
+func (s *segment) scan(offset int64, fn func(eventtypes.Message) bool) (bool, error) {
+	f, err := os.Open(s.path + segmentExt)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return false, err
+	}
+
+	scanner := bufio.NewScanner(io.LimitReader(f, s.size-offset))
+	scanner.Buffer(nil, journalMaxMessageSize)
+	for scanner.Scan() {
+		var m eventtypes.Message
+		if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+			return false, errors.Wrapf(err, "error decoding events journal segment %s", s.path)
+		}
+		if !fn(m) {
+			return false, nil
+		}
+	}
+	return true, scanner.Err()
+}
+
+func (s *segment) remove() error {
+	if err := os.Remove(s.path + segmentExt); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Remove(s.path + indexExt); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package events // import "github.com/docker/docker/daemon/events"
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	eventtypes "github.com/docker/docker/api/types/events"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func journalMessage(i int, t int64) eventtypes.Message {
+	return eventtypes.Message{
+		Action:   fmt.Sprintf("action_%d", i),
+		Type:     eventtypes.ContainerEventType,
+		Actor:    eventtypes.Actor{ID: fmt.Sprintf("cont_%d", i)},
+		Scope:    "local",
+		Time:     t / int64(time.Second),
+		TimeNano: t,
+	}
+}
+
+func readAll(t *testing.T, j *journal, since, until int64) []eventtypes.Message {
+	var msgs []eventtypes.Message
+	err := j.read(since, until, func(m eventtypes.Message) bool {
+		msgs = append(msgs, m)
+		return true
+	})
+	assert.NilError(t, err)
+	return msgs
+}
+
+func TestJournalReadSinceUntil(t *testing.T) {
+	root, err := ioutil.TempDir("", "events-journal")
+	assert.NilError(t, err)
+	defer os.RemoveAll(root)
+
+	j, err := newJournal(root, 1024*1024, 0)
+	assert.NilError(t, err)
+
+	base := time.Now().UnixNano()
+	for i := 0; i < 500; i++ {
+		assert.NilError(t, j.append(journalMessage(i, base+int64(i))))
+	}
+
+	msgs := readAll(t, j, base+100, base+199)
+	assert.Assert(t, is.Len(msgs, 100))
+	assert.Check(t, is.Equal("action_100", msgs[0].Action))
+	assert.Check(t, is.Equal("action_199", msgs[99].Action))
+
+	msgs = readAll(t, j, base+450, 0)
+	assert.Check(t, is.Len(msgs, 50))
+}
+
+func TestJournalReopen(t *testing.T) {
+	root, err := ioutil.TempDir("", "events-journal")
+	assert.NilError(t, err)
+	defer os.RemoveAll(root)
+
+	j, err := newJournal(root, 1024*1024, 0)
+	assert.NilError(t, err)
+
+	base := time.Now().UnixNano()
+	for i := 0; i < 100; i++ {
+		assert.NilError(t, j.append(journalMessage(i, base+int64(i))))
+	}
+	assert.NilError(t, j.close())
+
+	// simulate a crash in the middle of a write
+	seg := j.current().path + segmentExt
+	f, err := os.OpenFile(seg, os.O_WRONLY|os.O_APPEND, 0600)
+	assert.NilError(t, err)
+	_, err = f.WriteString(`{"Type":"container","Act`)
+	assert.NilError(t, err)
+	f.Close()
+	// and a lost index
+	assert.NilError(t, os.Remove(j.current().path+indexExt))
+
+	j, err = newJournal(root, 1024*1024, 0)
+	assert.NilError(t, err)
+	defer j.close()
+	assert.NilError(t, j.append(journalMessage(100, base+100)))
+
+	msgs := readAll(t, j, base, 0)
+	assert.Assert(t, is.Len(msgs, 101))
+	assert.Check(t, is.Equal("action_100", msgs[100].Action))
+
+	_, err = os.Stat(filepath.Join(j.current().path + indexExt))
+	assert.Check(t, err)
+}
+
+func TestJournalRetention(t *testing.T) {
+	root, err := ioutil.TempDir("", "events-journal")
+	assert.NilError(t, err)
+	defer os.RemoveAll(root)
+
+	const maxSize = 16 * 1024
+	j, err := newJournal(root, maxSize, 0)
+	assert.NilError(t, err)
+	defer j.close()
+
+	base := time.Now().UnixNano()
+	for i := 0; i < 1000; i++ {
+		assert.NilError(t, j.append(journalMessage(i, base+int64(i))))
+	}
+	assert.Check(t, j.size <= maxSize+j.segmentSize)
+	assert.Check(t, len(j.segments) > 1)
+
+	msgs := readAll(t, j, base, 0)
+	assert.Assert(t, len(msgs) > 0)
+	assert.Check(t, msgs[0].Action != "action_0")
+	assert.Check(t, is.Equal("action_999", msgs[len(msgs)-1].Action))
+}
+
+func TestJournalMaxAge(t *testing.T) {
+	root, err := ioutil.TempDir("", "events-journal")
+	assert.NilError(t, err)
+	defer os.RemoveAll(root)
+
+	j, err := newJournal(root, 1024, time.Hour)
+	assert.NilError(t, err)
+
+	old := time.Now().Add(-2 * time.Hour).UnixNano()
+	for i := 0; i < 50; i++ {
+		assert.NilError(t, j.append(journalMessage(i, old+int64(i))))
+	}
+	now := time.Now().UnixNano()
+	for i := 50; i < 100; i++ {
+		assert.NilError(t, j.append(journalMessage(i, now+int64(i))))
+	}
+
+	for _, m := range readAll(t, j, old, 0) {
+		assert.Check(t, m.TimeNano >= now, m.Action)
+	}
+}
+
+func TestJournalSequence(t *testing.T) {
+	root, err := ioutil.TempDir("", "events-journal")
+	assert.NilError(t, err)
+	defer os.RemoveAll(root)
+
+	const maxSize = 16 * 1024
+	j, err := newJournal(root, maxSize, 0)
+	assert.NilError(t, err)
+	seq := uint64(time.Now().Add(time.Hour).UnixNano())
+	base := time.Now().UnixNano()
+	for i := 0; i < 1000; i++ {
+		m := journalMessage(i, base+int64(i))
+		m.Sequence = seq + uint64(i)
+		assert.NilError(t, j.append(m))
+	}
+	evicted := j.evictedSequence()
+	assert.Assert(t, evicted >= seq)
+	assert.NilError(t, j.close())
+
+	// the evicted sequence is kept when the messages are gone
+	files, err := filepath.Glob(filepath.Join(root, "*"+segmentExt))
+	assert.NilError(t, err)
+	for _, f := range files {
+		assert.NilError(t, os.Remove(f))
+	}
+	e, err := NewWithJournal(root, maxSize, 0)
+	assert.NilError(t, err)
+	defer e.Close()
+	e.Log("restarted", eventtypes.ContainerEventType, eventtypes.Actor{ID: "cont"})
+	assert.Check(t, is.Equal(evicted+1, e.events[0].Sequence))
+}
+
+func TestEventsWithJournal(t *testing.T) {
+	root, err := ioutil.TempDir("", "events-journal")
+	assert.NilError(t, err)
+	defer os.RemoveAll(root)
+
+	e, err := NewWithJournal(root, 1024*1024, 0)
+	assert.NilError(t, err)
+	since := time.Now()
+	for i := 0; i < eventsLimit+16; i++ {
+		e.Log(fmt.Sprintf("action_%d", i), eventtypes.ContainerEventType, eventtypes.Actor{ID: "cont"})
+	}
+	assert.NilError(t, e.Close())
+
+	// events are replayed after a restart
+	e, err = NewWithJournal(root, 1024*1024, 0)
+	assert.NilError(t, err)
+	defer e.Close()
+
+	buffered, l := e.SubscribeTopic(since, time.Time{}, nil)
+	defer e.Evict(l)
+	assert.Assert(t, is.Len(buffered, eventsLimit+16))
+	assert.Check(t, is.Equal("action_0", buffered[0].Action))
+}
+
+func TestEventsWithPendingJournal(t *testing.T) {
+	root, err := ioutil.TempDir("", "events-journal")
+	assert.NilError(t, err)
+	defer os.RemoveAll(root)
+
+	e, err := NewWithJournal(root, 1024*1024, 0)
+	assert.NilError(t, err)
+	defer e.Close()
+
+	// events that may not have been written to the journal yet are read
+	// from the buffer, without gaps or duplicates
+	since := time.Now()
+	for i := 0; i < eventsLimit+16; i++ {
+		e.Log(fmt.Sprintf("action_%d", i), eventtypes.ContainerEventType, eventtypes.Actor{ID: "cont"})
+	}
+	buffered, l := e.SubscribeTopic(since, time.Time{}, nil)
+	defer e.Evict(l)
+	assert.Assert(t, is.Len(buffered, eventsLimit+16))
+	for i, m := range buffered {
+		assert.Check(t, is.Equal(fmt.Sprintf("action_%d", i), m.Action))
+	}
+}
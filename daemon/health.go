@@ -3,10 +3,18 @@ package daemon // import "github.com/docker/docker/daemon"
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/docker/docker/api/types"
@@ -14,6 +22,7 @@ import (
 	"github.com/docker/docker/api/types/strslice"
 	"github.com/docker/docker/container"
 	"github.com/docker/docker/daemon/exec"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
@@ -44,9 +53,66 @@ const (
 const (
 	// Exit status codes that can be returned by the probe command.
 
-	exitStatusHealthy = 0 // Container is healthy
+	exitStatusHealthy   = 0 // Container is healthy
+	exitStatusUnhealthy = 1 // Container is unhealthy
 )
 
+const (
+	// Status codes accepted by HTTP probes when none are configured.
+	defaultHTTPProbeMinStatus = 200
+	defaultHTTPProbeMaxStatus = 399
+)
+
+// probeKind identifies which of the container's probes a monitor runs.
+type probeKind int
+
+const (
+	// livenessProbe is the container's healthcheck. It sets the health
+	// status of the container.
+	livenessProbe probeKind = iota
+	// startupProbe runs until it succeeds once. The other probes only run
+	// once it succeeded.
+	startupProbe
+	// readinessProbe sets whether the container is ready to take traffic.
+	readinessProbe
+)
+
+func (k probeKind) String() string {
+	switch k {
+	case startupProbe:
+		return "startup probe"
+	case readinessProbe:
+		return "readiness probe"
+	default:
+		return "health check"
+	}
+}
+
+// config returns the container's configuration for this kind of probe.
+func (k probeKind) config(c *container.Container) *containertypes.HealthConfig {
+	switch k {
+	case startupProbe:
+		return c.Config.StartupProbe
+	case readinessProbe:
+		return c.Config.ReadinessProbe
+	default:
+		return c.Config.Healthcheck
+	}
+}
+
+// handleResult updates the container's state based on the latest result of
+// this kind of probe.
+func (k probeKind) handleResult(d *Daemon, c *container.Container, result *types.HealthcheckResult, done chan struct{}) {
+	switch k {
+	case startupProbe:
+		handleStartupProbeResult(d, c, result, done)
+	case readinessProbe:
+		handleReadinessProbeResult(d, c, result, done)
+	default:
+		handleProbeResult(d, c, result, done)
+	}
+}
+
 // probe implementations know how to run a particular type of probe.
 type probe interface {
 	// Perform one run of the check. Returns the exit code and an optional
@@ -114,6 +180,203 @@ func (p *cmdProbe) run(ctx context.Context, d *Daemon, cntr *container.Container
 	}, nil
 }
 
+// httpProbe implements the "HTTP" probe type.
+//
+// The test is {"HTTP", url, options...}. The request is sent from within the
+// container's network namespace, so a URL without a host, or with
+// "localhost", reaches the container's loopback interface. Other hosts must
+// be IP addresses, see probeHost. The options are:
+//
+//	status=<code>[-<code>] : accepted status codes (default 200-399)
+//	body=<text>            : text that the response body must contain
+type httpProbe struct {
+	url       string
+	minStatus int
+	maxStatus int
+	body      string
+}
+
+func newHTTPProbe(args []string) (*httpProbe, error) {
+	if len(args) == 0 {
+		return nil, errors.New("HTTP health check requires a URL")
+	}
+	u, err := url.Parse(args[0])
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid HTTP health check URL")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.Errorf("invalid HTTP health check URL %q: scheme must be http or https", args[0])
+	}
+	host, err := probeHost(u.Hostname())
+	if err != nil {
+		return nil, err
+	}
+	if port := u.Port(); port != "" {
+		u.Host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		u.Host = "[" + host + "]"
+	} else {
+		u.Host = host
+	}
+
+	p := &httpProbe{
+		url:       u.String(),
+		minStatus: defaultHTTPProbeMinStatus,
+		maxStatus: defaultHTTPProbeMaxStatus,
+	}
+	for _, opt := range args[1:] {
+		kv := strings.SplitN(opt, "=", 2)
+		if len(kv) != 2 {
+			return nil, errors.Errorf("invalid HTTP health check option %q", opt)
+		}
+		switch kv[0] {
+		case "status":
+			if p.minStatus, p.maxStatus, err = parseStatusRange(kv[1]); err != nil {
+				return nil, err
+			}
+		case "body":
+			p.body = kv[1]
+		default:
+			return nil, errors.Errorf("unknown HTTP health check option %q", kv[0])
+		}
+	}
+	return p, nil
+}
+
+// parseStatusRange parses a single HTTP status code or an inclusive range
+// of status codes such as "200-299".
+func parseStatusRange(value string) (int, int, error) {
+	parts := strings.SplitN(value, "-", 2)
+	lo, err := strconv.Atoi(parts[0])
+	if err != nil || lo < 100 || lo > 599 {
+		return 0, 0, errors.Errorf("invalid HTTP health check status %q", value)
+	}
+	hi := lo
+	if len(parts) == 2 {
+		hi, err = strconv.Atoi(parts[1])
+		if err != nil || hi < lo || hi > 599 {
+			return 0, 0, errors.Errorf("invalid HTTP health check status %q", value)
+		}
+	}
+	return lo, hi, nil
+}
+
+// send the request from the container's network namespace and check the
+// response. Connection failures are reported as an unhealthy result.
+func (p *httpProbe) run(ctx context.Context, d *Daemon, cntr *container.Container) (*types.HealthcheckResult, error) {
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return dialInContainer(ctx, cntr, network, addr)
+			},
+			DisableKeepAlives: true,
+			// Like the commands used for health checks, which typically
+			// don't verify certificates, the probe only checks that the
+			// service responds.
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+	req, err := http.NewRequest(http.MethodGet, p.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return &types.HealthcheckResult{
+			End:      time.Now(),
+			ExitCode: exitStatusUnhealthy,
+			Output:   err.Error(),
+		}, nil
+	}
+	defer resp.Body.Close()
+
+	output := &limitedBuffer{}
+	fmt.Fprintf(output, "%s %s\n", resp.Proto, resp.Status)
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxOutputLen))
+	if err != nil {
+		return nil, err
+	}
+	output.Write(body)
+
+	exitCode := exitStatusHealthy
+	if resp.StatusCode < p.minStatus || resp.StatusCode > p.maxStatus {
+		exitCode = exitStatusUnhealthy
+	}
+	if p.body != "" && !bytes.Contains(body, []byte(p.body)) {
+		exitCode = exitStatusUnhealthy
+	}
+	return &types.HealthcheckResult{
+		End:      time.Now(),
+		ExitCode: exitCode,
+		Output:   output.String(),
+	}, nil
+}
+
+// tcpProbe implements the "TCP" probe type.
+//
+// The test is {"TCP", address}, where address is "host:port" or ":port".
+// The container is healthy if a connection can be established from within
+// its network namespace. A missing host or "localhost" is the container's
+// loopback interface, other hosts must be IP addresses, see probeHost.
+type tcpProbe struct {
+	addr string
+}
+
+func newTCPProbe(args []string) (*tcpProbe, error) {
+	if len(args) != 1 {
+		return nil, errors.New("TCP health check requires an address")
+	}
+	host, port, err := net.SplitHostPort(args[0])
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid TCP health check address")
+	}
+	if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 {
+		return nil, errors.Errorf("invalid TCP health check port %q", port)
+	}
+	if host, err = probeHost(host); err != nil {
+		return nil, err
+	}
+	return &tcpProbe{addr: net.JoinHostPort(host, port)}, nil
+}
+
+// probeHost returns the IP address to connect to for the host of a probe.
+// A missing host or "localhost" is the container's loopback interface.
+// Host names are rejected: they would be resolved by the daemon, with the
+// host's resolver configuration and DNS servers, instead of the container's.
+func probeHost(host string) (string, error) {
+	if host == "" || host == "localhost" {
+		return "127.0.0.1", nil
+	}
+	if net.ParseIP(host) == nil {
+		return "", errors.Errorf("invalid health check host %q: only IP addresses and localhost are supported", host)
+	}
+	return host, nil
+}
+
+// connect to the address from the container's network namespace.
+func (p *tcpProbe) run(ctx context.Context, d *Daemon, cntr *container.Container) (*types.HealthcheckResult, error) {
+	conn, err := dialInContainer(ctx, cntr, "tcp", p.addr)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return &types.HealthcheckResult{
+			End:      time.Now(),
+			ExitCode: exitStatusUnhealthy,
+			Output:   err.Error(),
+		}, nil
+	}
+	conn.Close()
+	return &types.HealthcheckResult{
+		End:      time.Now(),
+		ExitCode: exitStatusHealthy,
+		Output:   fmt.Sprintf("connected to %s", p.addr),
+	}, nil
+}
+
 // Update the container's Status.Health struct based on the latest probe's result.
 func handleProbeResult(d *Daemon, c *container.Container, result *types.HealthcheckResult, done chan struct{}) {
 	c.Lock()
@@ -134,11 +397,7 @@ func handleProbeResult(d *Daemon, c *container.Container, result *types.Healthch
 	h := c.State.Health
 	oldStatus := h.Status()
 
-	if len(h.Log) >= maxLogEntries {
-		h.Log = append(h.Log[len(h.Log)+1-maxLogEntries:], result)
-	} else {
-		h.Log = append(h.Log, result)
-	}
+	h.Log = appendProbeLog(h.Log, result)
 
 	if result.ExitCode == exitStatusHealthy {
 		h.FailingStreak = 0
@@ -180,20 +439,197 @@ func handleProbeResult(d *Daemon, c *container.Container, result *types.Healthch
 	if oldStatus != current {
 		d.LogContainerEvent(c, "health_status: "+current)
 	}
+
+	if current == types.Unhealthy {
+		d.restartUnhealthyContainerIfNeeded(c)
+	}
+}
+
+// Update the container's Status.Health.Startup struct based on the latest
+// startup probe's result. The container is unhealthy if the startup probe
+// fails more than its retries once its start period is over.
+func handleStartupProbeResult(d *Daemon, c *container.Container, result *types.HealthcheckResult, done chan struct{}) {
+	c.Lock()
+	defer c.Unlock()
+
+	// probe may have been cancelled while waiting on lock. Ignore result then
+	select {
+	case <-done:
+		return
+	default:
+	}
+
+	config := c.Config.StartupProbe
+	retries := config.Retries
+	if retries <= 0 {
+		retries = defaultProbeRetries
+	}
+
+	h := c.State.Health
+	s := h.Startup
+	if s == nil || h.StartupStatus() != types.Starting {
+		return
+	}
+	s.Log = appendProbeLog(s.Log, result)
+
+	oldHealthStatus := h.Status()
+	if result.ExitCode == exitStatusHealthy {
+		s.FailingStreak = 0
+		h.SetStartupStatus(types.Started)
+	} else if result.Start.Sub(c.State.StartedAt) >= timeoutWithDefault(config.StartPeriod, defaultStartPeriod) {
+		s.FailingStreak++
+		if s.FailingStreak >= retries {
+			h.SetStartupStatus(types.Unhealthy)
+			h.SetStatus(types.Unhealthy)
+		}
+	}
+	updateReadiness(c)
+
+	if err := c.CheckpointTo(d.containersReplica); err != nil {
+		logrus.Errorf("Error replicating startup state for container %s: %v", c.ID, err)
+	}
+
+	if current := h.StartupStatus(); current != types.Starting {
+		d.LogContainerEvent(c, "startup_status: "+current)
+	}
+	if current := h.Status(); current != oldHealthStatus {
+		d.LogContainerEvent(c, "health_status: "+current)
+		d.restartUnhealthyContainerIfNeeded(c)
+	}
+}
+
+// Update the container's Status.Health.Readiness struct based on the latest
+// readiness probe's result.
+func handleReadinessProbeResult(d *Daemon, c *container.Container, result *types.HealthcheckResult, done chan struct{}) {
+	c.Lock()
+	defer c.Unlock()
+
+	// probe may have been cancelled while waiting on lock. Ignore result then
+	select {
+	case <-done:
+		return
+	default:
+	}
+
+	retries := c.Config.ReadinessProbe.Retries
+	if retries <= 0 {
+		retries = defaultProbeRetries
+	}
+
+	h := c.State.Health
+	s := h.Readiness
+	if s == nil {
+		return
+	}
+	s.Log = appendProbeLog(s.Log, result)
+
+	oldStatus := h.ReadinessStatus()
+	if result.ExitCode == exitStatusHealthy {
+		s.FailingStreak = 0
+		h.SetReadinessStatus(types.Ready)
+	} else {
+		s.FailingStreak++
+		if s.FailingStreak >= retries {
+			h.SetReadinessStatus(types.NotReady)
+		}
+	}
+	updateReadiness(c)
+
+	if err := c.CheckpointTo(d.containersReplica); err != nil {
+		logrus.Errorf("Error replicating readiness state for container %s: %v", c.ID, err)
+	}
+
+	if current := h.ReadinessStatus(); current != oldStatus {
+		d.LogContainerEvent(c, "readiness_status: "+current)
+	}
 }
 
-// Run the container's monitoring thread until notified via "stop".
-// There is never more than one monitor thread running per container at a time.
-func monitor(d *Daemon, c *container.Container, stop chan struct{}, probe probe) {
-	probeTimeout := timeoutWithDefault(c.Config.Healthcheck.Timeout, defaultProbeTimeout)
-	probeInterval := timeoutWithDefault(c.Config.Healthcheck.Interval, defaultProbeInterval)
+// updateReadiness sets whether the container is ready: it is running, its
+// startup probe succeeded and its readiness probe last succeeded.
+// Called with c locked.
+func updateReadiness(c *container.Container) {
+	ready := c.Running && !c.Restarting
+	if h := c.State.Health; h != nil {
+		readiness := h.ReadinessStatus()
+		ready = ready && h.Started() && (readiness == "" || readiness == types.Ready)
+	}
+	c.SetReady(ready)
+}
+
+// appendProbeLog appends a probe result to a log, keeping at most
+// maxLogEntries entries.
+func appendProbeLog(log []*types.HealthcheckResult, result *types.HealthcheckResult) []*types.HealthcheckResult {
+	if len(log) >= maxLogEntries {
+		return append(log[len(log)+1-maxLogEntries:], result)
+	}
+	return append(log, result)
+}
+
+// restartUnhealthyContainerIfNeeded restarts an unhealthy container with the
+// "on-unhealthy" restart policy once it stayed unhealthy long enough.
+// Called with c locked.
+func (d *Daemon) restartUnhealthyContainerIfNeeded(c *container.Container) {
+	h := c.State.Health
+	if h.Status() != types.Unhealthy || c.HostConfig == nil || !c.HostConfig.RestartPolicy.IsOnUnhealthy() {
+		return
+	}
+	if c.RestartManager().ShouldRestartUnhealthy(time.Since(h.UnhealthySince())) {
+		go d.restartUnhealthyContainer(c)
+	}
+}
+
+// restartUnhealthyContainer stops a container that stayed unhealthy, so that
+// the restart manager restarts it once it has exited. Unlike a stop requested
+// by the user, this doesn't cancel the restart manager.
+func (d *Daemon) restartUnhealthyContainer(c *container.Container) {
+	logrus.Infof("Container %s is unhealthy, restarting it", c.ID)
+
+	stopSignal := c.StopSignal()
+	if err := d.kill(c, stopSignal); err != nil {
+		logrus.Warnf("Failed to send signal %d to unhealthy container %s: %v", stopSignal, c.ID, err)
+	}
+
+	ctx := context.Background()
+	if seconds := c.StopTimeout(); seconds >= 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(seconds)*time.Second)
+		defer cancel()
+	}
+
+	if status := <-c.Wait(ctx, container.WaitConditionNotRunning); status.Err() != nil {
+		logrus.Infof("Unhealthy container %s failed to exit within its stop timeout - using the force", c.ID)
+		if err := d.kill(c, int(syscall.SIGKILL)); err != nil {
+			logrus.Warnf("Failed to kill unhealthy container %s: %v", c.ID, err)
+			if c.IsRunning() {
+				c.RestartManager().ResetUnhealthy()
+			}
+		}
+	}
+}
+
+// Run the container's monitoring thread for a kind of probe until notified via
+// "stop", or until the startup probe finished. There is never more than one
+// monitor thread running per container and kind of probe at a time.
+func monitor(d *Daemon, c *container.Container, stop chan struct{}, kind probeKind, probe probe) {
+	config := kind.config(c)
+	probeTimeout := timeoutWithDefault(config.Timeout, defaultProbeTimeout)
+	probeInterval := timeoutWithDefault(config.Interval, defaultProbeInterval)
+	h := c.State.Health
 	for {
+		if kind == startupProbe && h.StartupStatus() != types.Starting {
+			logrus.Debugf("Stop startup probe monitoring for container %s (startup %s)", c.ID, h.StartupStatus())
+			return
+		}
 		select {
 		case <-stop:
-			logrus.Debugf("Stop healthcheck monitoring for container %s (received while idle)", c.ID)
+			logrus.Debugf("Stop %s monitoring for container %s (received while idle)", kind, c.ID)
 			return
 		case <-time.After(probeInterval):
-			logrus.Debugf("Running health check for container %s ...", c.ID)
+			if kind != startupProbe && !h.Started() {
+				// wait for the startup probe to succeed
+				continue
+			}
+			logrus.Debugf("Running %s for container %s ...", kind, c.ID)
 			startTime := time.Now()
 			ctx, cancelProbe := context.WithTimeout(context.Background(), probeTimeout)
 			results := make(chan *types.HealthcheckResult, 1)
@@ -202,7 +638,7 @@ func monitor(d *Daemon, c *container.Container, stop chan struct{}, probe probe)
 				result, err := probe.run(ctx, d, c)
 				if err != nil {
 					healthChecksFailedCounter.Inc()
-					logrus.Warnf("Health check for container %s error: %v", c.ID, err)
+					logrus.Warnf("%s for container %s error: %v", strings.Title(kind.String()), c.ID, err)
 					results <- &types.HealthcheckResult{
 						ExitCode: -1,
 						Output:   err.Error(),
@@ -211,28 +647,28 @@ func monitor(d *Daemon, c *container.Container, stop chan struct{}, probe probe)
 					}
 				} else {
 					result.Start = startTime
-					logrus.Debugf("Health check for container %s done (exitCode=%d)", c.ID, result.ExitCode)
+					logrus.Debugf("%s for container %s done (exitCode=%d)", strings.Title(kind.String()), c.ID, result.ExitCode)
 					results <- result
 				}
 				close(results)
 			}()
 			select {
 			case <-stop:
-				logrus.Debugf("Stop healthcheck monitoring for container %s (received while probing)", c.ID)
+				logrus.Debugf("Stop %s monitoring for container %s (received while probing)", kind, c.ID)
 				cancelProbe()
 				// Wait for probe to exit (it might take a while to respond to the TERM
 				// signal and we don't want dying probes to pile up).
 				<-results
 				return
 			case result := <-results:
-				handleProbeResult(d, c, result, stop)
+				kind.handleResult(d, c, result, stop)
 				// Stop timeout
 				cancelProbe()
 			case <-ctx.Done():
-				logrus.Debugf("Health check for container %s taking too long", c.ID)
-				handleProbeResult(d, c, &types.HealthcheckResult{
+				logrus.Debugf("%s for container %s taking too long", strings.Title(kind.String()), c.ID)
+				kind.handleResult(d, c, &types.HealthcheckResult{
 					ExitCode: -1,
-					Output:   fmt.Sprintf("Health check exceeded timeout (%v)", probeTimeout),
+					Output:   fmt.Sprintf("%s exceeded timeout (%v)", strings.Title(kind.String()), probeTimeout),
 					Start:    startTime,
 					End:      time.Now(),
 				}, stop)
@@ -248,7 +684,13 @@ func monitor(d *Daemon, c *container.Container, stop chan struct{}, probe probe)
 // Get a suitable probe implementation for the container's healthcheck configuration.
 // Nil will be returned if no healthcheck was configured or NONE was set.
 func getProbe(c *container.Container) probe {
-	config := c.Config.Healthcheck
+	return getProbeForKind(c, livenessProbe)
+}
+
+// Get a suitable probe implementation for the configuration of a kind of probe
+// of the container. Nil will be returned if it was not configured or NONE was set.
+func getProbeForKind(c *container.Container, kind probeKind) probe {
+	config := kind.config(c)
 	if config == nil || len(config.Test) == 0 {
 		return nil
 	}
@@ -257,10 +699,24 @@ func getProbe(c *container.Container) probe {
 		return &cmdProbe{shell: false}
 	case "CMD-SHELL":
 		return &cmdProbe{shell: true}
+	case "HTTP":
+		p, err := newHTTPProbe(config.Test[1:])
+		if err != nil {
+			logrus.Warnf("Invalid %s in container %s: %v", kind, c.ID, err)
+			return nil
+		}
+		return p
+	case "TCP":
+		p, err := newTCPProbe(config.Test[1:])
+		if err != nil {
+			logrus.Warnf("Invalid %s in container %s: %v", kind, c.ID, err)
+			return nil
+		}
+		return p
 	case "NONE":
 		return nil
 	default:
-		logrus.Warnf("Unknown healthcheck type '%s' (expected 'CMD') in container %s", config.Test[0], c.ID)
+		logrus.Warnf("Unknown %s type '%s' (expected 'CMD', 'HTTP' or 'TCP') in container %s", kind, config.Test[0], c.ID)
 		return nil
 	}
 }
@@ -274,14 +730,25 @@ func (d *Daemon) updateHealthMonitor(c *container.Container) {
 		return // No healthcheck configured
 	}
 
-	probe := getProbe(c)
-	wantRunning := c.Running && !c.Paused && probe != nil
+	probes := make(map[probeKind]probe)
+	for _, kind := range []probeKind{livenessProbe, startupProbe, readinessProbe} {
+		if p := getProbeForKind(c, kind); p != nil {
+			probes[kind] = p
+		}
+	}
+	wantRunning := c.Running && !c.Paused && len(probes) > 0
 	if wantRunning {
 		if stop := h.OpenMonitorChannel(); stop != nil {
-			go monitor(d, c, stop, probe)
+			for kind, p := range probes {
+				if kind == startupProbe && h.StartupStatus() != types.Starting {
+					continue
+				}
+				go monitor(d, c, stop, kind, p)
+			}
 		}
 	} else {
 		h.CloseMonitorChannel()
+		updateReadiness(c)
 	}
 }
 
@@ -290,22 +757,39 @@ func (d *Daemon) updateHealthMonitor(c *container.Container) {
 // two instances at once.
 // Called with c locked.
 func (d *Daemon) initHealthMonitor(c *container.Container) {
+	liveness := getProbe(c)
+	startup := getProbeForKind(c, startupProbe)
+	readiness := getProbeForKind(c, readinessProbe)
+
 	// If no healthcheck is setup then don't init the monitor
-	if getProbe(c) == nil {
+	if liveness == nil && startup == nil && readiness == nil {
+		updateReadiness(c)
 		return
 	}
 
 	// This is needed in case we're auto-restarting
 	d.stopHealthchecks(c)
 
-	if h := c.State.Health; h != nil {
+	h := c.State.Health
+	if h == nil {
+		h = &container.Health{}
+		c.State.Health = h
+	}
+	if liveness != nil {
 		h.SetStatus(types.Starting)
-		h.FailingStreak = 0
 	} else {
-		h := &container.Health{}
-		h.SetStatus(types.Starting)
-		c.State.Health = h
+		h.SetStatus(types.NoHealthcheck)
+	}
+	h.FailingStreak = 0
+	h.Startup = nil
+	if startup != nil {
+		h.SetStartupStatus(types.Starting)
+	}
+	h.Readiness = nil
+	if readiness != nil {
+		h.SetReadinessStatus(types.NotReady)
 	}
+	updateReadiness(c)
 
 	d.updateHealthMonitor(c)
 }
@@ -316,6 +800,8 @@ func (d *Daemon) stopHealthchecks(c *container.Container) {
 	h := c.State.Health
 	if h != nil {
 		h.CloseMonitorChannel()
+		// the container is being stopped, or its probes are restarted
+		c.SetReady(false)
 	}
 }
 
@@ -0,0 +1,75 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"context"
+	"net"
+	"runtime"
+
+	"github.com/docker/docker/container"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netns"
+)
+
+// dialInContainer connects to addr from within the network namespace of the
+// container, so that probes reach services listening on the container's
+// loopback interface without the daemon needing a route to the container.
+// The host of addr must be an IP address or localhost, as names would be
+// resolved with the daemon's resolver configuration; this also applies to
+// the targets of HTTP redirects.
+func dialInContainer(ctx context.Context, c *container.Container, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if host, err = probeHost(host); err != nil {
+		return nil, err
+	}
+	addr = net.JoinHostPort(host, port)
+
+	c.Lock()
+	var nsPath string
+	if c.NetworkSettings != nil {
+		nsPath = c.NetworkSettings.SandboxKey
+	}
+	c.Unlock()
+	if nsPath == "" {
+		return nil, errors.Errorf("container %s has no network namespace", c.ID)
+	}
+
+	target, err := netns.GetFromPath(nsPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get network namespace of container %s", c.ID)
+	}
+	defer target.Close()
+
+	// The namespace is a property of the OS thread; keep this goroutine on
+	// it until the namespace has been restored. Sockets keep the namespace
+	// they were created in, so the connection can be used from any thread.
+	runtime.LockOSThread()
+	origin, err := netns.Get()
+	if err != nil {
+		runtime.UnlockOSThread()
+		return nil, errors.Wrap(err, "failed to get current network namespace")
+	}
+	defer origin.Close()
+
+	if err := netns.Set(target); err != nil {
+		runtime.UnlockOSThread()
+		return nil, errors.Wrapf(err, "failed to enter network namespace of container %s", c.ID)
+	}
+
+	// A negative FallbackDelay disables dialing the IPv4 and IPv6 addresses
+	// of a name in parallel, which would happen on other goroutines.
+	d := net.Dialer{FallbackDelay: -1}
+	conn, dialErr := d.DialContext(ctx, network, addr)
+
+	if err := netns.Set(origin); err != nil {
+		// Leave the thread locked so that it's terminated with the goroutine
+		// instead of being reused in the wrong namespace.
+		logrus.WithError(err).Error("Failed to restore network namespace after health check")
+	} else {
+		runtime.UnlockOSThread()
+	}
+	return conn, dialErr
+}
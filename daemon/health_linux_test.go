@@ -0,0 +1,84 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/daemon/network"
+)
+
+// probeContainer returns a container that shares the network namespace of
+// the test process.
+func probeContainer(test []string) *container.Container {
+	return &container.Container{
+		ID:    "container_id",
+		State: container.NewState(),
+		Config: &containertypes.Config{
+			Healthcheck: &containertypes.HealthConfig{Test: test},
+		},
+		NetworkSettings: &network.Settings{
+			SandboxKey: fmt.Sprintf("/proc/%d/ns/net", os.Getpid()),
+		},
+	}
+}
+
+func TestHTTPAndTCPProbes(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("root required")
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, "all good")
+	}))
+	defer srv.Close()
+	_, port, err := net.SplitHostPort(srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		test     []string
+		exitCode int
+	}{
+		{test: []string{"HTTP", "http://localhost:" + port + "/healthz"}, exitCode: 0},
+		{test: []string{"HTTP", "http://localhost:" + port + "/healthz", "body=good"}, exitCode: 0},
+		{test: []string{"HTTP", "http://localhost:" + port + "/healthz", "body=bad"}, exitCode: 1},
+		{test: []string{"HTTP", "http://localhost:" + port + "/missing"}, exitCode: 1},
+		{test: []string{"HTTP", "http://localhost:" + port + "/missing", "status=404"}, exitCode: 0},
+		{test: []string{"TCP", ":" + port}, exitCode: 0},
+	} {
+		c := probeContainer(tc.test)
+		p := getProbe(c)
+		if p == nil {
+			t.Fatalf("Expecting a probe for %q", tc.test)
+		}
+		result, err := p.run(context.Background(), nil, c)
+		if err != nil {
+			t.Fatalf("%q: %v", tc.test, err)
+		}
+		if result.ExitCode != tc.exitCode {
+			t.Errorf("%q: expecting exit code %d, but got %d (%s)", tc.test, tc.exitCode, result.ExitCode, result.Output)
+		}
+	}
+
+	srv.Close()
+	c := probeContainer([]string{"TCP", ":" + port})
+	result, err := getProbe(c).run(context.Background(), nil, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ExitCode != 1 {
+		t.Errorf("Expecting exit code 1 for a closed port, but got %d", result.ExitCode)
+	}
+}
@@ -152,3 +152,182 @@ func TestHealthStates(t *testing.T) {
 		t.Errorf("Expecting FailingStreak=0, but got %d\n", c.State.Health.FailingStreak)
 	}
 }
+
+func TestStartupAndReadinessProbeStates(t *testing.T) {
+	e := events.New()
+	_, l, _ := e.Subscribe()
+	defer e.Evict(l)
+
+	expect := func(expected string) {
+		select {
+		case event := <-l:
+			ev := event.(eventtypes.Message)
+			if ev.Status != expected {
+				t.Errorf("Expecting event %#v, but got %#v\n", expected, ev.Status)
+			}
+		case <-time.After(1 * time.Second):
+			t.Errorf("Expecting event %#v, but got nothing\n", expected)
+		}
+	}
+
+	c := &container.Container{
+		ID:   "container_id",
+		Name: "container_name",
+		Config: &containertypes.Config{
+			Image:          "image_name",
+			StartupProbe:   &containertypes.HealthConfig{Test: []string{"CMD", "true"}, Retries: 1},
+			ReadinessProbe: &containertypes.HealthConfig{Test: []string{"CMD", "true"}, Retries: 2},
+		},
+		State: container.NewState(),
+	}
+	c.State.Running = true
+
+	store, err := container.NewViewDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	daemon := &Daemon{
+		EventsService:     e,
+		containersReplica: store,
+	}
+
+	daemon.initHealthMonitor(c)
+	defer daemon.stopHealthchecks(c)
+	h := c.State.Health
+	if status := h.Status(); status != types.NoHealthcheck {
+		t.Errorf("Expecting none, but got %#v\n", status)
+	}
+	if h.Started() || c.State.IsReady() {
+		t.Error("Expecting the container not to be started and ready")
+	}
+
+	result := func(exitCode int) *types.HealthcheckResult {
+		now := time.Now()
+		return &types.HealthcheckResult{Start: now, End: now, ExitCode: exitCode}
+	}
+
+	handleStartupProbeResult(daemon, c, result(0), nil)
+	expect("startup_status: started")
+	if !h.Started() {
+		t.Error("Expecting the container to be started")
+	}
+	if c.State.IsReady() {
+		t.Error("Expecting the container not to be ready before the readiness probe succeeded")
+	}
+
+	handleReadinessProbeResult(daemon, c, result(0), nil)
+	expect("readiness_status: ready")
+	if !c.State.IsReady() {
+		t.Error("Expecting the container to be ready")
+	}
+
+	handleReadinessProbeResult(daemon, c, result(1), nil)
+	if !c.State.IsReady() {
+		t.Error("Expecting the container to stay ready until the retries are exhausted")
+	}
+	handleReadinessProbeResult(daemon, c, result(1), nil)
+	expect("readiness_status: not-ready")
+	if c.State.IsReady() {
+		t.Error("Expecting the container not to be ready")
+	}
+
+	// a failing startup probe makes the container unhealthy
+	daemon.initHealthMonitor(c)
+	handleStartupProbeResult(daemon, c, result(1), nil)
+	expect("startup_status: unhealthy")
+	expect("health_status: unhealthy")
+	if h.Started() {
+		t.Error("Expecting the container not to be started")
+	}
+}
+
+func TestReadinessAfterMonitorStopped(t *testing.T) {
+	c := &container.Container{
+		ID:   "container_id",
+		Name: "container_name",
+		Config: &containertypes.Config{
+			Image:          "image_name",
+			ReadinessProbe: &containertypes.HealthConfig{Test: []string{"CMD", "true"}},
+		},
+		State: container.NewState(),
+	}
+	c.State.Running = true
+
+	store, err := container.NewViewDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	daemon := &Daemon{
+		EventsService:     events.New(),
+		containersReplica: store,
+	}
+
+	daemon.initHealthMonitor(c)
+	now := time.Now()
+	handleReadinessProbeResult(daemon, c, &types.HealthcheckResult{Start: now, End: now}, nil)
+	if !c.State.IsReady() {
+		t.Fatal("Expecting the container to be ready")
+	}
+
+	// the readiness is derived from the persisted state of the probes
+	restored := &container.Container{Config: c.Config, State: container.NewState()}
+	restored.State.Running = true
+	restored.State.Health = c.State.Health
+	updateReadiness(restored)
+	if !restored.State.IsReady() {
+		t.Error("Expecting the restored container to be ready")
+	}
+
+	daemon.stopHealthchecks(c)
+	if c.State.IsReady() {
+		t.Error("Expecting the container not to be ready once its probes are stopped")
+	}
+	if status := c.State.Health.ReadinessStatus(); status != types.NotReady {
+		t.Errorf("Expecting not-ready, but got %#v", status)
+	}
+}
+
+func TestHTTPProbeConfig(t *testing.T) {
+	p, err := newHTTPProbe([]string{"http://:8080/healthz", "status=200-299", "body=ok"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.url != "http://127.0.0.1:8080/healthz" {
+		t.Errorf("Expecting url http://127.0.0.1:8080/healthz, but got %s", p.url)
+	}
+	if p.minStatus != 200 || p.maxStatus != 299 || p.body != "ok" {
+		t.Errorf("Unexpected probe configuration %+v", p)
+	}
+	if p, err = newHTTPProbe([]string{"https://[::1]/"}); err != nil || p.url != "https://[::1]/" {
+		t.Errorf("Expecting url https://[::1]/, but got %+v (%v)", p, err)
+	}
+
+	for _, args := range [][]string{
+		{},
+		{"ftp://localhost/"},
+		{"http://localhost/", "status=600"},
+		{"http://localhost/", "status=299-200"},
+		{"http://localhost/", "method=POST"},
+		{"http://example.com/"},
+	} {
+		if _, err := newHTTPProbe(args); err == nil {
+			t.Errorf("Expecting an error for %q", args)
+		}
+	}
+}
+
+func TestTCPProbeConfig(t *testing.T) {
+	p, err := newTCPProbe([]string{":5432"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.addr != "127.0.0.1:5432" {
+		t.Errorf("Expecting address 127.0.0.1:5432, but got %s", p.addr)
+	}
+
+	for _, args := range [][]string{{}, {"localhost"}, {":0"}, {":http"}, {"db:5432"}} {
+		if _, err := newTCPProbe(args); err == nil {
+			t.Errorf("Expecting an error for %q", args)
+		}
+	}
+}
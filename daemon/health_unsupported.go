@@ -0,0 +1,15 @@
+// +build !linux
+
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"context"
+	"net"
+
+	"github.com/docker/docker/container"
+	"github.com/pkg/errors"
+)
+
+func dialInContainer(ctx context.Context, c *container.Container, network, addr string) (net.Conn, error) {
+	return nil, errors.New("HTTP and TCP health checks are not supported on this platform")
+}
@@ -10,12 +10,20 @@ import (
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/distribution"
 	progressutils "github.com/docker/docker/distribution/utils"
+	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/pkg/progress"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
 )
 
 // PushImage initiates a push operation on the repository named localName.
-func (i *ImageService) PushImage(ctx context.Context, image, tag string, metaHeaders map[string][]string, authConfig *types.AuthConfig, outStream io.Writer) error {
+// format is the format of the pushed manifest, either "docker" (the default)
+// for a schema2 manifest or "oci" for an OCI image manifest.
+func (i *ImageService) PushImage(ctx context.Context, image, tag, format string, metaHeaders map[string][]string, authConfig *types.AuthConfig, outStream io.Writer) error {
 	start := time.Now()
+	if format != "" && format != "docker" && format != "oci" {
+		return errdefs.InvalidParameter(errors.Errorf("invalid manifest format %q: must be docker or oci", format))
+	}
 	ref, err := reference.ParseNormalizedNamed(image)
 	if err != nil {
 		return err
@@ -28,6 +36,55 @@ func (i *ImageService) PushImage(ctx context.Context, image, tag string, metaHea
 		}
 	}
 
+	err = i.push(ctx, ref, format, nil, metaHeaders, authConfig, outStream)
+	imageActions.WithValues("push").UpdateSince(start)
+	return err
+}
+
+// PushManifestList pushes the local images to the repository named
+// localName, and a manifest list referencing them with the tag. The images
+// must have different platforms. With the "oci" format, the images are pushed
+// as OCI image manifests referenced by an OCI image index.
+func (i *ImageService) PushManifestList(ctx context.Context, image, tag, format string, images []string, metaHeaders map[string][]string, authConfig *types.AuthConfig, outStream io.Writer) error {
+	start := time.Now()
+	if format != "" && format != "docker" && format != "oci" {
+		return errdefs.InvalidParameter(errors.Errorf("invalid manifest format %q: must be docker or oci", format))
+	}
+	if len(images) == 0 {
+		return errdefs.InvalidParameter(errors.New("no images specified for the manifest list"))
+	}
+	ref, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return errdefs.InvalidParameter(err)
+	}
+	if _, isCanonical := ref.(reference.Canonical); isCanonical {
+		return errdefs.InvalidParameter(errors.New("cannot push a manifest list by digest"))
+	}
+	if tag != "" {
+		ref, err = reference.WithTag(ref, tag)
+		if err != nil {
+			return errdefs.InvalidParameter(err)
+		}
+	}
+	ref = reference.TagNameOnly(ref)
+
+	ids := make([]digest.Digest, 0, len(images))
+	for _, name := range images {
+		img, err := i.GetImage(name)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, digest.Digest(img.ID()))
+	}
+
+	err = i.push(ctx, ref, format, ids, metaHeaders, authConfig, outStream)
+	imageActions.WithValues("push").UpdateSince(start)
+	return err
+}
+
+// push pushes ref, or the manifest list of images if images is not empty, to
+// the registry, and writes the progress to outStream.
+func (i *ImageService) push(ctx context.Context, ref reference.Named, format string, images []digest.Digest, metaHeaders map[string][]string, authConfig *types.AuthConfig, outStream io.Writer) error {
 	// Include a buffer so that slow client connections don't affect
 	// transfer performance.
 	progressChan := make(chan progress.Progress, 100)
@@ -52,14 +109,16 @@ func (i *ImageService) PushImage(ctx context.Context, image, tag string, metaHea
 			ImageStore:       distribution.NewImageConfigStoreFromStore(i.imageStore),
 			ReferenceStore:   i.referenceStore,
 		},
-		ConfigMediaType: schema2.MediaTypeImageConfig,
-		LayerStores:     distribution.NewLayerProvidersFromStores(i.layerStores),
-		UploadManager:   i.uploadManager,
+		ConfigMediaType:    schema2.MediaTypeImageConfig,
+		OCIManifest:        format == "oci",
+		LayerCompression:   i.layerCompression,
+		LayerStores:        distribution.NewLayerProvidersFromStores(i.layerStores),
+		UploadManager:      i.uploadManager,
+		ManifestListImages: images,
 	}
 
-	err = distribution.Push(ctx, ref, imagePushConfig)
+	err := distribution.Push(ctx, ref, imagePushConfig)
 	close(progressChan)
 	<-writesDone
-	imageActions.WithValues("push").UpdateSince(start)
 	return err
 }
@@ -0,0 +1,94 @@
+package images // import "github.com/docker/docker/daemon/images"
+
+import (
+	"fmt"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/distribution/metadata"
+	"github.com/docker/docker/distribution/trust"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/image"
+	"github.com/opencontainers/go-digest"
+)
+
+// CheckTrustPolicy checks that img, referred to by refOrID, can be run under
+// the trust policy of the daemon. If refOrID is a reference to img, the rule
+// of its repository applies; otherwise the image is accepted if the rule of
+// one of its repositories accepts it. A rule requiring signatures accepts the
+// image if it was pulled from the repository with a signature valid for the
+// rule. The signatures are verified again, so that the changes of the keys
+// of the policy apply to the images already pulled. An errdefs.Forbidden
+// error is returned if the image is not accepted.
+func (i *ImageService) CheckTrustPolicy(refOrID string, img *image.Image) error {
+	if i.trustPolicy == nil {
+		return nil
+	}
+
+	id := img.ID().Digest()
+	var names []reference.Named
+	if ref, err := reference.ParseNormalizedNamed(refOrID); err == nil {
+		if refID, err := i.referenceStore.Get(reference.TagNameOnly(ref)); err == nil && refID == id {
+			names = append(names, reference.TrimNamed(ref))
+		}
+	}
+	if len(names) == 0 {
+		seen := make(map[string]struct{})
+		for _, ref := range i.referenceStore.References(id) {
+			if _, ok := seen[ref.Name()]; ok {
+				continue
+			}
+			seen[ref.Name()] = struct{}{}
+			names = append(names, reference.TrimNamed(ref))
+		}
+	}
+	if len(names) == 0 {
+		if i.trustPolicy.Default.Type == trust.RuleAccept {
+			return nil
+		}
+		return errdefs.Forbidden(fmt.Errorf("image %s has no repository and is rejected by the trust policy", refOrID))
+	}
+
+	signatures, err := metadata.NewSignatureService(i.distributionMetadataStore).Get(id)
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for _, name := range names {
+		if lastErr = checkTrustRule(i.trustPolicy.Rule(name), name, signatures); lastErr == nil {
+			return nil
+		}
+	}
+	return lastErr
+}
+
+// checkTrustRule checks the signatures of an image pulled from the
+// repository name against the rule of the repository.
+func checkTrustRule(rule *trust.Rule, name reference.Named, signatures []metadata.ImageSignature) error {
+	if !rule.RequiresSignature() {
+		return rule.Check(name)
+	}
+
+	var digests []digest.Digest
+	byDigest := make(map[digest.Digest][]trust.Signature)
+	for _, s := range signatures {
+		if s.Repository != name.Name() {
+			continue
+		}
+		if _, ok := byDigest[s.Digest]; !ok {
+			digests = append(digests, s.Digest)
+		}
+		byDigest[s.Digest] = append(byDigest[s.Digest], trust.Signature{Payload: s.Payload, Signature: s.Signature})
+	}
+	if len(digests) == 0 {
+		return errdefs.Forbidden(fmt.Errorf("image %s was not pulled with a signature, which is required by the trust policy", reference.FamiliarName(name)))
+	}
+
+	var lastErr error
+	for _, dgst := range digests {
+		if _, lastErr = rule.Verify(name, dgst, byDigest[dgst]); lastErr == nil {
+			return nil
+		}
+	}
+	return lastErr
+}
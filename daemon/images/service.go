@@ -9,9 +9,11 @@ import (
 	daemonevents "github.com/docker/docker/daemon/events"
 	"github.com/docker/docker/distribution"
 	"github.com/docker/docker/distribution/metadata"
+	"github.com/docker/docker/distribution/trust"
 	"github.com/docker/docker/distribution/xfer"
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/layer"
+	"github.com/docker/docker/pkg/archive"
 	dockerreference "github.com/docker/docker/reference"
 	"github.com/docker/docker/registry"
 	"github.com/opencontainers/go-digest"
@@ -34,11 +36,13 @@ type ImageServiceConfig struct {
 	DistributionMetadataStore metadata.Store
 	EventsService             *daemonevents.Events
 	ImageStore                image.Store
+	LayerCompression          archive.Compression
 	LayerStores               map[string]layer.Store
 	MaxConcurrentDownloads    int
 	MaxConcurrentUploads      int
 	ReferenceStore            dockerreference.Store
 	RegistryService           registry.Service
+	TrustPolicy               *trust.Policy
 }
 
 // NewImageService returns a new ImageService from a configuration
@@ -51,9 +55,11 @@ func NewImageService(config ImageServiceConfig) *ImageService {
 		downloadManager:           xfer.NewLayerDownloadManager(config.LayerStores, config.MaxConcurrentDownloads),
 		eventsService:             config.EventsService,
 		imageStore:                config.ImageStore,
+		layerCompression:          config.LayerCompression,
 		layerStores:               config.LayerStores,
 		referenceStore:            config.ReferenceStore,
 		registryService:           config.RegistryService,
+		trustPolicy:               config.TrustPolicy,
 		uploadManager:             xfer.NewLayerUploadManager(config.MaxConcurrentUploads),
 	}
 }
@@ -65,10 +71,12 @@ type ImageService struct {
 	downloadManager           *xfer.LayerDownloadManager
 	eventsService             *daemonevents.Events
 	imageStore                image.Store
+	layerCompression          archive.Compression
 	layerStores               map[string]layer.Store // By operating system
 	pruneRunning              int32
 	referenceStore            dockerreference.Store
 	registryService           registry.Service
+	trustPolicy               *trust.Policy
 	uploadManager             *xfer.LayerUploadManager
 }
 
@@ -11,6 +11,7 @@ import (
 	"github.com/docker/docker/api/types/versions"
 	"github.com/docker/docker/api/types/versions/v1p20"
 	"github.com/docker/docker/container"
+	"github.com/docker/docker/daemon/logger"
 	"github.com/docker/docker/daemon/network"
 	"github.com/docker/docker/errdefs"
 	"github.com/docker/go-connections/nat"
@@ -144,6 +145,20 @@ func (daemon *Daemon) getInspectData(container *container.Container) (*types.Con
 			FailingStreak: container.State.Health.FailingStreak,
 			Log:           append([]*types.HealthcheckResult{}, container.State.Health.Log...),
 		}
+		if s := container.State.Health.Startup; s != nil {
+			containerHealth.Startup = &types.ProbeStatus{
+				Status:        container.State.Health.StartupStatus(),
+				FailingStreak: s.FailingStreak,
+				Log:           append([]*types.HealthcheckResult{}, s.Log...),
+			}
+		}
+		if s := container.State.Health.Readiness; s != nil {
+			containerHealth.Readiness = &types.ProbeStatus{
+				Status:        container.State.Health.ReadinessStatus(),
+				FailingStreak: s.FailingStreak,
+				Log:           append([]*types.HealthcheckResult{}, s.Log...),
+			}
+		}
 	}
 
 	containerState := &types.ContainerState{
@@ -160,6 +175,17 @@ func (daemon *Daemon) getInspectData(container *container.Container) (*types.Con
 		FinishedAt: container.State.FinishedAt.Format(time.RFC3339Nano),
 		Health:     containerHealth,
 	}
+	if container.State.Restarting && !container.State.NextRestartAt.IsZero() {
+		containerState.NextRestartAt = container.State.NextRestartAt.Format(time.RFC3339Nano)
+	}
+	containerState.OOMKillCount = container.State.OOMKillCount
+	if k := container.State.LastOOMKill; k != nil {
+		containerState.LastOOMKill = &types.OOMKill{
+			Time:    k.Time.Format(time.RFC3339Nano),
+			Pid:     k.Pid,
+			Process: k.Process,
+		}
+	}
 
 	contJSONBase := &types.ContainerJSONBase{
 		ID:           container.ID,
@@ -179,6 +205,29 @@ func (daemon *Daemon) getInspectData(container *container.Container) (*types.Con
 		HostConfig:   &hostConfig,
 	}
 
+	if r, ok := container.LogDriver.(logger.DropStatsReporter); ok {
+		stats := r.DropStats()
+		contJSONBase.LogStats = &types.LogStats{
+			RateLimited: stats.RateLimited,
+			SampledOut:  stats.SampledOut,
+		}
+	}
+	if r, ok := container.LogDriver.(logger.BufferStatsReporter); ok {
+		if stats := r.BufferStats(); stats.MaxSize > 0 {
+			if contJSONBase.LogStats == nil {
+				contJSONBase.LogStats = &types.LogStats{}
+			}
+			contJSONBase.LogStats.Buffer = &types.LogBufferStats{
+				Enqueued:     stats.Enqueued,
+				Dropped:      stats.Dropped,
+				DroppedBytes: stats.DroppedBytes,
+				Messages:     stats.Messages,
+				Size:         stats.Size,
+				MaxSize:      stats.MaxSize,
+			}
+		}
+	}
+
 	// Now set any platform-specific fields
 	contJSONBase = setPlatformSpecificContainerFields(container, contJSONBase)
 
@@ -30,6 +30,7 @@ type Copier struct {
 	copyJobs  sync.WaitGroup
 	closeOnce sync.Once
 	closed    chan struct{}
+	multiline *MultilineConfig
 }
 
 // NewCopier creates a new Copier
@@ -41,6 +42,12 @@ func NewCopier(srcs map[string]io.Reader, dst Logger) *Copier {
 	}
 }
 
+// SetMultiline enables the aggregation of lines into multi-line messages.
+// It must be called before Run.
+func (c *Copier) SetMultiline(cfg *MultilineConfig) {
+	c.multiline = cfg
+}
+
 // Run starts logs copying
 func (c *Copier) Run() {
 	for src, w := range c.srcs {
@@ -58,6 +65,13 @@ func (c *Copier) copySrc(name string, src io.Reader) {
 	}
 	buf := make([]byte, bufSize)
 
+	logMsg := func(msg *Message) { logMessage(c.dst, msg) }
+	if c.multiline != nil {
+		agg := newMultilineAggregator(c.multiline, c.dst, bufSize)
+		defer agg.Close()
+		logMsg = agg.Log
+	}
+
 	n := 0
 	eof := false
 	var partialid string
@@ -120,10 +134,7 @@ func (c *Copier) copySrc(name string, src io.Reader) {
 						msg.Timestamp = partialTS
 					}
 
-					if logErr := c.dst.Log(msg); logErr != nil {
-						logWritesFailedCount.Inc(1)
-						logrus.Errorf("Failed to log msg %q for logger %s: %s", msg.Line, c.dst.Name(), logErr)
-					}
+					logMsg(msg)
 				}
 				p += q + 1
 			}
@@ -153,10 +164,7 @@ func (c *Copier) copySrc(name string, src io.Reader) {
 					ordinal++
 					hasMorePartial = true
 
-					if logErr := c.dst.Log(msg); logErr != nil {
-						logWritesFailedCount.Inc(1)
-						logrus.Errorf("Failed to log msg %q for logger %s: %s", msg.Line, c.dst.Name(), logErr)
-					}
+					logMsg(msg)
 					p = 0
 					n = 0
 				}
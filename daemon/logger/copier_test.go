@@ -393,6 +393,149 @@ func TestCopierWithPartial(t *testing.T) {
 	}
 }
 
+// copyMultiline copies the given output with multi-line aggregation enabled
+// and returns the logged lines.
+func copyMultiline(t *testing.T, cfg map[string]string, src io.Reader) []string {
+	ml, err := ParseMultilineConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var jsonBuf bytes.Buffer
+	jsonLog := &TestLoggerJSON{Encoder: json.NewEncoder(&jsonBuf)}
+
+	c := NewCopier(map[string]io.Reader{"stdout": src}, jsonLog)
+	c.SetMultiline(ml)
+	c.Run()
+	wait := make(chan struct{})
+	go func() {
+		c.Wait()
+		close(wait)
+	}()
+	select {
+	case <-time.After(5 * time.Second):
+		t.Fatal("Copier failed to do its work in 5 seconds")
+	case <-wait:
+	}
+
+	var lines []string
+	dec := json.NewDecoder(&jsonBuf)
+	for {
+		var msg Message
+		if err := dec.Decode(&msg); err != nil {
+			if err == io.EOF {
+				break
+			}
+			t.Fatal(err)
+		}
+		lines = append(lines, string(msg.Line))
+	}
+	return lines
+}
+
+func TestCopierMultiline(t *testing.T) {
+	trace := "Exception in thread \"main\" java.lang.NullPointerException\n" +
+		"\tat Main.run(Main.java:10)\n" +
+		"\tat Main.main(Main.java:5)\n"
+	expected := strings.TrimSuffix(trace, "\n")
+
+	for _, tc := range []struct {
+		name string
+		cfg  map[string]string
+	}{
+		{"pattern", map[string]string{"multiline-mode": "pattern", "multiline-pattern": "^(Exception|INFO)"}},
+		{"indent", map[string]string{"multiline-mode": "indent"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			src := strings.NewReader("INFO starting\n" + trace + "INFO done\n")
+			lines := copyMultiline(t, tc.cfg, src)
+			if len(lines) != 3 {
+				t.Fatalf("Expected 3 messages, got %d: %q", len(lines), lines)
+			}
+			if lines[1] != expected {
+				t.Fatalf("Wrong Line: %q, expected %q", lines[1], expected)
+			}
+			if lines[2] != "INFO done" {
+				t.Fatalf("Wrong Line: %q, expected %q", lines[2], "INFO done")
+			}
+		})
+	}
+}
+
+func TestCopierMultilineMaxSize(t *testing.T) {
+	src := strings.NewReader("start\n  aaaa\n  bbbb\n  cccc\n")
+	lines := copyMultiline(t, map[string]string{"multiline-mode": "indent", "multiline-max-size": "16"}, src)
+	expected := []string{"start\n  aaaa", "  bbbb\n  cccc"}
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected %q, got %q", expected, lines)
+	}
+	for i := range expected {
+		if lines[i] != expected[i] {
+			t.Fatalf("Wrong Line: %q, expected %q", lines[i], expected[i])
+		}
+	}
+}
+
+func TestCopierMultilineTimeout(t *testing.T) {
+	r, w := io.Pipe()
+	var jsonBuf bytes.Buffer
+	jsonLog := &TestLoggerJSON{Encoder: json.NewEncoder(&jsonBuf)}
+
+	c := NewCopier(map[string]io.Reader{"stdout": r}, jsonLog)
+	c.SetMultiline(&MultilineConfig{Mode: MultilineModeIndent, Timeout: 10 * time.Millisecond})
+	c.Run()
+	defer c.Close()
+
+	if _, err := w.Write([]byte("first\n  continued\n")); err != nil {
+		t.Fatal(err)
+	}
+	// the message is logged after the timeout, even if the stream is still open
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		jsonLog.mu.Lock()
+		n := jsonBuf.Len()
+		jsonLog.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("multi-line message was not flushed after the timeout")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	w.Close()
+	c.Wait()
+
+	var msg Message
+	if err := json.NewDecoder(&jsonBuf).Decode(&msg); err != nil {
+		t.Fatal(err)
+	}
+	if string(msg.Line) != "first\n  continued" {
+		t.Fatalf("Wrong Line: %q, expected %q", msg.Line, "first\n  continued")
+	}
+}
+
+func TestParseMultilineConfig(t *testing.T) {
+	ml, err := ParseMultilineConfig(map[string]string{"max-file": "3"})
+	if err != nil || ml != nil {
+		t.Fatalf("Expected no multi-line config, got %v, %v", ml, err)
+	}
+
+	for _, cfg := range []map[string]string{
+		{"multiline-pattern": "^\\S"},
+		{"multiline-mode": "json"},
+		{"multiline-mode": "pattern"},
+		{"multiline-mode": "pattern", "multiline-pattern": "("},
+		{"multiline-mode": "indent", "multiline-pattern": "^\\S"},
+		{"multiline-mode": "indent", "multiline-timeout": "-1s"},
+		{"multiline-mode": "indent", "multiline-max-size": "lots"},
+	} {
+		if _, err := ParseMultilineConfig(cfg); err == nil {
+			t.Fatalf("Expected an error for %v", cfg)
+		}
+	}
+}
+
 type BenchmarkLoggerDummy struct {
 }
 
@@ -116,9 +116,41 @@ func GetLogDriver(name string) (Creator, error) {
 	return factory.get(name)
 }
 
+// AddBuiltinLogOpts updates the list of built-in log opts. This allows other
+// packages to supplement additional log options without having to register
+// a logging driver.
+func AddBuiltinLogOpts(opts map[string]bool) {
+	factory.m.Lock()
+	for k, v := range opts {
+		builtInLogOpts[k] = v
+	}
+	factory.m.Unlock()
+}
+
+// RegisterExternalValidator adds the validator to the list of validators
+// run for all logging drivers. It is used to validate the built-in log opts
+// added with AddBuiltinLogOpts.
+func RegisterExternalValidator(v LogOptValidator) {
+	factory.m.Lock()
+	externalValidators = append(externalValidators, v)
+	factory.m.Unlock()
+}
+
+var externalValidators []LogOptValidator
+
 var builtInLogOpts = map[string]bool{
-	"mode":            true,
-	"max-buffer-size": true,
+	"mode":              true,
+	"max-buffer-size":   true,
+	multilineModeKey:    true,
+	multilinePatternKey: true,
+	multilineTimeoutKey: true,
+	multilineMaxSizeKey: true,
+
+	rateLimitLinesKey:      true,
+	rateLimitLinesBurstKey: true,
+	rateLimitBytesKey:      true,
+	rateLimitBytesBurstKey: true,
+	sampleRatioKey:         true,
 }
 
 // ValidateLogOpts checks the options for the given log driver. The
@@ -143,16 +175,34 @@ func ValidateLogOpts(name string, cfg map[string]string) error {
 		}
 	}
 
+	if _, err := ParseMultilineConfig(cfg); err != nil {
+		return err
+	}
+	if _, err := ParseLimitConfig(cfg); err != nil {
+		return err
+	}
+
+	factory.m.Lock()
+	validators := externalValidators
+	factory.m.Unlock()
+	for _, validate := range validators {
+		if err := validate(cfg); err != nil {
+			return err
+		}
+	}
+
 	if !factory.driverRegistered(name) {
 		return fmt.Errorf("logger: no log driver named '%s' is registered", name)
 	}
 
-	filteredOpts := make(map[string]string, len(builtInLogOpts))
+	filteredOpts := make(map[string]string, len(cfg))
+	factory.m.Lock()
 	for k, v := range cfg {
 		if !builtInLogOpts[k] {
 			filteredOpts[k] = v
 		}
 	}
+	factory.m.Unlock()
 
 	validator := factory.getLogOptValidator(name)
 	if validator != nil {
@@ -0,0 +1,142 @@
+package logger // import "github.com/docker/docker/daemon/logger"
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/errdefs"
+	"github.com/pkg/errors"
+)
+
+var acceptedLogFilters = map[string]bool{
+	"match":   true,
+	"regexp":  true,
+	"stream":  true,
+	"attr":    true,
+	"partial": true,
+}
+
+// Filter selects the log messages returned by ReadLogs. A message matches
+// the filter if it matches at least one of the values of each of the
+// filter's keys:
+//
+//   - match: the message contains the given substring
+//   - regexp: the message matches the given regular expression
+//   - stream: the message was written to the given stream (stdout or stderr)
+//   - attr: the message has the given attribute (key or key=value)
+//   - partial: the message is (true) or isn't (false) part of a message that
+//     was split because it was too long
+type Filter struct {
+	match   [][]byte
+	regexps []*regexp.Regexp
+	streams map[string]bool
+	attrs   []string
+	partial map[bool]bool
+}
+
+// NewFilter creates a log filter from filter arguments. It returns nil if
+// there are no arguments.
+func NewFilter(args filters.Args) (*Filter, error) {
+	if args.Len() == 0 {
+		return nil, nil
+	}
+	if err := args.Validate(acceptedLogFilters); err != nil {
+		return nil, err
+	}
+
+	f := &Filter{}
+	for _, m := range args.Get("match") {
+		f.match = append(f.match, []byte(m))
+	}
+	for _, r := range args.Get("regexp") {
+		re, err := regexp.Compile(r)
+		if err != nil {
+			return nil, errdefs.InvalidParameter(errors.Wrapf(err, "invalid regexp filter %q", r))
+		}
+		f.regexps = append(f.regexps, re)
+	}
+	for _, s := range args.Get("stream") {
+		if s != "stdout" && s != "stderr" {
+			return nil, errdefs.InvalidParameter(errors.Errorf("invalid stream filter %q: must be stdout or stderr", s))
+		}
+		if f.streams == nil {
+			f.streams = make(map[string]bool)
+		}
+		f.streams[s] = true
+	}
+	f.attrs = args.Get("attr")
+	for _, p := range args.Get("partial") {
+		if f.partial == nil {
+			f.partial = make(map[bool]bool)
+		}
+		switch p {
+		case "true", "1":
+			f.partial[true] = true
+		case "false", "0":
+			f.partial[false] = true
+		default:
+			return nil, errdefs.InvalidParameter(errors.Errorf("invalid partial filter %q: must be true or false", p))
+		}
+	}
+	return f, nil
+}
+
+// Match returns whether a log message matches the filter. A nil filter
+// matches all messages.
+func (f *Filter) Match(msg *Message) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.match) > 0 && !f.matchAny(msg.Line) {
+		return false
+	}
+	if len(f.regexps) > 0 && !f.matchRegexp(msg.Line) {
+		return false
+	}
+	if f.streams != nil && !f.streams[msg.Source] {
+		return false
+	}
+	if len(f.attrs) > 0 && !f.matchAttrs(msg) {
+		return false
+	}
+	if f.partial != nil && !f.partial[msg.PLogMetaData != nil] {
+		return false
+	}
+	return true
+}
+
+func (f *Filter) matchAny(line []byte) bool {
+	for _, m := range f.match {
+		if bytes.Contains(line, m) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Filter) matchRegexp(line []byte) bool {
+	for _, re := range f.regexps {
+		if re.Match(line) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Filter) matchAttrs(msg *Message) bool {
+	for _, attr := range f.attrs {
+		key, value := attr, ""
+		hasValue := false
+		if i := strings.Index(attr, "="); i >= 0 {
+			key, value, hasValue = attr[:i], attr[i+1:], true
+		}
+		for _, a := range msg.Attrs {
+			if a.Key == key && (!hasValue || a.Value == value) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package logger // import "github.com/docker/docker/daemon/logger"
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/backend"
+	"github.com/docker/docker/api/types/filters"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestFilterMatch(t *testing.T) {
+	msg := &Message{
+		Line:   []byte("GET /index.html 200"),
+		Source: "stdout",
+		Attrs:  []backend.LogAttr{{Key: "tag", Value: "web"}},
+	}
+
+	for _, tc := range []struct {
+		args     filters.Args
+		expected bool
+	}{
+		{filters.NewArgs(), true},
+		{filters.NewArgs(filters.Arg("match", "index")), true},
+		{filters.NewArgs(filters.Arg("match", "POST")), false},
+		{filters.NewArgs(filters.Arg("match", "POST"), filters.Arg("match", "GET")), true},
+		{filters.NewArgs(filters.Arg("regexp", " [0-9]{3}$")), true},
+		{filters.NewArgs(filters.Arg("regexp", "^POST")), false},
+		{filters.NewArgs(filters.Arg("stream", "stdout")), true},
+		{filters.NewArgs(filters.Arg("stream", "stderr")), false},
+		{filters.NewArgs(filters.Arg("attr", "tag")), true},
+		{filters.NewArgs(filters.Arg("attr", "tag=web")), true},
+		{filters.NewArgs(filters.Arg("attr", "tag=db")), false},
+		{filters.NewArgs(filters.Arg("partial", "false")), true},
+		{filters.NewArgs(filters.Arg("partial", "true")), false},
+		{filters.NewArgs(filters.Arg("match", "GET"), filters.Arg("stream", "stderr")), false},
+	} {
+		f, err := NewFilter(tc.args)
+		assert.NilError(t, err)
+		assert.Check(t, is.Equal(f.Match(msg), tc.expected), "%v", tc.args)
+	}
+}
+
+func TestNewFilterInvalid(t *testing.T) {
+	for _, args := range []filters.Args{
+		filters.NewArgs(filters.Arg("unknown", "foo")),
+		filters.NewArgs(filters.Arg("regexp", "[")),
+		filters.NewArgs(filters.Arg("stream", "stdin")),
+		filters.NewArgs(filters.Arg("partial", "maybe")),
+	} {
+		_, err := NewFilter(args)
+		assert.Check(t, err != nil, "%v", args)
+	}
+}
@@ -180,6 +180,11 @@ func (l *JSONFileLogger) Close() error {
 	return err
 }
 
+// LogFiles returns the path of the log file.
+func (l *JSONFileLogger) LogFiles() []string {
+	return []string{l.writer.Path()}
+}
+
 // Name returns name of this logger.
 func (l *JSONFileLogger) Name() string {
 	return Name
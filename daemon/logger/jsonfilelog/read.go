@@ -24,6 +24,12 @@ func (l *JSONFileLogger) ReadLogs(config logger.ReadConfig) *logger.LogWatcher {
 	return logWatcher
 }
 
+// FiltersLogs implements the logger's FilteringLogReader interface, the
+// filter is applied before the tail.
+func (l *JSONFileLogger) FiltersLogs() bool {
+	return true
+}
+
 func (l *JSONFileLogger) readLogs(watcher *logger.LogWatcher, config logger.ReadConfig) {
 	defer close(watcher.Msg)
 
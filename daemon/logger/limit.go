@@ -0,0 +1,253 @@
+package logger // import "github.com/docker/docker/daemon/logger"
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+)
+
+const (
+	rateLimitLinesKey      = "rate-limit-lines"
+	rateLimitLinesBurstKey = "rate-limit-lines-burst"
+	rateLimitBytesKey      = "rate-limit-bytes"
+	rateLimitBytesBurstKey = "rate-limit-bytes-burst"
+	sampleRatioKey         = "sample-ratio"
+)
+
+// LimitConfig configures the rate limits and the sampling of the messages
+// of a container.
+type LimitConfig struct {
+	// LinesPerSecond is the maximum average number of messages logged per
+	// second, 0 meaning unlimited. Up to LinesBurst messages are logged at
+	// once.
+	LinesPerSecond float64
+	LinesBurst     int
+	// BytesPerSecond is the maximum average number of bytes logged per
+	// second, 0 meaning unlimited. Up to BytesBurst bytes are logged at once.
+	BytesPerSecond float64
+	BytesBurst     int
+	// SampleRatio is the ratio of the messages which are logged, between 0
+	// (excluded) and 1.
+	SampleRatio float64
+}
+
+// DropStats holds the number of messages dropped by a logger since it was
+// started.
+type DropStats struct {
+	RateLimited uint64 // dropped because of the rate limits
+	SampledOut  uint64 // dropped by sampling
+}
+
+// DropStatsReporter is implemented by the loggers which drop messages.
+type DropStatsReporter interface {
+	DropStats() DropStats
+}
+
+// ParseLimitConfig parses the rate limit and sampling log options. It
+// returns nil if messages are neither rate limited nor sampled.
+func ParseLimitConfig(cfg map[string]string) (*LimitConfig, error) {
+	lc := &LimitConfig{SampleRatio: 1}
+	enabled := false
+
+	if s, ok := cfg[rateLimitLinesKey]; ok {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil || v <= 0 {
+			return nil, errors.Errorf("logger: invalid value for %s: %q, must be a positive number", rateLimitLinesKey, s)
+		}
+		lc.LinesPerSecond = v
+		lc.LinesBurst = int(v)
+		enabled = true
+	}
+	if s, ok := cfg[rateLimitLinesBurstKey]; ok {
+		if lc.LinesPerSecond == 0 {
+			return nil, errors.Errorf("logger: %s option is only supported with %s", rateLimitLinesBurstKey, rateLimitLinesKey)
+		}
+		v, err := strconv.Atoi(s)
+		if err != nil || v <= 0 {
+			return nil, errors.Errorf("logger: invalid value for %s: %q, must be a positive integer", rateLimitLinesBurstKey, s)
+		}
+		lc.LinesBurst = v
+	}
+	if lc.LinesPerSecond > 0 && lc.LinesBurst < 1 {
+		lc.LinesBurst = 1
+	}
+
+	if s, ok := cfg[rateLimitBytesKey]; ok {
+		v, err := units.RAMInBytes(s)
+		if err != nil || v <= 0 {
+			return nil, errors.Errorf("logger: invalid value for %s: %q, must be a positive size", rateLimitBytesKey, s)
+		}
+		lc.BytesPerSecond = float64(v)
+		lc.BytesBurst = int(v)
+		enabled = true
+	}
+	if s, ok := cfg[rateLimitBytesBurstKey]; ok {
+		if lc.BytesPerSecond == 0 {
+			return nil, errors.Errorf("logger: %s option is only supported with %s", rateLimitBytesBurstKey, rateLimitBytesKey)
+		}
+		v, err := units.RAMInBytes(s)
+		if err != nil || v <= 0 {
+			return nil, errors.Errorf("logger: invalid value for %s: %q, must be a positive size", rateLimitBytesBurstKey, s)
+		}
+		lc.BytesBurst = int(v)
+	}
+
+	if s, ok := cfg[sampleRatioKey]; ok {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil || v <= 0 || v > 1 {
+			return nil, errors.Errorf("logger: invalid value for %s: %q, must be greater than 0 and at most 1", sampleRatioKey, s)
+		}
+		lc.SampleRatio = v
+		enabled = enabled || v < 1
+	}
+
+	if !enabled {
+		return nil, nil
+	}
+	return lc, nil
+}
+
+// LimitedLogger drops the messages exceeding the rate limits, or not
+// selected by sampling, before they reach the underlying logger.
+type LimitedLogger struct {
+	l     Logger
+	lines *rate.Limiter
+	bytes *rate.Limiter
+
+	mu          sync.Mutex
+	sampleRatio float64
+	sampled     float64 // sum of the ratios of the messages since the last logged one
+
+	rateLimited uint64
+	sampledOut  uint64
+}
+
+type limitedLoggerWithReader struct {
+	*LimitedLogger
+}
+
+// NewLimitedLogger wraps the logger to enforce the given rate limits and
+// sampling.
+func NewLimitedLogger(l Logger, cfg *LimitConfig) Logger {
+	ll := &LimitedLogger{l: l, sampleRatio: cfg.SampleRatio}
+	if cfg.LinesPerSecond > 0 {
+		ll.lines = rate.NewLimiter(rate.Limit(cfg.LinesPerSecond), cfg.LinesBurst)
+	}
+	if cfg.BytesPerSecond > 0 {
+		ll.bytes = rate.NewLimiter(rate.Limit(cfg.BytesPerSecond), cfg.BytesBurst)
+	}
+	if _, ok := l.(LogReader); ok {
+		return &limitedLoggerWithReader{ll}
+	}
+	return ll
+}
+
+// Log sends the message to the underlying logger, unless it is dropped.
+func (l *LimitedLogger) Log(msg *Message) error {
+	if !l.sample() {
+		atomic.AddUint64(&l.sampledOut, 1)
+		totalSampledOutLogs.Inc(1)
+		PutMessage(msg)
+		return nil
+	}
+
+	now := time.Now()
+	if l.lines != nil && !l.lines.AllowN(now, 1) {
+		l.drop(msg)
+		return nil
+	}
+	if l.bytes != nil {
+		// messages larger than the burst consume it all, rather than
+		// being always dropped
+		n := len(msg.Line)
+		if burst := l.bytes.Burst(); n > burst {
+			n = burst
+		}
+		if !l.bytes.AllowN(now, n) {
+			l.drop(msg)
+			return nil
+		}
+	}
+	return l.l.Log(msg)
+}
+
+// sample returns whether the next message is selected by sampling. Exactly
+// one message out of 1/SampleRatio is selected.
+func (l *LimitedLogger) sample() bool {
+	if l.sampleRatio >= 1 {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sampled += l.sampleRatio
+	if l.sampled < 1 {
+		return false
+	}
+	l.sampled--
+	return true
+}
+
+func (l *LimitedLogger) drop(msg *Message) {
+	atomic.AddUint64(&l.rateLimited, 1)
+	totalRateLimitedLogs.Inc(1)
+	PutMessage(msg)
+}
+
+// DropStats returns the number of messages dropped by the logger, including
+// the ones dropped by the underlying logger.
+func (l *LimitedLogger) DropStats() DropStats {
+	var stats DropStats
+	if r, ok := l.l.(DropStatsReporter); ok {
+		stats = r.DropStats()
+	}
+	stats.RateLimited += atomic.LoadUint64(&l.rateLimited)
+	stats.SampledOut += atomic.LoadUint64(&l.sampledOut)
+	return stats
+}
+
+// BufferStats returns the state of the buffers of the underlying logger.
+func (l *LimitedLogger) BufferStats() BufferStats {
+	if r, ok := l.l.(BufferStatsReporter); ok {
+		return r.BufferStats()
+	}
+	return BufferStats{}
+}
+
+// LogFiles returns the log files of the underlying logger.
+func (l *LimitedLogger) LogFiles() []string {
+	if lr, ok := l.l.(LogFilesReporter); ok {
+		return lr.LogFiles()
+	}
+	return nil
+}
+
+// Name returns the name of the underlying logger.
+func (l *LimitedLogger) Name() string {
+	return l.l.Name()
+}
+
+// BufSize returns the buffer size of the underlying logger.
+func (l *LimitedLogger) BufSize() int {
+	if sl, ok := l.l.(SizedLogger); ok {
+		return sl.BufSize()
+	}
+	return defaultBufSize
+}
+
+// Close closes the underlying logger.
+func (l *LimitedLogger) Close() error {
+	return l.l.Close()
+}
+
+func (l *limitedLoggerWithReader) ReadLogs(cfg ReadConfig) *LogWatcher {
+	return l.l.(LogReader).ReadLogs(cfg)
+}
+
+func (l *limitedLoggerWithReader) FiltersLogs() bool {
+	return FiltersLogs(l.l.(LogReader))
+}
@@ -0,0 +1,89 @@
+package logger // import "github.com/docker/docker/daemon/logger"
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func logN(t *testing.T, l Logger, n int, line string) {
+	for i := 0; i < n; i++ {
+		msg := NewMessage()
+		msg.Line = append(msg.Line, line...)
+		assert.NilError(t, l.Log(msg))
+	}
+}
+
+func TestLimitedLoggerLines(t *testing.T) {
+	cfg, err := ParseLimitConfig(map[string]string{"rate-limit-lines": "0.001", "rate-limit-lines-burst": "10"})
+	assert.NilError(t, err)
+
+	dst := &recordingLogger{}
+	l := NewLimitedLogger(dst, cfg)
+	logN(t, l, 25, "hello")
+
+	assert.Check(t, is.Len(dst.lines, 10))
+	assert.Check(t, is.DeepEqual(l.(DropStatsReporter).DropStats(), DropStats{RateLimited: 15}))
+}
+
+func TestLimitedLoggerBytes(t *testing.T) {
+	cfg, err := ParseLimitConfig(map[string]string{"rate-limit-bytes": "1", "rate-limit-bytes-burst": "1k"})
+	assert.NilError(t, err)
+
+	dst := &recordingLogger{}
+	l := NewLimitedLogger(dst, cfg)
+	// a message larger than the burst consumes it all
+	logN(t, l, 1, strings.Repeat("a", 2048))
+	logN(t, l, 1, "b")
+	assert.Check(t, is.Len(dst.lines, 1))
+
+	l = NewLimitedLogger(dst, cfg)
+	logN(t, l, 4, strings.Repeat("a", 300))
+	assert.Check(t, is.Len(dst.lines, 4))
+	assert.Check(t, is.Equal(l.(DropStatsReporter).DropStats().RateLimited, uint64(1)))
+}
+
+func TestLimitedLoggerSampling(t *testing.T) {
+	cfg, err := ParseLimitConfig(map[string]string{"sample-ratio": "0.25"})
+	assert.NilError(t, err)
+
+	dst := &readingLogger{}
+	l := NewLimitedLogger(dst, cfg)
+	_, ok := l.(LogReader)
+	assert.Check(t, ok)
+
+	logN(t, l, 100, "hello")
+	assert.Check(t, is.Len(dst.lines, 25))
+	assert.Check(t, is.DeepEqual(l.(DropStatsReporter).DropStats(), DropStats{SampledOut: 75}))
+}
+
+func TestParseLimitConfig(t *testing.T) {
+	cfg, err := ParseLimitConfig(map[string]string{"max-size": "10m", "sample-ratio": "1"})
+	assert.NilError(t, err)
+	assert.Check(t, cfg == nil)
+
+	cfg, err = ParseLimitConfig(map[string]string{"rate-limit-lines": "100", "rate-limit-bytes": "1m"})
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(cfg, &LimitConfig{
+		LinesPerSecond: 100,
+		LinesBurst:     100,
+		BytesPerSecond: 1024 * 1024,
+		BytesBurst:     1024 * 1024,
+		SampleRatio:    1,
+	}))
+
+	for _, opts := range []map[string]string{
+		{"rate-limit-lines": "-1"},
+		{"rate-limit-lines-burst": "10"},
+		{"rate-limit-lines": "10", "rate-limit-lines-burst": "lots"},
+		{"rate-limit-bytes": "fast"},
+		{"rate-limit-bytes-burst": "1m"},
+		{"sample-ratio": "0"},
+		{"sample-ratio": "2"},
+	} {
+		_, err := ParseLimitConfig(opts)
+		assert.Check(t, err != nil, "%v", opts)
+	}
+}
@@ -6,4 +6,8 @@
 // backwards (such as is the case when tailing a file)
 //
 // Example log message format: [22][This is a log message.][22][28][This is another log message.][28]
+//
+// A sparse index of the timestamps of the messages is kept alongside each log file, so that reading logs
+// since a given time seeks directly to the right position, and only decompresses the required part of
+// compressed rotated files.
 package local // import "github.com/docker/docker/daemon/logger/local"
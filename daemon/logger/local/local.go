@@ -154,6 +154,11 @@ func (d *driver) Name() string {
 	return Name
 }
 
+// LogFiles returns the path of the log file.
+func (d *driver) LogFiles() []string {
+	return []string{d.logfile.Path()}
+}
+
 func (d *driver) Log(msg *logger.Message) error {
 	d.mu.Lock()
 	err := d.logfile.WriteLogEntry(msg)
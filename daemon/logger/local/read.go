@@ -21,6 +21,12 @@ func (d *driver) ReadLogs(config logger.ReadConfig) *logger.LogWatcher {
 	return logWatcher
 }
 
+// FiltersLogs implements the logger's FilteringLogReader interface, the
+// filter is applied before the tail.
+func (d *driver) FiltersLogs() bool {
+	return true
+}
+
 func (d *driver) readLogs(watcher *logger.LogWatcher, config logger.ReadConfig) {
 	defer close(watcher.Msg)
 
@@ -91,6 +91,10 @@ type ReadConfig struct {
 	Until  time.Time
 	Tail   int
 	Follow bool
+	// Filter selects the messages to read. Tail applies to the matching
+	// messages for the FilteringLogReader readers. It is not passed to
+	// logging plugins.
+	Filter *Filter `json:"-"`
 }
 
 // LogReader is the interface for reading log messages for loggers that support reading.
@@ -99,6 +103,29 @@ type LogReader interface {
 	ReadLogs(ReadConfig) *LogWatcher
 }
 
+// LogFilesReporter is implemented by the loggers which write the logs to
+// local files, and by the loggers wrapping them.
+type LogFilesReporter interface {
+	// LogFiles returns the paths of the files the logs are written to. The
+	// rotated files are named after them, with a suffix.
+	LogFiles() []string
+}
+
+// FilteringLogReader is the interface for log readers which apply the
+// Filter of the ReadConfig themselves, before Tail. Other log readers
+// return the last Tail messages, and they are only filtered afterwards.
+type FilteringLogReader interface {
+	LogReader
+	FiltersLogs() bool
+}
+
+// FiltersLogs returns whether the log reader applies the Filter of the
+// ReadConfig before Tail.
+func FiltersLogs(r LogReader) bool {
+	fr, ok := r.(FilteringLogReader)
+	return ok && fr.FiltersLogs()
+}
+
 // LogWatcher is used when consuming logs read from the LogReader interface.
 type LogWatcher struct {
 	// For sending log messages to a reader.
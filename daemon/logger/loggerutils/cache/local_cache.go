@@ -0,0 +1,162 @@
+// Package cache provides a local cache for the logging drivers which can't
+// read back logs, so that "docker logs" works with any logging driver.
+package cache // import "github.com/docker/docker/daemon/logger/loggerutils/cache"
+
+import (
+	"strconv"
+	"strings"
+
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/daemon/logger"
+	"github.com/docker/docker/daemon/logger/local"
+	"github.com/docker/go-units"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DriverName is the name of the driver used for local log caching
+	DriverName = local.Name
+
+	cachePrefix      = "cache-"
+	cacheDisabledKey = cachePrefix + "disabled"
+)
+
+// WithLocalCache wraps the passed in logger with a logger which caches all
+// writes locally in addition to writing to the passed in logger, and reads
+// logs back from the cache. The cache is written to info.LogPath.
+func WithLocalCache(l logger.Logger, info logger.Info) (logger.Logger, error) {
+	initLogger, err := logger.GetLogDriver(DriverName)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheInfo := info
+	cacheInfo.Config = cacheConfig(info.Config)
+	cacher, err := initLogger(cacheInfo)
+	if err != nil {
+		return nil, errors.Wrap(err, "error initializing local log cache driver")
+	}
+
+	// Don't slow the container down, or make it fail, because of the cache.
+	if mode := containertypes.LogMode(info.Config["mode"]); mode == containertypes.LogModeUnset || mode == containertypes.LogModeNonBlock {
+		var size int64 = -1
+		if s, exists := info.Config["max-buffer-size"]; exists {
+			size, err = units.RAMInBytes(s)
+			if err != nil {
+				cacher.Close()
+				return nil, err
+			}
+		}
+		cacher = logger.NewRingLogger(cacher, cacheInfo, size)
+	}
+
+	lc := &loggerWithCache{l: l, cache: cacher}
+	if _, ok := l.(logger.SizedLogger); ok {
+		return &sizedLoggerWithCache{lc}, nil
+	}
+	return lc, nil
+}
+
+// cacheConfig returns the options of the cache driver, which are the
+// "cache-" prefixed options.
+func cacheConfig(cfg map[string]string) map[string]string {
+	cacheCfg := make(map[string]string)
+	for k, v := range cfg {
+		if k != cacheDisabledKey && strings.HasPrefix(k, cachePrefix) {
+			cacheCfg[strings.TrimPrefix(k, cachePrefix)] = v
+		}
+	}
+	return cacheCfg
+}
+
+type loggerWithCache struct {
+	l     logger.Logger
+	cache logger.Logger
+}
+
+func (l *loggerWithCache) Log(msg *logger.Message) error {
+	// copy the message as the original will be reset once the call to `Log` is complete
+	dup := logger.NewMessage()
+	dumbCopyMessage(dup, msg)
+
+	if err := l.l.Log(msg); err != nil {
+		logger.PutMessage(dup)
+		return err
+	}
+	return l.cache.Log(dup)
+}
+
+func (l *loggerWithCache) Name() string {
+	return l.l.Name()
+}
+
+// BufferStats returns the state of the buffers of the cached driver. The
+// buffer of the cache itself is not included.
+func (l *loggerWithCache) BufferStats() logger.BufferStats {
+	if r, ok := l.l.(logger.BufferStatsReporter); ok {
+		return r.BufferStats()
+	}
+	return logger.BufferStats{}
+}
+
+// LogFiles returns the log files of the cached driver, if any, and of the
+// cache.
+func (l *loggerWithCache) LogFiles() []string {
+	var files []string
+	for _, lg := range []logger.Logger{l.l, l.cache} {
+		if lr, ok := lg.(logger.LogFilesReporter); ok {
+			files = append(files, lr.LogFiles()...)
+		}
+	}
+	return files
+}
+
+func (l *loggerWithCache) ReadLogs(config logger.ReadConfig) *logger.LogWatcher {
+	return l.cache.(logger.LogReader).ReadLogs(config)
+}
+
+func (l *loggerWithCache) FiltersLogs() bool {
+	return logger.FiltersLogs(l.cache.(logger.LogReader))
+}
+
+func (l *loggerWithCache) Close() error {
+	err := l.l.Close()
+	if err := l.cache.Close(); err != nil {
+		logrus.WithError(err).Warn("error while shutting down cache logger")
+	}
+	return err
+}
+
+// sizedLoggerWithCache preserves the buffer size of the cached driver.
+type sizedLoggerWithCache struct {
+	*loggerWithCache
+}
+
+func (l *sizedLoggerWithCache) BufSize() int {
+	return l.l.(logger.SizedLogger).BufSize()
+}
+
+// ShouldUseCache reads the log opts to determine if caching should be enabled
+func ShouldUseCache(cfg map[string]string) bool {
+	if cfg[cacheDisabledKey] == "" {
+		return true
+	}
+	b, err := strconv.ParseBool(cfg[cacheDisabledKey])
+	if err != nil {
+		// This shouldn't happen since the values are validated before hand.
+		return false
+	}
+	return !b
+}
+
+// dumbCopyMessage is a bit of a fake copy but avoids extra allocations which
+// are not necessary for this use case.
+func dumbCopyMessage(dst, src *logger.Message) {
+	dst.Source = src.Source
+	dst.Timestamp = src.Timestamp
+	dst.PLogMetaData = src.PLogMetaData
+	dst.Err = src.Err
+	dst.Attrs = src.Attrs
+	dst.Line = append(dst.Line[:0], src.Line...)
+}
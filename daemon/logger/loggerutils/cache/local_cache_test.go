@@ -0,0 +1,75 @@
+package cache // import "github.com/docker/docker/daemon/logger/loggerutils/cache"
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/daemon/logger"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+type countingLogger struct {
+	n int
+}
+
+func (l *countingLogger) Log(msg *logger.Message) error {
+	l.n++
+	logger.PutMessage(msg)
+	return nil
+}
+
+func (l *countingLogger) Name() string { return "counting" }
+
+func (l *countingLogger) Close() error { return nil }
+
+func TestLocalCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", t.Name())
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	remote := &countingLogger{}
+	info := logger.Info{
+		ContainerID: "test",
+		LogPath:     filepath.Join(dir, "container-cached.log"),
+		Config:      map[string]string{"mode": "blocking", "cache-max-file": "2"},
+	}
+	l, err := WithLocalCache(remote, info)
+	assert.NilError(t, err)
+	defer l.Close()
+
+	lines := []string{"hello", "world"}
+	for _, line := range lines {
+		msg := logger.NewMessage()
+		msg.Source = "stdout"
+		msg.Timestamp = time.Now()
+		msg.Line = append(msg.Line, line...)
+		assert.NilError(t, l.Log(msg))
+	}
+	assert.Check(t, is.Equal(remote.n, len(lines)))
+	assert.Check(t, is.Equal(l.Name(), "counting"))
+
+	lw := l.(logger.LogReader).ReadLogs(logger.ReadConfig{Tail: -1})
+	defer lw.ConsumerGone()
+	for _, line := range lines {
+		select {
+		case msg := <-lw.Msg:
+			assert.Check(t, is.Equal(string(msg.Line), line+"\n"))
+		case err := <-lw.Err:
+			t.Fatal(err)
+		case <-time.After(10 * time.Second):
+			t.Fatal("timeout waiting for cached log message")
+		}
+	}
+}
+
+func TestValidateLogCacheOpts(t *testing.T) {
+	assert.Check(t, validateLogCacheOpts(map[string]string{"cache-disabled": "true", "cache-max-size": "10m"}))
+	assert.Check(t, validateLogCacheOpts(map[string]string{"cache-disabled": "maybe"}) != nil)
+	assert.Check(t, validateLogCacheOpts(map[string]string{"cache-max-file": "a few"}) != nil)
+	assert.Check(t, !ShouldUseCache(map[string]string{"cache-disabled": "true"}))
+	assert.Check(t, ShouldUseCache(map[string]string{}))
+}
@@ -0,0 +1,59 @@
+package cache // import "github.com/docker/docker/daemon/logger/loggerutils/cache"
+
+import (
+	"strconv"
+
+	"github.com/docker/docker/daemon/logger"
+	"github.com/docker/docker/daemon/logger/local"
+	"github.com/docker/go-units"
+	"github.com/pkg/errors"
+)
+
+var builtInCacheLogOpts = map[string]bool{
+	cacheDisabledKey: true,
+}
+
+func init() {
+	for k, v := range local.LogOptKeys {
+		builtInCacheLogOpts[cachePrefix+k] = v
+	}
+	logger.AddBuiltinLogOpts(builtInCacheLogOpts)
+	logger.RegisterExternalValidator(validateLogCacheOpts)
+}
+
+func validateLogCacheOpts(cfg map[string]string) error {
+	if v, ok := cfg[cacheDisabledKey]; ok {
+		if _, err := strconv.ParseBool(v); err != nil {
+			return errors.Wrapf(err, "invalid value for %s", cacheDisabledKey)
+		}
+	}
+	if v, ok := cfg[cachePrefix+"max-size"]; ok {
+		if _, err := units.FromHumanSize(v); err != nil {
+			return errors.Wrapf(err, "invalid value for %smax-size", cachePrefix)
+		}
+	}
+	if v, ok := cfg[cachePrefix+"max-file"]; ok {
+		if _, err := strconv.Atoi(v); err != nil {
+			return errors.Wrapf(err, "invalid value for %smax-file", cachePrefix)
+		}
+	}
+	if v, ok := cfg[cachePrefix+"compress"]; ok {
+		if _, err := strconv.ParseBool(v); err != nil {
+			return errors.Wrapf(err, "invalid value for %scompress", cachePrefix)
+		}
+	}
+	return nil
+}
+
+// MergeDefaultLogConfig reads the default log opts and makes sure that any
+// caching related keys that exist there are added to dst.
+func MergeDefaultLogConfig(dst, defaults map[string]string) {
+	for k, v := range defaults {
+		if !builtInCacheLogOpts[k] {
+			continue
+		}
+		if _, exists := dst[k]; !exists {
+			dst[k] = v
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package loggerutils // import "github.com/docker/docker/daemon/logger/loggerutils"
+
+import (
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// indexSuffix is the suffix of the index file kept alongside each log
+	// file. Rotated files keep their index under the same name, without the
+	// compression extension (e.g. "container.log.1.idx" for
+	// "container.log.1.gz").
+	indexSuffix = ".idx"
+
+	// indexInterval is the minimum number of bytes of log entries between
+	// two entries of the index.
+	indexInterval = 64 * 1024
+
+	// indexEntrySize is the size of an encoded index entry.
+	indexEntrySize = 24
+)
+
+// indexEntry maps the timestamp of a log entry to its position in a log
+// file. The index is sparse: there is an entry every indexInterval bytes.
+type indexEntry struct {
+	Timestamp int64 // Timestamp of the log entry, in nanoseconds
+	Offset    int64 // Offset of the log entry in the uncompressed log file
+	// CompressedOffset is the offset of the gzip member that starts with
+	// the log entry in a compressed log file. It is 0 for uncompressed files.
+	CompressedOffset int64
+}
+
+func (e indexEntry) marshal(buf []byte) {
+	binary.BigEndian.PutUint64(buf[0:], uint64(e.Timestamp))
+	binary.BigEndian.PutUint64(buf[8:], uint64(e.Offset))
+	binary.BigEndian.PutUint64(buf[16:], uint64(e.CompressedOffset))
+}
+
+func (e *indexEntry) unmarshal(buf []byte) {
+	e.Timestamp = int64(binary.BigEndian.Uint64(buf[0:]))
+	e.Offset = int64(binary.BigEndian.Uint64(buf[8:]))
+	e.CompressedOffset = int64(binary.BigEndian.Uint64(buf[16:]))
+}
+
+// indexPath returns the path of the index of a log file.
+func indexPath(logPath string) string {
+	return strings.TrimSuffix(logPath, ".gz") + indexSuffix
+}
+
+// logIndex writes the index of the current log file.
+type logIndex struct {
+	f          *os.File
+	lastOffset int64 // offset of the last indexed log entry
+	empty      bool
+	buf        [indexEntrySize]byte
+}
+
+// openLogIndex opens the index of a log file of the given size for writing.
+// Entries which don't match the log file, because of a crash, are dropped.
+func openLogIndex(logPath string, logSize int64, perms os.FileMode, truncate bool) (*logIndex, error) {
+	flags := os.O_RDWR | os.O_CREATE
+	if truncate {
+		flags |= os.O_TRUNC
+	}
+	f, err := os.OpenFile(indexPath(logPath), flags, perms)
+	if err != nil {
+		return nil, errors.Wrap(err, "error opening log index")
+	}
+	ix := &logIndex{f: f, empty: true}
+
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, errors.Wrap(err, "error reading size of log index")
+	}
+	size := st.Size() - st.Size()%indexEntrySize
+	if size > 0 {
+		if _, err := f.ReadAt(ix.buf[:], size-indexEntrySize); err != nil {
+			f.Close()
+			return nil, errors.Wrap(err, "error reading log index")
+		}
+		var last indexEntry
+		last.unmarshal(ix.buf[:])
+		if last.Offset < logSize {
+			ix.lastOffset = last.Offset
+			ix.empty = false
+		} else {
+			size = 0
+		}
+	}
+	if size != st.Size() {
+		if err := f.Truncate(size); err != nil {
+			f.Close()
+			return nil, errors.Wrap(err, "error truncating log index")
+		}
+	}
+	if _, err := f.Seek(size, io.SeekStart); err != nil {
+		f.Close()
+		return nil, errors.Wrap(err, "error seeking log index")
+	}
+	return ix, nil
+}
+
+// add indexes the log entry with the given timestamp written at the given
+// offset, if it is far enough from the last indexed entry.
+func (ix *logIndex) add(timestamp time.Time, offset int64) error {
+	if !ix.empty && offset-ix.lastOffset < indexInterval {
+		return nil
+	}
+	indexEntry{Timestamp: timestamp.UnixNano(), Offset: offset}.marshal(ix.buf[:])
+	if _, err := ix.f.Write(ix.buf[:]); err != nil {
+		return err
+	}
+	ix.lastOffset = offset
+	ix.empty = false
+	return nil
+}
+
+func (ix *logIndex) close() error {
+	return ix.f.Close()
+}
+
+// readLogIndex reads the index of a log file. It returns no entries if the
+// log file has no index.
+func readLogIndex(logPath string) ([]indexEntry, error) {
+	b, err := ioutil.ReadFile(indexPath(logPath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	entries := make([]indexEntry, len(b)/indexEntrySize)
+	for i := range entries {
+		entries[i].unmarshal(b[i*indexEntrySize:])
+	}
+	return entries, nil
+}
+
+// writeLogIndex replaces the index of a log file.
+func writeLogIndex(logPath string, entries []indexEntry, perms os.FileMode) error {
+	b := make([]byte, len(entries)*indexEntrySize)
+	for i, e := range entries {
+		e.marshal(b[i*indexEntrySize:])
+	}
+	tmp := indexPath(logPath) + tmpLogfileSuffix
+	if err := ioutil.WriteFile(tmp, b, perms); err != nil {
+		return err
+	}
+	return os.Rename(tmp, indexPath(logPath))
+}
+
+// seekIndex returns the last entry of the index that is not after since,
+// so that reading the log file from it returns all the entries since then.
+func seekIndex(entries []indexEntry, since time.Time) (indexEntry, bool) {
+	ts := since.UnixNano()
+	i := sort.Search(len(entries), func(i int) bool {
+		return entries[i].Timestamp > ts
+	})
+	if i == 0 {
+		return indexEntry{}, false
+	}
+	return entries[i-1], true
+}
+
+// indexedOffset returns the offset from which to read a log file of the given
+// size to get the entries since the given time.
+func indexedOffset(logPath string, size int64, since time.Time) int64 {
+	if since.IsZero() {
+		return 0
+	}
+	entries, err := readLogIndex(logPath)
+	if err != nil {
+		return 0
+	}
+	e, ok := seekIndex(entries, since)
+	if !ok || e.Offset > size {
+		return 0
+	}
+	return e.Offset
+}
+
+// compressedOffset returns the offset of the gzip member from which to
+// decompress a compressed log file to get the entries since the given time.
+func compressedOffset(logPath string, since time.Time) int64 {
+	if since.IsZero() {
+		return 0
+	}
+	entries, err := readLogIndex(logPath)
+	if err != nil {
+		return 0
+	}
+	e, ok := seekIndex(entries, since)
+	if !ok {
+		return 0
+	}
+	return e.CompressedOffset
+}
@@ -0,0 +1,136 @@
+package loggerutils // import "github.com/docker/docker/daemon/logger/loggerutils"
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/daemon/logger"
+	"github.com/docker/docker/pkg/tailfile"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+// timestampedLine marshals log messages as "<timestamp> <line>" lines.
+func timestampedLine(msg *logger.Message) ([]byte, error) {
+	return []byte(fmt.Sprintf("%d %s\n", msg.Timestamp.UnixNano(), msg.Line)), nil
+}
+
+func decodeTimestampedLine(rdr io.Reader) func() (*logger.Message, error) {
+	br := bufio.NewReader(rdr)
+	return func() (*logger.Message, error) {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		parts := strings.SplitN(strings.TrimSuffix(line, "\n"), " ", 2)
+		ts, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return &logger.Message{Line: []byte(parts[1]), Timestamp: time.Unix(0, ts)}, nil
+	}
+}
+
+func TestLogFileIndexSince(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		t.Run(fmt.Sprintf("compress=%v", compress), func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "logfile-index")
+			assert.NilError(t, err)
+			defer os.RemoveAll(dir)
+
+			tailReader := func(ctx context.Context, r SizeReaderAt, lines int) (io.Reader, int, error) {
+				return tailfile.NewTailReader(ctx, r, lines)
+			}
+			logPath := filepath.Join(dir, "container.log")
+			lf, err := NewLogFile(logPath, 512*1024, 3, compress, timestampedLine, decodeTimestampedLine, 0640, tailReader)
+			assert.NilError(t, err)
+			defer lf.Close()
+
+			const count = 12000
+			base := time.Unix(1500000000, 0)
+			line := strings.Repeat("x", 100)
+			for i := 0; i < count; i++ {
+				msg := logger.NewMessage()
+				msg.Line = append(msg.Line, fmt.Sprintf("%05d %s", i, line)...)
+				msg.Timestamp = base.Add(time.Duration(i) * time.Millisecond)
+				assert.NilError(t, lf.WriteLogEntry(msg))
+			}
+			// wait for the compression of the rotated file to complete
+			lf.rotateMu.Lock()
+			lf.rotateMu.Unlock()
+
+			suffix := ""
+			if compress {
+				suffix = ".gz"
+			}
+			rotated := logPath + ".2" + suffix
+			entries, err := readLogIndex(rotated)
+			assert.NilError(t, err)
+			assert.Assert(t, len(entries) > 1)
+			if compress {
+				assert.Check(t, entries[len(entries)-1].CompressedOffset > 0)
+			}
+
+			// read from the middle of the oldest file
+			first := entries[len(entries)/2]
+			since := time.Unix(0, first.Timestamp).Add(time.Millisecond)
+			if compress {
+				assert.Check(t, is.Equal(first.CompressedOffset, compressedOffset(rotated, since)))
+			} else {
+				assert.Check(t, is.Equal(first.Offset, indexedOffset(rotated, 1<<30, since)))
+			}
+
+			watcher := logger.NewLogWatcher()
+			go lf.ReadLogs(logger.ReadConfig{Since: since, Tail: -1}, watcher)
+
+			expected := int(since.Sub(base) / time.Millisecond)
+			for expected < count {
+				select {
+				case msg := <-watcher.Msg:
+					assert.Assert(t, is.Equal(fmt.Sprintf("%05d %s", expected, line), string(msg.Line)))
+					expected++
+				case err := <-watcher.Err:
+					t.Fatal(err)
+				case <-time.After(10 * time.Second):
+					t.Fatalf("timeout waiting for log message %d", expected)
+				}
+			}
+			watcher.ConsumerGone()
+		})
+	}
+}
+
+func TestOpenLogIndexDropsStaleEntries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logfile-index")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	logPath := filepath.Join(dir, "container.log")
+
+	ix, err := openLogIndex(logPath, 0, 0640, false)
+	assert.NilError(t, err)
+	assert.NilError(t, ix.add(time.Unix(1, 0), 0))
+	assert.NilError(t, ix.add(time.Unix(2, 0), 10)) // too close to the previous entry
+	assert.NilError(t, ix.add(time.Unix(3, 0), indexInterval))
+	assert.NilError(t, ix.close())
+
+	entries, err := readLogIndex(logPath)
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(entries, 2))
+
+	// the log file was truncated after the last indexed entry was written
+	ix, err = openLogIndex(logPath, indexInterval, 0640, false)
+	assert.NilError(t, err)
+	assert.NilError(t, ix.close())
+	entries, err = readLogIndex(logPath)
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(entries, 0))
+}
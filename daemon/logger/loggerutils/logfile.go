@@ -79,6 +79,7 @@ func (rc *refCounter) Dereference(fileName string) error {
 type LogFile struct {
 	mu              sync.RWMutex // protects the logfile access
 	f               *os.File     // store for closing
+	index           *logIndex    // sparse timestamp index of the current file, if any
 	closed          bool
 	rotateMu        sync.Mutex // blocks the next rotation until the current rotation is completed
 	capacity        int64      // maximum size of each file
@@ -121,8 +122,15 @@ func NewLogFile(logPath string, capacity int64, maxFiles int, compress bool, mar
 		return nil, err
 	}
 
+	// The index is only an optimization, keep logging without it.
+	index, err := openLogIndex(logPath, size, perms, false)
+	if err != nil {
+		logrus.WithError(err).WithField("file", logPath).Warn("Failed to open log index")
+	}
+
 	return &LogFile{
 		f:               log,
+		index:           index,
 		capacity:        capacity,
 		currentSize:     size,
 		maxFiles:        maxFiles,
@@ -144,6 +152,7 @@ func (w *LogFile) WriteLogEntry(msg *logger.Message) error {
 		return errors.Wrap(err, "error marshalling log message")
 	}
 
+	timestamp := msg.Timestamp
 	logger.PutMessage(msg)
 
 	w.mu.Lock()
@@ -157,10 +166,18 @@ func (w *LogFile) WriteLogEntry(msg *logger.Message) error {
 		return err
 	}
 
+	if w.index != nil {
+		if err := w.index.add(timestamp, w.currentSize); err != nil {
+			logrus.WithError(err).WithField("file", w.f.Name()).Warn("Failed to index log entry, disabling the log index")
+			w.index.close()
+			w.index = nil
+		}
+	}
+
 	n, err := w.f.Write(b)
 	if err == nil {
 		w.currentSize += int64(n)
-		w.lastTimestamp = msg.Timestamp
+		w.lastTimestamp = timestamp
 	}
 	w.mu.Unlock()
 	return err
@@ -178,6 +195,9 @@ func (w *LogFile) checkCapacityAndRotate() error {
 			w.rotateMu.Unlock()
 			return errors.Wrap(err, "error closing file")
 		}
+		if w.index != nil {
+			w.index.close()
+		}
 		if err := rotate(fname, w.maxFiles, w.compress); err != nil {
 			w.rotateMu.Unlock()
 			return err
@@ -189,6 +209,10 @@ func (w *LogFile) checkCapacityAndRotate() error {
 		}
 		w.f = file
 		w.currentSize = 0
+		w.index, err = openLogIndex(fname, 0, w.perms, true)
+		if err != nil {
+			logrus.WithError(err).WithField("file", fname).Warn("Failed to open log index")
+		}
 		w.notifyRotate.Publish(struct{}{})
 
 		if w.maxFiles <= 1 || !w.compress {
@@ -220,6 +244,9 @@ func rotate(name string, maxFiles int, compress bool) error {
 	if err != nil && !os.IsNotExist(err) {
 		return errors.Wrap(err, "error removing oldest log file")
 	}
+	if err := os.Remove(indexPath(lastFile)); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "error removing oldest log index")
+	}
 
 	for i := maxFiles - 1; i > 1; i-- {
 		toPath := name + "." + strconv.Itoa(i) + extension
@@ -227,11 +254,17 @@ func rotate(name string, maxFiles int, compress bool) error {
 		if err := os.Rename(fromPath, toPath); err != nil && !os.IsNotExist(err) {
 			return err
 		}
+		if err := os.Rename(indexPath(fromPath), indexPath(toPath)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
 	}
 
 	if err := os.Rename(name, name+".1"); err != nil && !os.IsNotExist(err) {
 		return err
 	}
+	if err := os.Rename(indexPath(name), indexPath(name+".1")); err != nil && !os.IsNotExist(err) {
+		return err
+	}
 
 	return nil
 }
@@ -262,23 +295,79 @@ func compressFile(fileName string, lastTimestamp time.Time) {
 		}
 	}()
 
-	compressWriter := gzip.NewWriter(outFile)
-	defer compressWriter.Close()
-
 	// Add the last log entry timestramp to the gzip header
 	extra := rotateFileMetadata{}
 	extra.LastTime = lastTimestamp
-	compressWriter.Header.Extra, err = json.Marshal(&extra)
+	header, err := json.Marshal(&extra)
 	if err != nil {
 		// Here log the error only and don't return since this is just an optimization.
 		logrus.Warningf("Failed to marshal gzip header as JSON: %v", err)
 	}
 
-	_, err = pools.Copy(compressWriter, file)
+	// Start a new gzip member at each entry of the index, so that reading
+	// the compressed file can start from any of them. Readers decompress
+	// the members as a single stream.
+	index, err := readLogIndex(fileName)
+	if err != nil {
+		logrus.WithError(err).WithField("file", fileName).Warn("Failed to read log index")
+		index, err = nil, nil
+	}
+	out := &countingWriter{w: outFile}
+	compressWriter := gzip.NewWriter(out)
+	compressWriter.Header.Extra = header
+	var pos int64
+	for i := range index {
+		if index[i].Offset > pos {
+			if _, err = pools.Copy(compressWriter, io.LimitReader(file, index[i].Offset-pos)); err != nil {
+				break
+			}
+			if err = compressWriter.Close(); err != nil {
+				break
+			}
+			pos = index[i].Offset
+			compressWriter.Reset(out)
+		}
+		index[i].CompressedOffset = out.n
+	}
+	if err == nil {
+		_, err = pools.Copy(compressWriter, file)
+	}
+	if err == nil {
+		err = compressWriter.Close()
+	}
 	if err != nil {
 		logrus.WithError(err).WithField("module", "container.logs").WithField("file", fileName).Error("Error compressing log file")
 		return
 	}
+
+	if len(index) > 0 {
+		if err := writeLogIndex(fileName, index, 0640); err != nil {
+			// the index is only an optimization, remove it rather than
+			// keeping offsets that don't match the compressed file
+			logrus.WithError(err).WithField("file", fileName).Warn("Failed to write log index")
+			os.Remove(indexPath(fileName))
+		}
+	}
+}
+
+// countingWriter counts the bytes written to the underlying writer.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Path returns the path of the log file. The rotated files are named after
+// it, with a suffix.
+func (w *LogFile) Path() string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.f.Name()
 }
 
 // MaxFiles return maximum number of files
@@ -296,6 +385,9 @@ func (w *LogFile) Close() error {
 	if err := w.f.Close(); err != nil {
 		return err
 	}
+	if w.index != nil {
+		w.index.close()
+	}
 	w.closed = true
 	return nil
 }
@@ -320,6 +412,9 @@ func (w *LogFile) ReadLogs(config logger.ReadConfig, watcher *logger.LogWatcher)
 		watcher.Err <- err
 		return
 	}
+	if offset := indexedOffset(currentFile.Name(), currentChunk.Size(), config.Since); offset > 0 {
+		currentChunk = io.NewSectionReader(currentFile, offset, currentChunk.Size()-offset)
+	}
 
 	if config.Tail != 0 {
 		// TODO(@cpuguy83): Instead of opening every file, only get the files which
@@ -353,7 +448,12 @@ func (w *LogFile) ReadLogs(config logger.ReadConfig, watcher *logger.LogWatcher)
 				closeFiles()
 				return
 			}
-			readers = append(readers, io.NewSectionReader(f, 0, stat.Size()))
+			// decompressed files already start at the indexed offset
+			var offset int64
+			if !strings.HasSuffix(f.Name(), tmpLogfileSuffix) {
+				offset = indexedOffset(f.Name(), stat.Size(), config.Since)
+			}
+			readers = append(readers, io.NewSectionReader(f, offset, stat.Size()-offset))
 		}
 		if currentChunk.Size() > 0 {
 			readers = append(readers, currentChunk)
@@ -404,11 +504,17 @@ func (w *LogFile) openRotatedFiles(config logger.ReadConfig) (files []*os.File,
 
 			fileName := fmt.Sprintf("%s.%d.gz", w.f.Name(), i-1)
 			decompressedFileName := fileName + tmpLogfileSuffix
+			// Only decompress the file from the gzip member holding the
+			// first entry since config.Since, if it is indexed.
+			offset := compressedOffset(fileName, config.Since)
+			if offset > 0 {
+				decompressedFileName = fmt.Sprintf("%s.%d%s", fileName, offset, tmpLogfileSuffix)
+			}
 			tmpFile, err := w.filesRefCounter.GetReference(decompressedFileName, func(refFileName string, exists bool) (*os.File, error) {
 				if exists {
 					return os.Open(refFileName)
 				}
-				return decompressfile(fileName, refFileName, config.Since)
+				return decompressfile(fileName, refFileName, config.Since, offset)
 			})
 
 			if err != nil {
@@ -431,7 +537,9 @@ func (w *LogFile) openRotatedFiles(config logger.ReadConfig) (files []*os.File,
 	return files, nil
 }
 
-func decompressfile(fileName, destFileName string, since time.Time) (*os.File, error) {
+// decompressfile decompresses a log file from the gzip member at the given
+// offset.
+func decompressfile(fileName, destFileName string, since time.Time, offset int64) (*os.File, error) {
 	cf, err := os.Open(fileName)
 	if err != nil {
 		return nil, errors.Wrap(err, "error opening file for decompression")
@@ -451,6 +559,15 @@ func decompressfile(fileName, destFileName string, since time.Time) (*os.File, e
 		return nil, nil
 	}
 
+	if offset > 0 {
+		if _, err := cf.Seek(offset, io.SeekStart); err != nil {
+			return nil, errors.Wrap(err, "error seeking compressed log file")
+		}
+		if err := rc.Reset(cf); err != nil {
+			return nil, errors.Wrap(err, "error making gzip reader for compressed log file")
+		}
+	}
+
 	rs, err := os.OpenFile(destFileName, os.O_CREATE|os.O_RDWR, 0640)
 	if err != nil {
 		return nil, errors.Wrap(err, "error creating file for copying decompressed log stream")
@@ -495,7 +612,10 @@ func tailFiles(files []SizeReaderAt, watcher *logger.LogWatcher, createDecoder m
 
 	readers := make([]io.Reader, 0, len(files))
 
-	if config.Tail > 0 {
+	// When filtering, the number of lines to read back to get the last
+	// matching ones is unknown, so all the files are read and the matching
+	// messages are tailed in memory.
+	if config.Tail > 0 && config.Filter == nil {
 		for i := len(files) - 1; i >= 0 && nLines > 0; i-- {
 			tail, n, err := getTailReader(ctx, files[i], nLines)
 			if err != nil {
@@ -513,11 +633,33 @@ func tailFiles(files []SizeReaderAt, watcher *logger.LogWatcher, createDecoder m
 
 	rdr := io.MultiReader(readers...)
 	decodeLogLine := createDecoder(rdr)
+
+	// tail is a ring buffer of the last matching messages when filtering,
+	// head is the position of the oldest one and n the number of messages
+	// it holds.
+	var (
+		tail    []*logger.Message
+		head, n int
+	)
+	if config.Tail > 0 && config.Filter != nil {
+		tail = make([]*logger.Message, config.Tail)
+		defer func() {
+			for i := 0; i < n; i++ {
+				select {
+				case <-ctx.Done():
+					return
+				case watcher.Msg <- tail[(head+i)%len(tail)]:
+				}
+			}
+		}()
+	}
+
 	for {
 		msg, err := decodeLogLine()
 		if err != nil {
 			if errors.Cause(err) != io.EOF {
 				watcher.Err <- err
+				n = 0
 			}
 			return
 		}
@@ -527,6 +669,19 @@ func tailFiles(files []SizeReaderAt, watcher *logger.LogWatcher, createDecoder m
 		if !config.Until.IsZero() && msg.Timestamp.After(config.Until) {
 			return
 		}
+		if !config.Filter.Match(msg) {
+			continue
+		}
+		if tail != nil {
+			if n == len(tail) {
+				tail[head] = msg
+				head = (head + 1) % len(tail)
+			} else {
+				tail[(head+n)%len(tail)] = msg
+				n++
+			}
+			continue
+		}
 		select {
 		case <-ctx.Done():
 			return
@@ -10,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/daemon/logger"
 	"github.com/docker/docker/pkg/tailfile"
 	"gotest.tools/assert"
@@ -77,6 +78,49 @@ func TestTailFiles(t *testing.T) {
 	}
 }
 
+func TestTailFilesFiltered(t *testing.T) {
+	s1 := strings.NewReader("Hello.\nMy name is Inigo Montoya.\n")
+	s2 := strings.NewReader("I'm serious.\nDon't call me Shirley!\n")
+	s3 := strings.NewReader("Roads?\nWhere we're going we don't need roads.\n")
+
+	files := []SizeReaderAt{s1, s2, s3}
+	watcher := logger.NewLogWatcher()
+	createDecoder := func(r io.Reader) func() (*logger.Message, error) {
+		scanner := bufio.NewScanner(r)
+		return func() (*logger.Message, error) {
+			if !scanner.Scan() {
+				if err := scanner.Err(); err != nil {
+					return nil, err
+				}
+				return nil, io.EOF
+			}
+			line := append([]byte(nil), scanner.Bytes()...)
+			return &logger.Message{Line: line, Timestamp: time.Now()}, nil
+		}
+	}
+	tailReader := func(ctx context.Context, r SizeReaderAt, lines int) (io.Reader, int, error) {
+		return tailfile.NewTailReader(ctx, r, lines)
+	}
+
+	args := filters.NewArgs(filters.Arg("match", "'"))
+	filter, err := logger.NewFilter(args)
+	assert.NilError(t, err)
+
+	go tailFiles(files, watcher, createDecoder, tailReader, logger.ReadConfig{Tail: 2, Filter: filter})
+
+	for _, expected := range []string{"Don't call me Shirley!", "Where we're going we don't need roads."} {
+		select {
+		case <-time.After(60 * time.Second):
+			t.Fatal("timeout waiting for tail line")
+		case err := <-watcher.Err:
+			assert.NilError(t, err)
+		case msg := <-watcher.Msg:
+			assert.Assert(t, msg != nil)
+			assert.Equal(t, string(msg.Line), expected)
+		}
+	}
+}
+
 func TestFollowLogsConsumerGone(t *testing.T) {
 	lw := logger.NewLogWatcher()
 
@@ -2,12 +2,15 @@ package logger // import "github.com/docker/docker/daemon/logger"
 
 import (
 	"github.com/docker/go-metrics"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
 	logWritesFailedCount metrics.Counter
 	logReadsFailedCount  metrics.Counter
 	totalPartialLogs     metrics.Counter
+	totalRateLimitedLogs metrics.Counter
+	totalSampledOutLogs  metrics.Counter
 )
 
 func init() {
@@ -16,6 +19,64 @@ func init() {
 	logWritesFailedCount = loggerMetrics.NewCounter("log_write_operations_failed", "Number of log write operations that failed")
 	logReadsFailedCount = loggerMetrics.NewCounter("log_read_operations_failed", "Number of log reads from container stdio that failed")
 	totalPartialLogs = loggerMetrics.NewCounter("log_entries_size_greater_than_buffer", "Number of log entries which are larger than the log buffer")
+	totalRateLimitedLogs = loggerMetrics.NewCounter("log_entries_rate_limited", "Number of log entries dropped because of the rate limits of their container")
+	totalSampledOutLogs = loggerMetrics.NewCounter("log_entries_sampled_out", "Number of log entries dropped by the sampling of their container")
+
+	loggerMetrics.Add(newBufferCollector(loggerMetrics))
 
 	metrics.Register(loggerMetrics)
 }
+
+// bufferCollector reports the state of the buffer of each open RingLogger.
+type bufferCollector struct {
+	enqueued     *prometheus.Desc
+	dropped      *prometheus.Desc
+	droppedBytes *prometheus.Desc
+	messages     *prometheus.Desc
+	size         *prometheus.Desc
+	maxSize      *prometheus.Desc
+}
+
+func newBufferCollector(ns *metrics.Namespace) *bufferCollector {
+	return &bufferCollector{
+		enqueued:     ns.NewDesc("buffer_enqueued", "Number of log entries added to the buffer of a non-blocking logger", metrics.Total, "container", "driver"),
+		dropped:      ns.NewDesc("buffer_dropped", "Number of log entries dropped because the buffer of a non-blocking logger was full", metrics.Total, "container", "driver"),
+		droppedBytes: ns.NewDesc("buffer_dropped", "Size of the log entries dropped because the buffer of a non-blocking logger was full", metrics.Bytes, "container", "driver"),
+		messages:     ns.NewDesc("buffer", "Number of log entries in the buffer of a non-blocking logger", metrics.Unit("entries"), "container", "driver"),
+		size:         ns.NewDesc("buffer_size", "Size of the log entries in the buffer of a non-blocking logger", metrics.Bytes, "container", "driver"),
+		maxSize:      ns.NewDesc("buffer_max_size", "Size of the buffer of a non-blocking logger", metrics.Bytes, "container", "driver"),
+	}
+}
+
+func (c *bufferCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.enqueued
+	ch <- c.dropped
+	ch <- c.droppedBytes
+	ch <- c.messages
+	ch <- c.size
+	ch <- c.maxSize
+}
+
+// Collect reports the buffers of the RingLoggers of each container and
+// driver. A container can log to several destinations with the same driver,
+// so their buffers are summed up, as the metrics would otherwise have the
+// same labels.
+func (c *bufferCollector) Collect(ch chan<- prometheus.Metric) {
+	type bufferKey struct{ id, driver string }
+	stats := make(map[bufferKey]*BufferStats)
+	for _, r := range rings.list() {
+		k := bufferKey{r.logInfo.ContainerID, r.l.Name()}
+		if stats[k] == nil {
+			stats[k] = &BufferStats{}
+		}
+		stats[k].add(r.BufferStats())
+	}
+	for k, s := range stats {
+		ch <- prometheus.MustNewConstMetric(c.enqueued, prometheus.CounterValue, float64(s.Enqueued), k.id, k.driver)
+		ch <- prometheus.MustNewConstMetric(c.dropped, prometheus.CounterValue, float64(s.Dropped), k.id, k.driver)
+		ch <- prometheus.MustNewConstMetric(c.droppedBytes, prometheus.CounterValue, float64(s.DroppedBytes), k.id, k.driver)
+		ch <- prometheus.MustNewConstMetric(c.messages, prometheus.GaugeValue, float64(s.Messages), k.id, k.driver)
+		ch <- prometheus.MustNewConstMetric(c.size, prometheus.GaugeValue, float64(s.Size), k.id, k.driver)
+		ch <- prometheus.MustNewConstMetric(c.maxSize, prometheus.GaugeValue, float64(s.MaxSize), k.id, k.driver)
+	}
+}
@@ -0,0 +1,41 @@
+package logger // import "github.com/docker/docker/daemon/logger"
+
+import (
+	"testing"
+
+	"github.com/docker/go-metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestBufferCollectorSameDriver(t *testing.T) {
+	// a container logging to two destinations with the same driver
+	info := Info{ContainerID: "buffer-collector-test"}
+	for i := 0; i < 2; i++ {
+		r := newRingLogger(nopLogger{}, info, 1024)
+		defer r.Close()
+	}
+
+	reg := prometheus.NewRegistry()
+	assert.NilError(t, reg.Register(newBufferCollector(metrics.NewNamespace("logger", "", nil))))
+	families, err := reg.Gather()
+	assert.NilError(t, err)
+
+	var found bool
+	for _, f := range families {
+		if f.GetName() != "logger_buffer_max_size_bytes" {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "container" && l.GetValue() == info.ContainerID {
+					assert.Check(t, !found, "duplicate metric")
+					found = true
+					assert.Check(t, is.Equal(float64(2048), m.GetGauge().GetValue()))
+				}
+			}
+		}
+	}
+	assert.Check(t, found)
+}
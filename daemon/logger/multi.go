@@ -0,0 +1,128 @@
+package logger // import "github.com/docker/docker/daemon/logger"
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// MultiLogger sends the messages to several logging drivers. Logs are read
+// back from the first driver which supports reading logs.
+type MultiLogger struct {
+	loggers []Logger
+	bufSize int
+}
+
+type multiLoggerWithReader struct {
+	*MultiLogger
+	reader LogReader
+}
+
+// NewMultiLogger creates a logger which sends the messages to all of the
+// given loggers. Since each message is sent to the loggers one after the
+// other, the loggers should not block, e.g. by using a RingLogger for each
+// of them.
+func NewMultiLogger(loggers ...Logger) Logger {
+	l := &MultiLogger{loggers: loggers, bufSize: defaultBufSize}
+	for _, lg := range loggers {
+		if sl, ok := lg.(SizedLogger); ok && sl.BufSize() > 0 && sl.BufSize() < l.bufSize {
+			l.bufSize = sl.BufSize()
+		}
+	}
+	for _, lg := range loggers {
+		if r, ok := lg.(LogReader); ok {
+			return &multiLoggerWithReader{MultiLogger: l, reader: r}
+		}
+	}
+	return l
+}
+
+// Log sends a copy of the message to each logger. The message itself is
+// sent to the last logger, which owns it after that.
+func (l *MultiLogger) Log(msg *Message) error {
+	var errs []string
+	for i, lg := range l.loggers {
+		m := msg
+		if i < len(l.loggers)-1 {
+			m = NewMessage()
+			copyMessage(m, msg)
+		}
+		if err := lg.Log(m); err != nil {
+			errs = append(errs, lg.Name()+": "+err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// Name returns the names of the loggers, separated by commas.
+func (l *MultiLogger) Name() string {
+	names := make([]string, 0, len(l.loggers))
+	for _, lg := range l.loggers {
+		names = append(names, lg.Name())
+	}
+	return strings.Join(names, ",")
+}
+
+// BufSize returns the smallest buffer size of the loggers, so that messages
+// fit all of them.
+func (l *MultiLogger) BufSize() int {
+	return l.bufSize
+}
+
+// Close closes all the loggers.
+func (l *MultiLogger) Close() error {
+	var errs []string
+	for _, lg := range l.loggers {
+		if err := lg.Close(); err != nil {
+			errs = append(errs, lg.Name()+": "+err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// BufferStats returns the sum of the states of the buffers of the loggers.
+func (l *MultiLogger) BufferStats() BufferStats {
+	var stats BufferStats
+	for _, lg := range l.loggers {
+		if r, ok := lg.(BufferStatsReporter); ok {
+			stats.add(r.BufferStats())
+		}
+	}
+	return stats
+}
+
+// LogFiles returns the log files of all the loggers.
+func (l *MultiLogger) LogFiles() []string {
+	var files []string
+	for _, lg := range l.loggers {
+		if lr, ok := lg.(LogFilesReporter); ok {
+			files = append(files, lr.LogFiles()...)
+		}
+	}
+	return files
+}
+
+func (l *multiLoggerWithReader) ReadLogs(cfg ReadConfig) *LogWatcher {
+	return l.reader.ReadLogs(cfg)
+}
+
+func (l *multiLoggerWithReader) FiltersLogs() bool {
+	return FiltersLogs(l.reader)
+}
+
+// copyMessage copies src to dst. Attrs and the partial metadata are not
+// modified by the loggers, so they are shared.
+func copyMessage(dst, src *Message) {
+	dst.Source = src.Source
+	dst.Timestamp = src.Timestamp
+	dst.PLogMetaData = src.PLogMetaData
+	dst.Err = src.Err
+	dst.Attrs = src.Attrs
+	dst.Line = append(dst.Line[:0], src.Line...)
+}
@@ -0,0 +1,90 @@
+package logger // import "github.com/docker/docker/daemon/logger"
+
+import (
+	"testing"
+	"time"
+)
+
+type recordingLogger struct {
+	lines []string
+}
+
+func (l *recordingLogger) Log(msg *Message) error {
+	l.lines = append(l.lines, string(msg.Line))
+	PutMessage(msg)
+	return nil
+}
+
+func (l *recordingLogger) Name() string { return "recording" }
+
+func (l *recordingLogger) Close() error { return nil }
+
+type readingLogger struct {
+	recordingLogger
+	reads int
+}
+
+func (l *readingLogger) ReadLogs(ReadConfig) *LogWatcher {
+	l.reads++
+	return NewLogWatcher()
+}
+
+func TestMultiLogger(t *testing.T) {
+	l1 := &recordingLogger{}
+	l2 := &readingLogger{}
+	l3 := &recordingLogger{}
+
+	l := NewMultiLogger(l1, l2, l3)
+	for _, line := range []string{"hello", "world"} {
+		msg := NewMessage()
+		msg.Line = append(msg.Line, line...)
+		msg.Timestamp = time.Now()
+		if err := l.Log(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, rl := range []*recordingLogger{l1, &l2.recordingLogger, l3} {
+		if len(rl.lines) != 2 || rl.lines[0] != "hello" || rl.lines[1] != "world" {
+			t.Fatalf("unexpected lines: %q", rl.lines)
+		}
+	}
+
+	if name := l.Name(); name != "recording,recording,recording" {
+		t.Fatalf("unexpected name: %q", name)
+	}
+	if sl, ok := l.(SizedLogger); !ok || sl.BufSize() != defaultBufSize {
+		t.Fatal("expected the default buffer size")
+	}
+
+	reader, ok := l.(LogReader)
+	if !ok {
+		t.Fatal("expected a log reader")
+	}
+	reader.ReadLogs(ReadConfig{})
+	if l2.reads != 1 {
+		t.Fatal("expected logs to be read from the reading logger")
+	}
+
+	if _, ok := NewMultiLogger(l1, l3).(LogReader); ok {
+		t.Fatal("expected no log reader")
+	}
+}
+
+type filteringLogger struct {
+	readingLogger
+}
+
+func (l *filteringLogger) FiltersLogs() bool { return true }
+
+func TestMultiLoggerFiltersLogs(t *testing.T) {
+	l := NewMultiLogger(&recordingLogger{}, &readingLogger{})
+	if FiltersLogs(l.(LogReader)) {
+		t.Fatal("expected a reader which does not filter logs")
+	}
+
+	l = NewMultiLogger(&recordingLogger{}, NewRingLogger(&filteringLogger{}, Info{}, -1))
+	if !FiltersLogs(l.(LogReader)) {
+		t.Fatal("expected a reader which filters logs")
+	}
+	l.Close()
+}
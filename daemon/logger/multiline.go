@@ -0,0 +1,192 @@
+package logger // import "github.com/docker/docker/daemon/logger"
+
+import (
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Multi-line modes supported by the "multiline-mode" log option.
+const (
+	// MultilineModePattern starts a new message at each line matching
+	// the "multiline-pattern" regular expression. Other lines are appended
+	// to the current message.
+	MultilineModePattern = "pattern"
+	// MultilineModeIndent appends the lines starting with a space or a tab
+	// to the current message.
+	MultilineModeIndent = "indent"
+)
+
+const (
+	multilineModeKey    = "multiline-mode"
+	multilinePatternKey = "multiline-pattern"
+	multilineTimeoutKey = "multiline-timeout"
+	multilineMaxSizeKey = "multiline-max-size"
+
+	// defaultMultilineTimeout is the time after which a multi-line message
+	// is logged if no line was appended to it.
+	defaultMultilineTimeout = time.Second
+)
+
+// MultilineConfig configures how the Copier aggregates lines into
+// multi-line messages, such as stack traces.
+type MultilineConfig struct {
+	Mode    string
+	Pattern *regexp.Regexp
+	// Timeout is the time after which a message is logged if no line was
+	// appended to it.
+	Timeout time.Duration
+	// MaxSize is the maximum size of a message, in bytes. A message is
+	// logged as soon as appending a line would make it larger. 0 means the
+	// size of the Copier's buffer.
+	MaxSize int
+}
+
+// ParseMultilineConfig parses the multi-line log options. It returns nil if
+// multi-line aggregation is not enabled.
+func ParseMultilineConfig(cfg map[string]string) (*MultilineConfig, error) {
+	mode, ok := cfg[multilineModeKey]
+	if !ok {
+		for _, key := range []string{multilinePatternKey, multilineTimeoutKey, multilineMaxSizeKey} {
+			if _, ok := cfg[key]; ok {
+				return nil, errors.Errorf("logger: %s option is only supported with %s", key, multilineModeKey)
+			}
+		}
+		return nil, nil
+	}
+
+	ml := &MultilineConfig{Mode: mode, Timeout: defaultMultilineTimeout}
+	switch mode {
+	case MultilineModePattern:
+		s, ok := cfg[multilinePatternKey]
+		if !ok || s == "" {
+			return nil, errors.Errorf("logger: %s option is required with '%s=%s'", multilinePatternKey, multilineModeKey, mode)
+		}
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing option %s", multilinePatternKey)
+		}
+		ml.Pattern = re
+	case MultilineModeIndent:
+		if _, ok := cfg[multilinePatternKey]; ok {
+			return nil, errors.Errorf("logger: %s option is only supported with '%s=%s'", multilinePatternKey, multilineModeKey, MultilineModePattern)
+		}
+	default:
+		return nil, errors.Errorf("logger: multi-line mode not supported: %s", mode)
+	}
+
+	if s, ok := cfg[multilineTimeoutKey]; ok {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing option %s", multilineTimeoutKey)
+		}
+		if d <= 0 {
+			return nil, errors.Errorf("logger: %s must be positive", multilineTimeoutKey)
+		}
+		ml.Timeout = d
+	}
+	if s, ok := cfg[multilineMaxSizeKey]; ok {
+		size, err := units.RAMInBytes(s)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing option %s", multilineMaxSizeKey)
+		}
+		if size <= 0 {
+			return nil, errors.Errorf("logger: %s must be positive", multilineMaxSizeKey)
+		}
+		ml.MaxSize = int(size)
+	}
+	return ml, nil
+}
+
+// continues returns whether a line is the continuation of the previous one.
+func (ml *MultilineConfig) continues(line []byte) bool {
+	if ml.Mode == MultilineModeIndent {
+		return len(line) > 0 && (line[0] == ' ' || line[0] == '\t')
+	}
+	return !ml.Pattern.Match(line)
+}
+
+// multilineAggregator aggregates the lines of a stream into multi-line
+// messages before logging them.
+type multilineAggregator struct {
+	cfg     *MultilineConfig
+	maxSize int
+	dst     Logger
+
+	mu      sync.Mutex
+	pending *Message
+	timer   *time.Timer
+}
+
+func newMultilineAggregator(cfg *MultilineConfig, dst Logger, bufSize int) *multilineAggregator {
+	maxSize := cfg.MaxSize
+	if maxSize == 0 || maxSize > bufSize {
+		maxSize = bufSize
+	}
+	return &multilineAggregator{cfg: cfg, maxSize: maxSize, dst: dst}
+}
+
+// Log appends the line of a message to the pending message, or logs the
+// pending message and keeps this one pending. Partial messages are logged
+// as-is.
+func (a *multilineAggregator) Log(msg *Message) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if msg.PLogMetaData != nil {
+		a.flushLocked()
+		logMessage(a.dst, msg)
+		return
+	}
+
+	if a.pending != nil && a.cfg.continues(msg.Line) && len(a.pending.Line)+1+len(msg.Line) <= a.maxSize {
+		a.pending.Line = append(a.pending.Line, '\n')
+		a.pending.Line = append(a.pending.Line, msg.Line...)
+		PutMessage(msg)
+	} else {
+		a.flushLocked()
+		a.pending = msg
+	}
+
+	if a.timer == nil {
+		a.timer = time.AfterFunc(a.cfg.Timeout, a.Flush)
+	} else {
+		a.timer.Reset(a.cfg.Timeout)
+	}
+}
+
+// Flush logs the pending message, if any.
+func (a *multilineAggregator) Flush() {
+	a.mu.Lock()
+	a.flushLocked()
+	a.mu.Unlock()
+}
+
+func (a *multilineAggregator) flushLocked() {
+	if a.pending == nil {
+		return
+	}
+	logMessage(a.dst, a.pending)
+	a.pending = nil
+}
+
+// Close logs the pending message and stops the flush timer.
+func (a *multilineAggregator) Close() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.timer != nil {
+		a.timer.Stop()
+	}
+	a.flushLocked()
+}
+
+func logMessage(dst Logger, msg *Message) {
+	if logErr := dst.Log(msg); logErr != nil {
+		logWritesFailedCount.Inc(1)
+		logrus.Errorf("Failed to log msg %q for logger %s: %s", msg.Line, dst.Name(), logErr)
+	}
+}
@@ -0,0 +1,178 @@
+package otlp // import "github.com/docker/docker/daemon/logger/otlp"
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/docker/docker/pkg/pools"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// exportMethod is the gRPC method of the OTLP logs service.
+	exportMethod = "/opentelemetry.proto.collector.logs.v1.LogsService/Export"
+
+	// maxResponseSize is the max amount that will be read from an http response
+	maxResponseSize = 64 * 1024
+)
+
+// exporter sends encoded ExportLogsServiceRequests to a collector.
+type exporter interface {
+	export(ctx context.Context, req []byte) error
+	close() error
+}
+
+// retryableError is an export error after which the export can be retried,
+// possibly after the delay requested by the collector.
+type retryableError struct {
+	err   error
+	delay time.Duration
+}
+
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+// rawMessage is a protobuf message which is already encoded, which allows
+// to use the gRPC protobuf codec with the encoding of proto.go.
+type rawMessage struct {
+	data []byte
+}
+
+func (m *rawMessage) Reset()         { m.data = nil }
+func (m *rawMessage) String() string { return fmt.Sprintf("%x", m.data) }
+func (*rawMessage) ProtoMessage()    {}
+
+func (m *rawMessage) Marshal() ([]byte, error) {
+	return m.data, nil
+}
+
+func (m *rawMessage) Unmarshal(b []byte) error {
+	m.data = append(m.data[:0], b...)
+	return nil
+}
+
+type grpcExporter struct {
+	conn    *grpc.ClientConn
+	headers metadata.MD
+}
+
+func newGRPCExporter(endpoint string, tlsConfig *tls.Config, headers map[string]string) (*grpcExporter, error) {
+	opts := []grpc.DialOption{grpc.WithInsecure()}
+	if tlsConfig != nil {
+		opts = []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))}
+	}
+	// the connection is established in the background, and re-established
+	// when it is lost.
+	conn, err := grpc.Dial(endpoint, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &grpcExporter{conn: conn, headers: metadata.New(headers)}, nil
+}
+
+func (e *grpcExporter) export(ctx context.Context, req []byte) error {
+	if len(e.headers) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, e.headers)
+	}
+	var resp rawMessage
+	if err := e.conn.Invoke(ctx, exportMethod, &rawMessage{data: req}, &resp); err != nil {
+		switch status.Code(err) {
+		case codes.Canceled, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted, codes.OutOfRange, codes.Unavailable, codes.DataLoss:
+			return &retryableError{err: err}
+		}
+		return err
+	}
+	return checkPartialSuccess(resp.data)
+}
+
+func (e *grpcExporter) close() error {
+	return e.conn.Close()
+}
+
+type httpExporter struct {
+	client  *http.Client
+	url     string
+	headers map[string]string
+}
+
+func newHTTPExporter(url string, tlsConfig *tls.Config, headers map[string]string) *httpExporter {
+	transport := &http.Transport{
+		TLSClientConfig: tlsConfig,
+		Proxy:           http.ProxyFromEnvironment,
+	}
+	return &httpExporter{
+		client:  &http.Client{Transport: transport},
+		url:     url,
+		headers: headers,
+	}
+}
+
+func (e *httpExporter) export(ctx context.Context, req []byte) error {
+	httpReq, err := http.NewRequest(http.MethodPost, e.url, bytes.NewReader(req))
+	if err != nil {
+		return err
+	}
+	httpReq = httpReq.WithContext(ctx)
+	for k, v := range e.headers {
+		httpReq.Header.Set(k, v)
+	}
+	httpReq.Header.Set("Content-Type", "application/x-protobuf")
+
+	resp, err := e.client.Do(httpReq)
+	if err != nil {
+		return &retryableError{err: err}
+	}
+	defer func() {
+		pools.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return &retryableError{err: err}
+	}
+	if resp.StatusCode == http.StatusOK {
+		return checkPartialSuccess(body)
+	}
+
+	err = fmt.Errorf("%s: failed to export logs - %s", driverName, resp.Status)
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		var delay time.Duration
+		if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && s > 0 {
+			delay = time.Duration(s) * time.Second
+		}
+		return &retryableError{err: err, delay: delay}
+	}
+	return err
+}
+
+func (e *httpExporter) close() error {
+	e.client.Transport.(*http.Transport).CloseIdleConnections()
+	return nil
+}
+
+// checkPartialSuccess returns an error if the collector rejected some of the
+// log records. The export is not retried in that case.
+func checkPartialSuccess(resp []byte) error {
+	rejected, message, err := decodeExportResponse(resp)
+	if err != nil {
+		return fmt.Errorf("%s: invalid export response: %v", driverName, err)
+	}
+	if rejected > 0 {
+		return fmt.Errorf("%s: %d log records rejected by the collector: %s", driverName, rejected, message)
+	}
+	return nil
+}
@@ -0,0 +1,494 @@
+// Package otlp provides the log driver for forwarding container logs to an
+// OpenTelemetry collector using the OpenTelemetry protocol (OTLP), over gRPC
+// or HTTP.
+package otlp // import "github.com/docker/docker/daemon/logger/otlp"
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/daemon/logger"
+	"github.com/docker/docker/daemon/logger/loggerutils"
+	"github.com/docker/go-connections/tlsconfig"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	driverName               = "otlp"
+	endpointKey              = "otlp-endpoint"
+	protocolKey              = "otlp-protocol"
+	insecureKey              = "otlp-insecure"
+	headersKey               = "otlp-headers"
+	timeoutKey               = "otlp-timeout"
+	batchSizeKey             = "otlp-batch-size"
+	batchTimeoutKey          = "otlp-batch-timeout"
+	maxRetriesKey            = "otlp-max-retries"
+	tlsCACertKey             = "otlp-tls-ca-cert"
+	tlsCertKey               = "otlp-tls-cert"
+	tlsKeyKey                = "otlp-tls-key"
+	tlsInsecureSkipVerifyKey = "otlp-tls-insecure-skip-verify"
+	envKey                   = "env"
+	envRegexKey              = "env-regex"
+	tagKey                   = "tag"
+)
+
+const (
+	protocolGRPC         = "grpc"
+	protocolHTTPProtobuf = "http/protobuf"
+
+	// defaultHTTPPath is the path of the logs endpoint of OTLP/HTTP collectors
+	defaultHTTPPath = "/v1/logs"
+	// How long an export can take
+	defaultTimeout = 10 * time.Second
+	// How many records are exported at once
+	defaultBatchSize = 512
+	// How often records are exported (if we are not reaching batch size)
+	defaultBatchTimeout = time.Second
+	// How many times a failed export is retried before dropping the records
+	defaultMaxRetries = 5
+	// Number of records allowed to be queued in the channel
+	defaultStreamChannelSize = 4 * defaultBatchSize
+	// maxRetryBackoff is the maximum delay between two attempts of an export
+	maxRetryBackoff = 30 * time.Second
+
+	instrumentationScopeName = "github.com/docker/docker/daemon/logger/otlp"
+	containerLabelAttrPrefix = "container.label."
+	logIOStreamAttr          = "log.iostream"
+)
+
+// initialRetryBackoff is the delay before retrying a failed export. It is
+// doubled after each attempt, up to maxRetryBackoff.
+var initialRetryBackoff = time.Second
+
+type otlpLogger struct {
+	exporter     exporter
+	resource     []byte
+	scope        []byte
+	timeout      time.Duration
+	batchSize    int
+	batchTimeout time.Duration
+	maxRetries   int
+
+	// For synchronization between background worker and logger.
+	// We use channel to send records to worker go routine.
+	// closing is closed when the logger is closed, to stop retrying exports,
+	// and done when the worker exported the remaining records.
+	stream  chan *logRecord
+	lock    sync.RWMutex
+	closing chan struct{}
+	done    chan struct{}
+}
+
+func init() {
+	if err := logger.RegisterLogDriver(driverName, New); err != nil {
+		logrus.Fatal(err)
+	}
+	if err := logger.RegisterLogOptValidator(driverName, ValidateLogOpt); err != nil {
+		logrus.Fatal(err)
+	}
+}
+
+// New creates an otlp logger using the configuration passed in on the
+// context.
+func New(info logger.Info) (logger.Logger, error) {
+	protocol := info.Config[protocolKey]
+	if protocol == "" {
+		protocol = protocolGRPC
+	}
+	endpoint, secure, err := parseEndpoint(info.Config[endpointKey], protocol)
+	if err != nil {
+		return nil, err
+	}
+	if s, ok := info.Config[insecureKey]; ok {
+		insecure, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid value for %s: %v", driverName, insecureKey, err)
+		}
+		secure = !insecure
+	}
+
+	var tlsConfig *tls.Config
+	if secure {
+		var skipVerify bool
+		if s, ok := info.Config[tlsInsecureSkipVerifyKey]; ok && s != "" {
+			if skipVerify, err = strconv.ParseBool(s); err != nil {
+				return nil, fmt.Errorf("%s: invalid value for %s: %v", driverName, tlsInsecureSkipVerifyKey, err)
+			}
+		}
+		tlsConfig, err = tlsconfig.Client(tlsconfig.Options{
+			CAFile:             info.Config[tlsCACertKey],
+			CertFile:           info.Config[tlsCertKey],
+			KeyFile:            info.Config[tlsKeyKey],
+			InsecureSkipVerify: skipVerify,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", driverName, err)
+		}
+	}
+
+	headers, err := parseHeaders(info.Config[headersKey])
+	if err != nil {
+		return nil, err
+	}
+
+	timeout, err := parseDuration(info.Config, timeoutKey, defaultTimeout)
+	if err != nil {
+		return nil, err
+	}
+	batchTimeout, err := parseDuration(info.Config, batchTimeoutKey, defaultBatchTimeout)
+	if err != nil {
+		return nil, err
+	}
+	batchSize, err := parseInt(info.Config, batchSizeKey, defaultBatchSize, 1)
+	if err != nil {
+		return nil, err
+	}
+	maxRetries, err := parseInt(info.Config, maxRetriesKey, defaultMaxRetries, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	resource, err := resourceAttributes(info)
+	if err != nil {
+		return nil, err
+	}
+
+	var exp exporter
+	switch protocol {
+	case protocolGRPC:
+		exp, err = newGRPCExporter(endpoint, tlsConfig, headers)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to connect to %s: %v", driverName, endpoint, err)
+		}
+	case protocolHTTPProtobuf:
+		exp = newHTTPExporter(endpoint, tlsConfig, headers)
+	}
+
+	l := &otlpLogger{
+		exporter:     exp,
+		resource:     encodeResource(resource),
+		scope:        encodeScope(instrumentationScopeName),
+		timeout:      timeout,
+		batchSize:    batchSize,
+		batchTimeout: batchTimeout,
+		maxRetries:   maxRetries,
+		stream:       make(chan *logRecord, defaultStreamChannelSize),
+		closing:      make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	go l.worker()
+	return l, nil
+}
+
+// resourceAttributes returns the attributes of the resource which produces
+// the logs, the container.
+func resourceAttributes(info logger.Info) ([]attribute, error) {
+	// Allow user to remove the service name by setting tag to empty string
+	serviceName := ""
+	if tagTemplate, ok := info.Config[tagKey]; !ok || tagTemplate != "" {
+		var err error
+		serviceName, err = loggerutils.ParseLogTag(info, "{{.Name}}")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	attrs := []attribute{
+		{"container.id", info.ContainerID},
+		{"container.name", info.Name()},
+		{"container.image.name", info.ImageName()},
+		{"container.image.id", info.ContainerImageID},
+	}
+	if serviceName != "" {
+		attrs = append(attrs, attribute{"service.name", serviceName})
+	}
+
+	labels := make([]string, 0, len(info.ContainerLabels))
+	for k := range info.ContainerLabels {
+		labels = append(labels, k)
+	}
+	sort.Strings(labels)
+	for _, k := range labels {
+		attrs = append(attrs, attribute{containerLabelAttrPrefix + k, info.ContainerLabels[k]})
+	}
+
+	// the environment variables selected with the env and env-regex options
+	extra, err := info.ExtraAttributes(nil)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(extra))
+	for k := range extra {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		attrs = append(attrs, attribute{k, extra[k]})
+	}
+	return attrs, nil
+}
+
+func (l *otlpLogger) Log(msg *logger.Message) error {
+	r := &logRecord{
+		timestamp:    msg.Timestamp.UnixNano(),
+		observedTime: time.Now().UnixNano(),
+		body:         append([]byte(nil), msg.Line...),
+		attributes:   make([]attribute, 0, len(msg.Attrs)+1),
+	}
+	if msg.Source != "" {
+		r.attributes = append(r.attributes, attribute{logIOStreamAttr, msg.Source})
+	}
+	for _, attr := range msg.Attrs {
+		r.attributes = append(r.attributes, attribute{attr.Key, attr.Value})
+	}
+	logger.PutMessage(msg)
+
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	select {
+	case <-l.closing:
+		return fmt.Errorf("%s: driver is closed", driverName)
+	default:
+	}
+	l.stream <- r
+	return nil
+}
+
+func (l *otlpLogger) worker() {
+	defer close(l.done)
+
+	ticker := time.NewTicker(l.batchTimeout)
+	defer ticker.Stop()
+
+	var records []*logRecord
+	for {
+		select {
+		case r, open := <-l.stream:
+			if !open {
+				l.exportRecords(records)
+				return
+			}
+			records = append(records, r)
+			if len(records) >= l.batchSize {
+				l.exportRecords(records)
+				records = records[:0]
+			}
+		case <-ticker.C:
+			if len(records) > 0 {
+				l.exportRecords(records)
+				records = records[:0]
+			}
+		}
+	}
+}
+
+// exportRecords exports a batch of log records, retrying with an exponential
+// backoff on transient errors. The records are dropped if they can't be
+// exported.
+func (l *otlpLogger) exportRecords(records []*logRecord) {
+	if len(records) == 0 {
+		return
+	}
+	req := encodeExportRequest(l.resource, l.scope, records)
+
+	backoff := initialRetryBackoff
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), l.timeout)
+		err := l.exporter.export(ctx, req)
+		cancel()
+		if err == nil {
+			return
+		}
+
+		retryErr, ok := err.(*retryableError)
+		if !ok || attempt >= l.maxRetries {
+			logrus.WithError(err).WithField("module", "logger/otlp").Errorf("Dropping %d log records", len(records))
+			return
+		}
+		delay := backoff
+		if retryErr.delay > 0 {
+			delay = retryErr.delay
+		}
+		logrus.WithError(err).WithField("module", "logger/otlp").Debugf("Error exporting logs, retrying in %v", delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-l.closing:
+			// the container is being stopped, make a last attempt now
+			timer.Stop()
+			attempt = l.maxRetries - 1
+		}
+		if backoff *= 2; backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
+}
+
+func (l *otlpLogger) Close() error {
+	l.lock.Lock()
+	select {
+	case <-l.closing:
+		l.lock.Unlock()
+		<-l.done
+		return nil
+	default:
+	}
+	close(l.closing)
+	close(l.stream)
+	l.lock.Unlock()
+
+	<-l.done
+	return l.exporter.close()
+}
+
+func (l *otlpLogger) Name() string {
+	return driverName
+}
+
+// ValidateLogOpt looks for otlp specific log options.
+func ValidateLogOpt(cfg map[string]string) error {
+	for key := range cfg {
+		switch key {
+		case endpointKey:
+		case protocolKey:
+		case insecureKey:
+		case headersKey:
+		case timeoutKey:
+		case batchSizeKey:
+		case batchTimeoutKey:
+		case maxRetriesKey:
+		case tlsCACertKey:
+		case tlsCertKey:
+		case tlsKeyKey:
+		case tlsInsecureSkipVerifyKey:
+		case envKey:
+		case envRegexKey:
+		case tagKey:
+		default:
+			return fmt.Errorf("unknown log opt '%s' for %s log driver", key, driverName)
+		}
+	}
+
+	protocol := cfg[protocolKey]
+	switch protocol {
+	case "":
+		protocol = protocolGRPC
+	case protocolGRPC, protocolHTTPProtobuf:
+	default:
+		return fmt.Errorf("%s: unsupported protocol %q for %s, supported protocols are %s and %s", driverName, protocol, protocolKey, protocolGRPC, protocolHTTPProtobuf)
+	}
+	if _, _, err := parseEndpoint(cfg[endpointKey], protocol); err != nil {
+		return err
+	}
+	for _, key := range []string{insecureKey, tlsInsecureSkipVerifyKey} {
+		if s, ok := cfg[key]; ok && s != "" {
+			if _, err := strconv.ParseBool(s); err != nil {
+				return fmt.Errorf("%s: invalid value for %s: %v", driverName, key, err)
+			}
+		}
+	}
+	if _, err := parseHeaders(cfg[headersKey]); err != nil {
+		return err
+	}
+	if _, err := parseDuration(cfg, timeoutKey, defaultTimeout); err != nil {
+		return err
+	}
+	if _, err := parseDuration(cfg, batchTimeoutKey, defaultBatchTimeout); err != nil {
+		return err
+	}
+	if _, err := parseInt(cfg, batchSizeKey, defaultBatchSize, 1); err != nil {
+		return err
+	}
+	if _, err := parseInt(cfg, maxRetriesKey, defaultMaxRetries, 0); err != nil {
+		return err
+	}
+	return nil
+}
+
+// parseEndpoint returns the address or URL to connect to, and whether TLS
+// is used by default. gRPC endpoints are in the host:port form, optionally
+// prefixed with the http:// or https:// scheme. HTTP endpoints are URLs, to
+// which the default "/v1/logs" path is added if they have none.
+func parseEndpoint(endpoint, protocol string) (string, bool, error) {
+	if endpoint == "" {
+		return "", false, fmt.Errorf("%s: %s is expected", driverName, endpointKey)
+	}
+
+	if protocol == protocolGRPC {
+		secure := true
+		switch {
+		case strings.HasPrefix(endpoint, "http://"):
+			endpoint, secure = strings.TrimPrefix(endpoint, "http://"), false
+		case strings.HasPrefix(endpoint, "https://"):
+			endpoint = strings.TrimPrefix(endpoint, "https://")
+		}
+		endpoint = strings.TrimSuffix(endpoint, "/")
+		if endpoint == "" || strings.Contains(endpoint, "/") {
+			return "", false, fmt.Errorf("%s: expected format [scheme://]host:port for %s", driverName, endpointKey)
+		}
+		return endpoint, secure, nil
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", false, fmt.Errorf("%s: expected format scheme://host:port[/path] for %s", driverName, endpointKey)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = defaultHTTPPath
+	}
+	return u.String(), u.Scheme == "https", nil
+}
+
+// parseHeaders parses headers in the key1=value1,key2=value2 form.
+func parseHeaders(s string) (map[string]string, error) {
+	headers := make(map[string]string)
+	if s == "" {
+		return headers, nil
+	}
+	for _, h := range strings.Split(s, ",") {
+		kv := strings.SplitN(h, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, fmt.Errorf("%s: expected format key1=value1,key2=value2 for %s", driverName, headersKey)
+		}
+		headers[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return headers, nil
+}
+
+func parseDuration(cfg map[string]string, key string, defaultValue time.Duration) (time.Duration, error) {
+	s, ok := cfg[key]
+	if !ok {
+		return defaultValue, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid value for %s: %v", driverName, key, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%s: %s must be positive", driverName, key)
+	}
+	return d, nil
+}
+
+func parseInt(cfg map[string]string, key string, defaultValue, min int) (int, error) {
+	s, ok := cfg[key]
+	if !ok {
+		return defaultValue, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid value for %s: %v", driverName, key, err)
+	}
+	if v < min {
+		return 0, fmt.Errorf("%s: %s must be at least %d", driverName, key, min)
+	}
+	return v, nil
+}
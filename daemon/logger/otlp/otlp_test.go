@@ -0,0 +1,333 @@
+package otlp // import "github.com/docker/docker/daemon/logger/otlp"
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/daemon/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+// decodedRequest is the content of an ExportLogsServiceRequest received by
+// a collector stub.
+type decodedRequest struct {
+	resource map[string]string
+	scope    string
+	records  []decodedRecord
+}
+
+type decodedRecord struct {
+	timestamp  int64
+	body       string
+	attributes map[string]string
+}
+
+func decodeAttribute(b []byte, attrs map[string]string) error {
+	var key, value string
+	err := decodeFields(b, func(f protoField) error {
+		switch f.number {
+		case keyValueKeyField:
+			key = string(f.data)
+		case keyValueValueField:
+			return decodeFields(f.data, func(f protoField) error {
+				value = string(f.data)
+				return nil
+			})
+		}
+		return nil
+	})
+	attrs[key] = value
+	return err
+}
+
+func decodeRecord(b []byte) (decodedRecord, error) {
+	r := decodedRecord{attributes: make(map[string]string)}
+	err := decodeFields(b, func(f protoField) error {
+		switch f.number {
+		case logRecordTimeField:
+			r.timestamp = int64(f.value)
+		case logRecordBodyField:
+			return decodeFields(f.data, func(f protoField) error {
+				r.body = string(f.data)
+				return nil
+			})
+		case logRecordAttributesField:
+			return decodeAttribute(f.data, r.attributes)
+		}
+		return nil
+	})
+	return r, err
+}
+
+func decodeRequest(b []byte) (*decodedRequest, error) {
+	req := &decodedRequest{resource: make(map[string]string)}
+	err := decodeFields(b, func(f protoField) error {
+		return decodeFields(f.data, func(f protoField) error {
+			switch f.number {
+			case resourceLogsResourceField:
+				return decodeFields(f.data, func(f protoField) error {
+					return decodeAttribute(f.data, req.resource)
+				})
+			case resourceLogsScopeLogsField:
+				return decodeFields(f.data, func(f protoField) error {
+					switch f.number {
+					case scopeLogsScopeField:
+						return decodeFields(f.data, func(f protoField) error {
+							req.scope = string(f.data)
+							return nil
+						})
+					case scopeLogsLogRecordsField:
+						r, err := decodeRecord(f.data)
+						req.records = append(req.records, r)
+						return err
+					}
+					return nil
+				})
+			}
+			return nil
+		})
+	})
+	return req, err
+}
+
+// collectorStub records the export requests it receives. The first
+// failures requests fail with a transient error.
+type collectorStub struct {
+	mu       sync.Mutex
+	failures int
+	requests []*decodedRequest
+	headers  map[string]string
+	received chan struct{}
+}
+
+func newCollectorStub(failures int) *collectorStub {
+	return &collectorStub{failures: failures, received: make(chan struct{}, 100)}
+}
+
+func (c *collectorStub) export(b []byte, headers map[string]string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.failures > 0 {
+		c.failures--
+		return false, nil
+	}
+	req, err := decodeRequest(b)
+	if err != nil {
+		return false, err
+	}
+	c.requests = append(c.requests, req)
+	c.headers = headers
+	c.received <- struct{}{}
+	return true, nil
+}
+
+func (c *collectorStub) records(t *testing.T, n int) []decodedRecord {
+	var records []decodedRecord
+	for len(records) < n {
+		select {
+		case <-c.received:
+		case <-time.After(10 * time.Second):
+			t.Fatalf("timeout waiting for %d log records, got %d", n, len(records))
+		}
+		c.mu.Lock()
+		records = records[:0]
+		for _, req := range c.requests {
+			records = append(records, req.records...)
+		}
+		c.mu.Unlock()
+	}
+	return records
+}
+
+var logsServiceDesc = grpc.ServiceDesc{
+	ServiceName: "opentelemetry.proto.collector.logs.v1.LogsService",
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "Export",
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+			var req rawMessage
+			if err := dec(&req); err != nil {
+				return nil, err
+			}
+			headers := make(map[string]string)
+			if md, ok := metadata.FromIncomingContext(ctx); ok {
+				for k, v := range md {
+					headers[k] = v[0]
+				}
+			}
+			ok, err := srv.(*collectorStub).export(req.data, headers)
+			if err != nil {
+				return nil, status.Error(codes.InvalidArgument, err.Error())
+			}
+			if !ok {
+				return nil, status.Error(codes.Unavailable, "try again later")
+			}
+			return &rawMessage{}, nil
+		},
+	}},
+}
+
+func newTestInfo(config map[string]string) logger.Info {
+	return logger.Info{
+		Config:             config,
+		ContainerID:        "containeriid",
+		ContainerName:      "/container_name",
+		ContainerImageID:   "sha256:imageid",
+		ContainerImageName: "busybox",
+		ContainerLabels:    map[string]string{"com.example.team": "infra"},
+	}
+}
+
+func logLines(t *testing.T, l logger.Logger, lines ...string) {
+	for _, line := range lines {
+		msg := logger.NewMessage()
+		msg.Line = append(msg.Line, line...)
+		msg.Source = "stdout"
+		msg.Timestamp = time.Now()
+		assert.NilError(t, l.Log(msg))
+	}
+}
+
+func TestGRPC(t *testing.T) {
+	initialRetryBackoff = 10 * time.Millisecond
+	defer func() { initialRetryBackoff = time.Second }()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	collector := newCollectorStub(1)
+	server := grpc.NewServer()
+	server.RegisterService(&logsServiceDesc, collector)
+	go server.Serve(ln)
+	defer server.Stop()
+
+	l, err := New(newTestInfo(map[string]string{
+		endpointKey:     "http://" + ln.Addr().String(),
+		headersKey:      "Authorization=Bearer token",
+		batchSizeKey:    "2",
+		batchTimeoutKey: "10ms",
+	}))
+	assert.NilError(t, err)
+	logLines(t, l, "hello", "world", "bye")
+
+	records := collector.records(t, 3)
+	assert.Check(t, is.Equal(records[0].body, "hello"))
+	assert.Check(t, is.Equal(records[2].body, "bye"))
+	assert.Check(t, is.Equal(records[0].attributes[logIOStreamAttr], "stdout"))
+	assert.Check(t, records[0].timestamp > 0)
+
+	req := collector.requests[0]
+	assert.Check(t, is.Equal(req.scope, instrumentationScopeName))
+	assert.Check(t, is.Equal(req.resource["container.id"], "containeriid"))
+	assert.Check(t, is.Equal(req.resource["container.name"], "container_name"))
+	assert.Check(t, is.Equal(req.resource["container.image.name"], "busybox"))
+	assert.Check(t, is.Equal(req.resource["service.name"], "container_name"))
+	assert.Check(t, is.Equal(req.resource["container.label.com.example.team"], "infra"))
+	assert.Check(t, is.Equal(collector.headers["authorization"], "Bearer token"))
+
+	assert.NilError(t, l.Close())
+}
+
+func TestHTTP(t *testing.T) {
+	initialRetryBackoff = 10 * time.Millisecond
+	defer func() { initialRetryBackoff = time.Second }()
+
+	collector := newCollectorStub(1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != defaultHTTPPath || r.Header.Get("Content-Type") != "application/x-protobuf" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		ok, err := collector.export(b, map[string]string{"x-tenant": r.Header.Get("X-Tenant")})
+		switch {
+		case err != nil:
+			w.WriteHeader(http.StatusBadRequest)
+		case !ok:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	l, err := New(newTestInfo(map[string]string{
+		endpointKey:  server.URL,
+		protocolKey:  protocolHTTPProtobuf,
+		headersKey:   "X-Tenant=moby",
+		batchSizeKey: "100",
+	}))
+	assert.NilError(t, err)
+	logLines(t, l, "hello", "\xff\xfe")
+	// the remaining records are exported when the logger is closed
+	assert.NilError(t, l.Close())
+
+	records := collector.records(t, 2)
+	assert.Check(t, is.Equal(records[0].body, "hello"))
+	assert.Check(t, is.Equal(records[1].body, "\xff\xfe"))
+	assert.Check(t, is.Equal(collector.headers["x-tenant"], "moby"))
+
+	assert.Check(t, l.Log(logger.NewMessage()) != nil)
+}
+
+func TestExportResponse(t *testing.T) {
+	assert.Check(t, checkPartialSuccess(nil))
+
+	partial := appendVarint(appendTag(nil, partialSuccessRejectedField, wireVarint), 3)
+	partial = appendString(partial, partialSuccessMessageField, "too old")
+	resp := appendBytes(nil, responsePartialSuccessField, partial)
+	rejected, message, err := decodeExportResponse(resp)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(rejected, int64(3)))
+	assert.Check(t, is.Equal(message, "too old"))
+	assert.Check(t, is.ErrorContains(checkPartialSuccess(resp), "3 log records rejected"))
+}
+
+func TestValidateLogOpt(t *testing.T) {
+	assert.Check(t, ValidateLogOpt(map[string]string{endpointKey: "collector:4317", tagKey: "{{.ID}}"}))
+	assert.Check(t, ValidateLogOpt(map[string]string{endpointKey: "https://collector:4318", protocolKey: protocolHTTPProtobuf}))
+
+	for _, cfg := range []map[string]string{
+		{},
+		{endpointKey: "collector:4317", "otlp-foo": "bar"},
+		{endpointKey: "collector:4317", protocolKey: "http/json"},
+		{endpointKey: "collector:4318", protocolKey: protocolHTTPProtobuf},
+		{endpointKey: "http://collector:4317/v1/logs"},
+		{endpointKey: "collector:4317", headersKey: "novalue"},
+		{endpointKey: "collector:4317", timeoutKey: "soon"},
+		{endpointKey: "collector:4317", batchSizeKey: "0"},
+		{endpointKey: "collector:4317", insecureKey: "maybe"},
+	} {
+		assert.Check(t, ValidateLogOpt(cfg) != nil, "%v", cfg)
+	}
+}
+
+func TestParseEndpoint(t *testing.T) {
+	for _, tc := range []struct {
+		endpoint, protocol, expected string
+		secure                       bool
+	}{
+		{"collector:4317", protocolGRPC, "collector:4317", true},
+		{"http://collector:4317", protocolGRPC, "collector:4317", false},
+		{"https://collector:4317/", protocolGRPC, "collector:4317", true},
+		{"http://collector:4318", protocolHTTPProtobuf, "http://collector:4318/v1/logs", false},
+		{"https://collector:4318/otlp/v1/logs", protocolHTTPProtobuf, "https://collector:4318/otlp/v1/logs", true},
+	} {
+		endpoint, secure, err := parseEndpoint(tc.endpoint, tc.protocol)
+		assert.Check(t, err)
+		assert.Check(t, is.Equal(endpoint, tc.expected))
+		assert.Check(t, is.Equal(secure, tc.secure), tc.endpoint)
+	}
+}
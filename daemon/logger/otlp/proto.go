@@ -0,0 +1,241 @@
+package otlp // import "github.com/docker/docker/daemon/logger/otlp"
+
+import (
+	"encoding/binary"
+	"errors"
+	"unicode/utf8"
+)
+
+// This file implements the protobuf encoding of the subset of the OTLP logs
+// data model used by the driver (opentelemetry/proto/logs/v1/logs.proto and
+// opentelemetry/proto/collector/logs/v1/logs_service.proto), which is small
+// enough not to require the generated code.
+
+// protobuf wire types
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// Field numbers of the OTLP messages.
+const (
+	// ExportLogsServiceRequest
+	requestResourceLogsField = 1
+
+	// ResourceLogs
+	resourceLogsResourceField  = 1
+	resourceLogsScopeLogsField = 2
+
+	// Resource
+	resourceAttributesField = 1
+
+	// ScopeLogs
+	scopeLogsScopeField      = 1
+	scopeLogsLogRecordsField = 2
+
+	// InstrumentationScope
+	scopeNameField = 1
+
+	// LogRecord
+	logRecordTimeField         = 1
+	logRecordBodyField         = 5
+	logRecordAttributesField   = 6
+	logRecordObservedTimeField = 11
+
+	// KeyValue
+	keyValueKeyField   = 1
+	keyValueValueField = 2
+
+	// AnyValue
+	anyValueStringField = 1
+	anyValueBytesField  = 7
+
+	// ExportLogsServiceResponse
+	responsePartialSuccessField = 1
+
+	// ExportLogsPartialSuccess
+	partialSuccessRejectedField = 1
+	partialSuccessMessageField  = 2
+)
+
+var errInvalidProto = errors.New("invalid protobuf message")
+
+// attribute is a string attribute of a resource or a log record.
+type attribute struct {
+	key   string
+	value string
+}
+
+// logRecord is a log record waiting to be exported.
+type logRecord struct {
+	timestamp    int64
+	observedTime int64
+	body         []byte
+	attributes   []attribute
+}
+
+func appendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+func appendTag(b []byte, field, wireType int) []byte {
+	return appendVarint(b, uint64(field)<<3|uint64(wireType))
+}
+
+func appendBytes(b []byte, field int, v []byte) []byte {
+	b = appendTag(b, field, wireBytes)
+	b = appendVarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+func appendString(b []byte, field int, v string) []byte {
+	b = appendTag(b, field, wireBytes)
+	b = appendVarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+func appendFixed64(b []byte, field int, v uint64) []byte {
+	b = appendTag(b, field, wireFixed64)
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func appendAttribute(b []byte, field int, attr attribute) []byte {
+	value := appendString(nil, anyValueStringField, attr.value)
+	kv := appendString(nil, keyValueKeyField, attr.key)
+	kv = appendBytes(kv, keyValueValueField, value)
+	return appendBytes(b, field, kv)
+}
+
+// encodeResource encodes a Resource with the given attributes.
+func encodeResource(attrs []attribute) []byte {
+	var b []byte
+	for _, attr := range attrs {
+		b = appendAttribute(b, resourceAttributesField, attr)
+	}
+	return b
+}
+
+// encodeScope encodes an InstrumentationScope with the given name.
+func encodeScope(name string) []byte {
+	return appendString(nil, scopeNameField, name)
+}
+
+func appendLogRecord(b []byte, field int, r *logRecord) []byte {
+	rec := appendFixed64(nil, logRecordTimeField, uint64(r.timestamp))
+	// the body is a string, unless the line is not valid UTF-8, which
+	// protobuf strings must be
+	var body []byte
+	if utf8.Valid(r.body) {
+		body = appendBytes(nil, anyValueStringField, r.body)
+	} else {
+		body = appendBytes(nil, anyValueBytesField, r.body)
+	}
+	rec = appendBytes(rec, logRecordBodyField, body)
+	for _, attr := range r.attributes {
+		rec = appendAttribute(rec, logRecordAttributesField, attr)
+	}
+	rec = appendFixed64(rec, logRecordObservedTimeField, uint64(r.observedTime))
+	return appendBytes(b, field, rec)
+}
+
+// encodeExportRequest encodes an ExportLogsServiceRequest with the log
+// records of a single resource and instrumentation scope.
+func encodeExportRequest(resource, scope []byte, records []*logRecord) []byte {
+	scopeLogs := appendBytes(nil, scopeLogsScopeField, scope)
+	for _, r := range records {
+		scopeLogs = appendLogRecord(scopeLogs, scopeLogsLogRecordsField, r)
+	}
+	resourceLogs := appendBytes(nil, resourceLogsResourceField, resource)
+	resourceLogs = appendBytes(resourceLogs, resourceLogsScopeLogsField, scopeLogs)
+	return appendBytes(nil, requestResourceLogsField, resourceLogs)
+}
+
+// protoField is a decoded protobuf field. value is set for varint and fixed
+// fields, and data for length-delimited fields.
+type protoField struct {
+	number   int
+	wireType int
+	value    uint64
+	data     []byte
+}
+
+func consumeVarint(b []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < len(b) && i < 10; i++ {
+		v |= uint64(b[i]&0x7f) << (7 * uint(i))
+		if b[i] < 0x80 {
+			return v, i + 1
+		}
+	}
+	return 0, 0
+}
+
+// decodeFields calls fn for each field of a protobuf message.
+func decodeFields(b []byte, fn func(f protoField) error) error {
+	for len(b) > 0 {
+		tag, n := consumeVarint(b)
+		if n == 0 {
+			return errInvalidProto
+		}
+		b = b[n:]
+		f := protoField{number: int(tag >> 3), wireType: int(tag & 7)}
+		switch f.wireType {
+		case wireVarint:
+			if f.value, n = consumeVarint(b); n == 0 {
+				return errInvalidProto
+			}
+		case wireFixed64:
+			if n = 8; len(b) < n {
+				return errInvalidProto
+			}
+			f.value = binary.LittleEndian.Uint64(b)
+		case wireFixed32:
+			if n = 4; len(b) < n {
+				return errInvalidProto
+			}
+			f.value = uint64(binary.LittleEndian.Uint32(b))
+		case wireBytes:
+			l, m := consumeVarint(b)
+			if m == 0 || uint64(len(b)-m) < l {
+				return errInvalidProto
+			}
+			f.data = b[m : m+int(l)]
+			n = m + int(l)
+		default:
+			return errInvalidProto
+		}
+		b = b[n:]
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeExportResponse decodes an ExportLogsServiceResponse, and returns the
+// number of log records rejected by the collector and the reason.
+func decodeExportResponse(b []byte) (rejected int64, message string, err error) {
+	err = decodeFields(b, func(f protoField) error {
+		if f.number != responsePartialSuccessField || f.wireType != wireBytes {
+			return nil
+		}
+		return decodeFields(f.data, func(f protoField) error {
+			switch {
+			case f.number == partialSuccessRejectedField && f.wireType == wireVarint:
+				rejected = int64(f.value)
+			case f.number == partialSuccessMessageField && f.wireType == wireBytes:
+				message = string(f.data)
+			}
+			return nil
+		})
+	})
+	return rejected, message, err
+}
@@ -12,6 +12,87 @@ const (
 	defaultRingMaxSize = 1e6 // 1MB
 )
 
+// BufferStats holds the state of the buffer of a non-blocking logger.
+type BufferStats struct {
+	Enqueued     uint64 // number of messages added to the buffer
+	Dropped      uint64 // number of messages dropped because the buffer was full
+	DroppedBytes uint64 // size of the dropped messages
+	Messages     int    // number of messages in the buffer
+	Size         int64  // size of the messages in the buffer
+	MaxSize      int64  // size of the buffer
+}
+
+func (s *BufferStats) add(o BufferStats) {
+	s.Enqueued += o.Enqueued
+	s.Dropped += o.Dropped
+	s.DroppedBytes += o.DroppedBytes
+	s.Messages += o.Messages
+	s.Size += o.Size
+	s.MaxSize += o.MaxSize
+}
+
+// BufferStatsReporter is implemented by the loggers which buffer messages,
+// and by the loggers wrapping them.
+type BufferStatsReporter interface {
+	// BufferStats returns the state of the buffers of the logger. It is
+	// zero if the logger has no buffer.
+	BufferStats() BufferStats
+}
+
+// BufferEventFunc is called when a non-blocking logger starts dropping
+// messages because its buffer is full, and when its buffer is empty again
+// after dropping messages. driver is the name of the logger of the buffer.
+type BufferEventFunc func(info Info, driver string, dropping bool, stats BufferStats)
+
+var (
+	bufferEventMu   sync.Mutex
+	bufferEventFunc BufferEventFunc
+)
+
+// RegisterBufferEventFunc sets the function called when non-blocking loggers
+// start and stop dropping messages.
+func RegisterBufferEventFunc(fn BufferEventFunc) {
+	bufferEventMu.Lock()
+	bufferEventFunc = fn
+	bufferEventMu.Unlock()
+}
+
+func getBufferEventFunc() BufferEventFunc {
+	bufferEventMu.Lock()
+	defer bufferEventMu.Unlock()
+	return bufferEventFunc
+}
+
+// ringRegistry keeps track of the open ring loggers, for the metrics.
+type ringRegistry struct {
+	mu    sync.Mutex
+	rings map[*RingLogger]struct{}
+}
+
+var rings = &ringRegistry{rings: make(map[*RingLogger]struct{})}
+
+func (r *ringRegistry) add(l *RingLogger) {
+	r.mu.Lock()
+	r.rings[l] = struct{}{}
+	r.mu.Unlock()
+}
+
+func (r *ringRegistry) remove(l *RingLogger) {
+	r.mu.Lock()
+	delete(r.rings, l)
+	r.mu.Unlock()
+}
+
+func (r *ringRegistry) list() []*RingLogger {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ls := make([]*RingLogger, 0, len(r.rings))
+	for l := range r.rings {
+		ls = append(ls, l)
+	}
+	return ls
+}
+
 // RingLogger is a ring buffer that implements the Logger interface.
 // This is used when lossy logging is OK.
 type RingLogger struct {
@@ -34,12 +115,18 @@ func (r *ringWithReader) ReadLogs(cfg ReadConfig) *LogWatcher {
 	return reader.ReadLogs(cfg)
 }
 
+func (r *ringWithReader) FiltersLogs() bool {
+	return FiltersLogs(r.l.(LogReader))
+}
+
 func newRingLogger(driver Logger, logInfo Info, maxSize int64) *RingLogger {
 	l := &RingLogger{
 		buffer:  newRing(maxSize),
 		l:       driver,
 		logInfo: logInfo,
 	}
+	l.buffer.notify = l.notifyDropping
+	rings.add(l)
 	go l.run()
 	return l
 }
@@ -70,6 +157,35 @@ func (r *RingLogger) Name() string {
 	return r.l.Name()
 }
 
+// BufferStats returns the state of the ring buffer.
+func (r *RingLogger) BufferStats() BufferStats {
+	return r.buffer.Stats()
+}
+
+// LogFiles returns the log files of the underlying logger.
+func (r *RingLogger) LogFiles() []string {
+	if lr, ok := r.l.(LogFilesReporter); ok {
+		return lr.LogFiles()
+	}
+	return nil
+}
+
+func (r *RingLogger) notifyDropping(dropping bool) {
+	stats := r.buffer.Stats()
+	if dropping {
+		logrus.WithField("driver", r.l.Name()).
+			WithField("container", r.logInfo.ContainerID).
+			Debugf("Log buffer is full, dropping messages")
+	} else {
+		logrus.WithField("driver", r.l.Name()).
+			WithField("container", r.logInfo.ContainerID).
+			Debugf("Log buffer is empty after dropping %d messages", stats.Dropped)
+	}
+	if fn := getBufferEventFunc(); fn != nil {
+		fn(r.logInfo, r.l.Name(), dropping, stats)
+	}
+}
+
 func (r *RingLogger) closed() bool {
 	return atomic.LoadInt32(&r.closeFlag) == 1
 }
@@ -80,6 +196,7 @@ func (r *RingLogger) setClosed() {
 
 // Close closes the logger
 func (r *RingLogger) Close() error {
+	rings.remove(r)
 	r.setClosed()
 	r.buffer.Close()
 	// empty out the queue
@@ -134,6 +251,14 @@ type messageRing struct {
 	maxBytes  int64 // max buffer size size
 	queue     []*Message
 	closed    bool
+
+	enqueued     uint64
+	dropped      uint64
+	droppedBytes uint64
+	// dropping is set when messages are dropped, until the queue is empty
+	dropping bool
+	// notify is called, without holding the lock, when dropping changes
+	notify func(dropping bool)
 }
 
 func newRing(maxBytes int64) *messageRing {
@@ -161,11 +286,20 @@ func (r *messageRing) Enqueue(m *Message) error {
 		return errClosed
 	}
 	if mSize+r.sizeBytes > r.maxBytes && len(r.queue) > 0 {
+		r.dropped++
+		r.droppedBytes += uint64(mSize)
+		started := !r.dropping
+		r.dropping = true
 		r.wait.Signal()
 		r.mu.Unlock()
+		PutMessage(m)
+		if started && r.notify != nil {
+			r.notify(true)
+		}
 		return nil
 	}
 
+	r.enqueued++
 	r.queue = append(r.queue, m)
 	r.sizeBytes += mSize
 	r.wait.Signal()
@@ -190,10 +324,31 @@ func (r *messageRing) Dequeue() (*Message, error) {
 	msg := r.queue[0]
 	r.queue = r.queue[1:]
 	r.sizeBytes -= int64(len(msg.Line))
+	stopped := r.dropping && len(r.queue) == 0
+	if stopped {
+		r.dropping = false
+	}
 	r.mu.Unlock()
+	if stopped && r.notify != nil {
+		r.notify(false)
+	}
 	return msg, nil
 }
 
+// Stats returns the counters and the fill level of the buffer.
+func (r *messageRing) Stats() BufferStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return BufferStats{
+		Enqueued:     r.enqueued,
+		Dropped:      r.dropped,
+		DroppedBytes: r.droppedBytes,
+		Messages:     len(r.queue),
+		Size:         r.sizeBytes,
+		MaxSize:      r.maxBytes,
+	}
+}
+
 var errClosed = errors.New("closed")
 
 // Close closes the buffer ensuring no new messages can be added.
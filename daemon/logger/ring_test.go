@@ -91,6 +91,41 @@ func TestRingCap(t *testing.T) {
 	}
 }
 
+func TestRingStats(t *testing.T) {
+	r := newRing(5)
+	var notified []bool
+	r.notify = func(dropping bool) {
+		notified = append(notified, dropping)
+	}
+
+	for i := 0; i < 10; i++ {
+		if err := r.Enqueue(&Message{Line: []byte(strconv.Itoa(i))}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stats := r.Stats()
+	expected := BufferStats{Enqueued: 5, Dropped: 5, DroppedBytes: 5, Messages: 5, Size: 5, MaxSize: 5}
+	if stats != expected {
+		t.Fatalf("expected stats %+v, got %+v", expected, stats)
+	}
+	if len(notified) != 1 || !notified[0] {
+		t.Fatalf("expected a single notification that messages are dropped, got %v", notified)
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, err := r.Dequeue(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stats = r.Stats()
+	if stats.Messages != 0 || stats.Size != 0 || stats.Dropped != 5 {
+		t.Fatalf("unexpected stats after draining the queue: %+v", stats)
+	}
+	if len(notified) != 2 || notified[1] {
+		t.Fatalf("expected a notification that messages are no longer dropped, got %v", notified)
+	}
+}
+
 func TestRingClose(t *testing.T) {
 	r := newRing(1)
 	if err := r.Enqueue(&Message{Line: []byte("hello")}); err != nil {
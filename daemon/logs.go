@@ -11,6 +11,7 @@ import (
 	timetypes "github.com/docker/docker/api/types/time"
 	"github.com/docker/docker/container"
 	"github.com/docker/docker/daemon/logger"
+	"github.com/docker/docker/daemon/logger/loggerutils/cache"
 	"github.com/docker/docker/errdefs"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -88,11 +89,21 @@ func (daemon *Daemon) ContainerLogs(ctx context.Context, containerName string, c
 		until = time.Unix(s, n)
 	}
 
+	filter, err := logger.NewFilter(config.Filters)
+	if err != nil {
+		return nil, false, errdefs.InvalidParameter(err)
+	}
+	if filter != nil && tailLines > 0 && !logger.FiltersLogs(logReader) {
+		// the driver would return the last lines before filtering them
+		return nil, false, errdefs.InvalidParameter(errors.Errorf("the %s logging driver does not support filters with tail", container.HostConfig.LogConfig.Type))
+	}
+
 	readConfig := logger.ReadConfig{
 		Since:  since,
 		Until:  until,
 		Tail:   tailLines,
 		Follow: follow,
+		Filter: filter,
 	}
 
 	logs := logReader.ReadLogs(readConfig)
@@ -142,6 +153,10 @@ func (daemon *Daemon) ContainerLogs(ctx context.Context, containerName string, c
 				if !ok {
 					return
 				}
+				// not all log readers apply the filter themselves
+				if !filter.Match(msg) {
+					continue
+				}
 				m := msg.AsLogMessage() // just a pointer conversion, does not copy data
 
 				// there could be a case where the reader stops accepting
@@ -190,9 +205,35 @@ func (daemon *Daemon) mergeAndVerifyLogConfig(cfg *containertypes.LogConfig) err
 		}
 	}
 
+	cache.MergeDefaultLogConfig(cfg.Config, daemon.defaultLogConfig.Config)
+
 	return logger.ValidateLogOpts(cfg.Type, cfg.Config)
 }
 
+// verifyAdditionalLogConfigs checks the logging destinations of a container
+// in addition to its log config.
+func verifyAdditionalLogConfigs(hostConfig *containertypes.HostConfig) error {
+	if len(hostConfig.AdditionalLogConfigs) == 0 {
+		return nil
+	}
+	if hostConfig.LogConfig.Type == "none" {
+		return errors.New("additional log configs are not supported with the none log driver")
+	}
+	for i := range hostConfig.AdditionalLogConfigs {
+		cfg := &hostConfig.AdditionalLogConfigs[i]
+		if cfg.Type == "" || cfg.Type == "none" {
+			return errors.Errorf("invalid additional log config %d: a log driver is required", i)
+		}
+		if cfg.Config == nil {
+			cfg.Config = make(map[string]string)
+		}
+		if err := logger.ValidateLogOpts(cfg.Type, cfg.Config); err != nil {
+			return errors.Wrapf(err, "invalid additional log config %d", i)
+		}
+	}
+	return nil
+}
+
 func (daemon *Daemon) setupDefaultLogConfig() error {
 	config := daemon.configStore
 	if len(config.LogConfig.Config) > 0 {
@@ -13,3 +13,26 @@ func TestMergeAndVerifyLogConfigNilConfig(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestVerifyAdditionalLogConfigs(t *testing.T) {
+	hostConfig := &containertypes.HostConfig{
+		LogConfig:            containertypes.LogConfig{Type: "json-file"},
+		AdditionalLogConfigs: []containertypes.LogConfig{{Type: "local"}},
+	}
+	if err := verifyAdditionalLogConfigs(hostConfig); err != nil {
+		t.Fatal(err)
+	}
+	if hostConfig.AdditionalLogConfigs[0].Config == nil {
+		t.Fatal("expected the log opts of the additional log config to be initialized")
+	}
+
+	for _, hc := range []*containertypes.HostConfig{
+		{LogConfig: containertypes.LogConfig{Type: "none"}, AdditionalLogConfigs: []containertypes.LogConfig{{Type: "local"}}},
+		{LogConfig: containertypes.LogConfig{Type: "json-file"}, AdditionalLogConfigs: []containertypes.LogConfig{{}}},
+		{LogConfig: containertypes.LogConfig{Type: "json-file"}, AdditionalLogConfigs: []containertypes.LogConfig{{Type: "local", Config: map[string]string{"foo": "bar"}}}},
+	} {
+		if err := verifyAdditionalLogConfigs(hc); err == nil {
+			t.Fatalf("expected an error for %+v", hc.AdditionalLogConfigs)
+		}
+	}
+}
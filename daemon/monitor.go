@@ -42,14 +42,51 @@ func (daemon *Daemon) ProcessEvent(id string, e libcontainerdtypes.EventType, ei
 
 		c.Lock()
 		defer c.Unlock()
+		kill := &container.OOMKill{Time: time.Now().UTC()}
+		c.OOMKillCount++
+		c.LastOOMKill = kill
 		daemon.updateHealthMonitor(c)
 		if err := c.CheckpointTo(daemon.containersReplica); err != nil {
 			return err
 		}
 
-		daemon.LogContainerEvent(c, "oom")
+		// Reading the kernel log may take a while, so the killed process
+		// is looked up, and the event logged, asynchronously. The events of
+		// the container are kept in order by waiting for the previous
+		// lookup here, and for the last one before logging its exit.
+		attributes := map[string]string{
+			"oomKillCount": strconv.Itoa(c.OOMKillCount),
+		}
+		done := make(chan struct{})
+		prev, _ := daemon.oomKills.Load(c.ID)
+		daemon.oomKills.Store(c.ID, done)
+		go func(since time.Time) {
+			defer close(done)
+			if prev != nil {
+				<-prev.(chan struct{})
+			}
+			pid, process, ok := findOOMKill(c.ID, since)
+
+			c.Lock()
+			defer c.Unlock()
+			if ok {
+				attributes["pid"] = strconv.Itoa(pid)
+				attributes["process"] = process
+				if c.LastOOMKill == kill {
+					kill.Pid, kill.Process = pid, process
+					if err := c.CheckpointTo(daemon.containersReplica); err != nil {
+						logrus.WithError(err).WithField("container", c.ID).Warn("failed to checkpoint the OOM kill")
+					}
+				}
+			}
+			daemon.LogContainerEventWithAttributes(c, "oom", attributes)
+		}(c.StartedAt)
 	case libcontainerdtypes.EventExit:
 		if int(ei.Pid) == c.Pid {
+			if done, ok := daemon.oomKills.Load(c.ID); ok {
+				<-done.(chan struct{})
+				daemon.oomKills.Delete(c.ID)
+			}
 			c.Lock()
 			_, _, err := daemon.containerd.DeleteTask(context.Background(), c.ID)
 			if err != nil {
@@ -68,6 +105,7 @@ func (daemon *Daemon) ProcessEvent(id string, e libcontainerdtypes.EventType, ei
 			if err == nil && restart {
 				c.RestartCount++
 				c.SetRestarting(&exitStatus)
+				c.NextRestartAt = c.RestartManager().NextRestart()
 			} else {
 				if ei.Error != nil {
 					c.SetError(ei.Error)
@@ -90,17 +128,43 @@ func (daemon *Daemon) ProcessEvent(id string, e libcontainerdtypes.EventType, ei
 
 			if err == nil && restart {
 				go func() {
-					err := <-wait
-					if err == nil {
-						// daemon.netController is initialized when daemon is restoring containers.
-						// But containerStart will use daemon.netController segment.
-						// So to avoid panic at startup process, here must wait util daemon restore done.
-						daemon.waitForStartupDone()
-						if err = daemon.containerStart(c, "", "", false); err != nil {
-							logrus.Debugf("failed to restart container: %+v", err)
+					for {
+						err := <-wait
+						if err == nil {
+							// daemon.netController is initialized when daemon is restoring containers.
+							// But containerStart will use daemon.netController segment.
+							// So to avoid panic at startup process, here must wait util daemon restore done.
+							daemon.waitForStartupDone()
+							if err = daemon.waitForDependencies(c, nil); err != nil {
+								// Unmet dependencies are a failed start
+								// attempt, which is retried according to
+								// the restart policy, with its backoff.
+								depErr := err
+								logrus.Debugf("failed to restart container: %+v", err)
+								var restart bool
+								c.Lock()
+								restart, wait, err = c.RestartManager().ShouldRestart(ei.ExitCode, daemon.IsShuttingDown() || c.HasBeenManuallyStopped, 0)
+								if err == nil && restart {
+									c.RestartCount++
+									c.NextRestartAt = c.RestartManager().NextRestart()
+									if err := c.CheckpointTo(daemon.containersReplica); err != nil {
+										logrus.WithError(err).Warnf("failed to checkpoint container %s", c.ID)
+									}
+									c.Unlock()
+									continue
+								}
+								c.Unlock()
+								if err == nil {
+									err = depErr
+								}
+							} else if err = daemon.containerStart(c, "", "", false); err != nil {
+								logrus.Debugf("failed to restart container: %+v", err)
+							}
 						}
-					}
-					if err != nil {
+						if err == nil {
+							return
+						}
+
 						c.Lock()
 						c.SetStopped(&exitStatus)
 						daemon.setStateCounter(c)
@@ -110,6 +174,7 @@ func (daemon *Daemon) ProcessEvent(id string, e libcontainerdtypes.EventType, ei
 						if err != restartmanager.ErrRestartCanceled {
 							logrus.Errorf("restartmanger wait error: %+v", err)
 						}
+						return
 					}
 				}()
 			}
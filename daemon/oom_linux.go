@@ -0,0 +1,103 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"bytes"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+var (
+	// Since Linux 4.19, e.g. "oom-kill:constraint=CONSTRAINT_MEMCG,...,task_memcg=/docker/<id>,task=stress,pid=1234,uid=0"
+	oomKillRe = regexp.MustCompile(`^oom-kill:.*task_memcg=([^,]*),task=([^,]*),pid=(\d+)`)
+	// Before Linux 4.19, e.g. "Task in /docker/<id> killed as a result of limit of /docker/<id>"
+	// followed by "Killed process 1234 (stress) total-vm:..."
+	oomTaskInRe = regexp.MustCompile(`^Task in (\S+) killed`)
+	oomKilledRe = regexp.MustCompile(`^Killed process (\d+) \((.*?)\)`)
+)
+
+// findOOMKill looks up the last process of the container killed by the OOM
+// killer in the kernel log, skipping the records logged before since. ok is
+// false if it is not found, e.g. because the daemon can't read the kernel log.
+func findOOMKill(containerID string, since time.Time) (pid int, process string, ok bool) {
+	f, err := os.OpenFile("/dev/kmsg", os.O_RDONLY|unix.O_NONBLOCK, 0)
+	if err != nil {
+		return 0, "", false
+	}
+	defer f.Close()
+
+	// record timestamps are relative to the boot
+	var sinceBoot time.Duration
+	var ts unix.Timespec
+	if err := unix.ClockGettime(unix.CLOCK_MONOTONIC, &ts); err == nil {
+		sinceBoot = since.Sub(time.Now().Add(-time.Duration(ts.Nano())))
+	}
+
+	var messages []string
+	buf := make([]byte, 8192)
+	for {
+		n, err := f.Read(buf)
+		if err != nil {
+			// EAGAIN once all the records were read, EPIPE if records
+			// were overwritten while reading
+			if pe, ok := err.(*os.PathError); ok && pe.Err == unix.EPIPE {
+				continue
+			}
+			break
+		}
+		if t, message, ok := parseKmsgRecord(buf[:n]); ok && t >= sinceBoot {
+			messages = append(messages, message)
+		}
+	}
+	return parseOOMKill(messages, containerID)
+}
+
+// parseKmsgRecord returns the time since boot and the message of a kernel log
+// record, "<prio>,<seq>,<time>,<flags>;<message>".
+func parseKmsgRecord(record []byte) (t time.Duration, message string, ok bool) {
+	i := bytes.IndexByte(record, ';')
+	if i < 0 {
+		return 0, "", false
+	}
+	fields := strings.SplitN(string(record[:i]), ",", 4)
+	if len(fields) < 3 {
+		return 0, "", false
+	}
+	usec, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return 0, "", false
+	}
+	message = strings.SplitN(string(record[i+1:]), "\n", 2)[0]
+	return time.Duration(usec) * time.Microsecond, strings.TrimRight(message, " "), true
+}
+
+// parseOOMKill returns the last process of the container killed by the OOM
+// killer in the kernel messages.
+func parseOOMKill(messages []string, containerID string) (pid int, process string, ok bool) {
+	inContainer := false
+	for _, m := range messages {
+		if match := oomKillRe.FindStringSubmatch(m); match != nil {
+			if strings.Contains(match[1], containerID) {
+				if p, err := strconv.Atoi(match[3]); err == nil {
+					pid, process, ok = p, match[2], true
+				}
+			}
+			continue
+		}
+		if match := oomTaskInRe.FindStringSubmatch(m); match != nil {
+			inContainer = strings.Contains(match[1], containerID)
+			continue
+		}
+		if match := oomKilledRe.FindStringSubmatch(m); match != nil && inContainer {
+			if p, err := strconv.Atoi(match[1]); err == nil {
+				pid, process, ok = p, match[2], true
+			}
+			inContainer = false
+		}
+	}
+	return pid, process, ok
+}
@@ -0,0 +1,52 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestParseOOMKill(t *testing.T) {
+	const id = "2d8c0e7f4a1b"
+	messages := []string{
+		"stress invoked oom-killer: gfp_mask=0x6000c0(GFP_KERNEL), order=0, oom_score_adj=0",
+		"oom-kill:constraint=CONSTRAINT_MEMCG,nodemask=(null),cpuset=" + id + ",mems_allowed=0,oom_memcg=/docker/" + id + ",task_memcg=/docker/" + id + ",task=stress,pid=1234,uid=0",
+		"Memory cgroup out of memory: Killed process 1234 (stress) total-vm:264192kB",
+		"oom-kill:constraint=CONSTRAINT_MEMCG,nodemask=(null),cpuset=other,mems_allowed=0,oom_memcg=/docker/other,task_memcg=/docker/other,task=java,pid=4321,uid=0",
+	}
+	pid, process, ok := parseOOMKill(messages, id)
+	assert.Check(t, ok)
+	assert.Check(t, is.Equal(pid, 1234))
+	assert.Check(t, is.Equal(process, "stress"))
+
+	// kernels before 4.19
+	messages = []string{
+		"Task in /docker/" + id + " killed as a result of limit of /docker/" + id,
+		"memory: usage 102400kB, limit 102400kB, failcnt 42",
+		"Memory cgroup out of memory: Kill process 5678 (python3) score 1000 or sacrifice child",
+		"Killed process 5678 (python3) total-vm:123456kB, anon-rss:102400kB, file-rss:0kB",
+		"Task in /docker/other killed as a result of limit of /docker/other",
+		"Killed process 8765 (java) total-vm:123456kB, anon-rss:102400kB, file-rss:0kB",
+	}
+	pid, process, ok = parseOOMKill(messages, id)
+	assert.Check(t, ok)
+	assert.Check(t, is.Equal(pid, 5678))
+	assert.Check(t, is.Equal(process, "python3"))
+
+	_, _, ok = parseOOMKill(messages, "unknown")
+	assert.Check(t, !ok)
+}
+
+func TestParseKmsgRecord(t *testing.T) {
+	t0, message, ok := parseKmsgRecord([]byte("3,1234,5678901,-;Killed process 1234 (stress) total-vm:264192kB \n SUBSYSTEM=memory\n"))
+	assert.Check(t, ok)
+	assert.Check(t, is.Equal(t0, 5678901*time.Microsecond))
+	assert.Check(t, is.Equal(message, "Killed process 1234 (stress) total-vm:264192kB"))
+
+	_, _, ok = parseKmsgRecord([]byte("3,1234;no time"))
+	assert.Check(t, !ok)
+	_, _, ok = parseKmsgRecord([]byte("no header"))
+	assert.Check(t, !ok)
+}
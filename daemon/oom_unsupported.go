@@ -0,0 +1,9 @@
+// +build !linux
+
+package daemon // import "github.com/docker/docker/daemon"
+
+import "time"
+
+func findOOMKill(containerID string, since time.Time) (pid int, process string, ok bool) {
+	return 0, "", false
+}
@@ -0,0 +1,155 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+)
+
+const unifiedMountpoint = "/sys/fs/cgroup"
+
+// cgroupDirs returns the cgroup directories of the cpu, memory and block I/O
+// controllers of a process. With cgroup v2, the three are the same.
+func cgroupDirs(pid int) (cpu, memory, io string, err error) {
+	paths, err := cgroups.ParseCgroupFile(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return "", "", "", err
+	}
+	if _, ok := paths["memory"]; !ok {
+		if p, ok := paths[""]; ok {
+			dir := filepath.Join(unifiedMountpoint, p)
+			return dir, dir, dir, nil
+		}
+	}
+	dir := func(subsystem string) string {
+		p, ok := paths[subsystem]
+		if !ok {
+			return ""
+		}
+		mnt, err := cgroups.FindCgroupMountpoint("", subsystem)
+		if err != nil {
+			return ""
+		}
+		return filepath.Join(mnt, p)
+	}
+	return dir("cpu"), dir("memory"), dir("blkio"), nil
+}
+
+// setPressureAndOOMStats adds the pressure stall information and the number
+// of OOM kills of the cgroup of the container to its stats. They are left
+// unset if the kernel doesn't provide them.
+func setPressureAndOOMStats(pid int, s *types.StatsJSON) {
+	cpuDir, memoryDir, ioDir, err := cgroupDirs(pid)
+	if err != nil {
+		return
+	}
+
+	var ps types.PressureStats
+	ps.CPU = readPressure(cpuDir, "cpu.pressure")
+	ps.Memory = readPressure(memoryDir, "memory.pressure")
+	ps.IO = readPressure(ioDir, "io.pressure")
+	if ps.CPU != nil || ps.Memory != nil || ps.IO != nil {
+		s.PressureStats = &ps
+	}
+
+	// cgroup v2 reports the OOM kills in memory.events, cgroup v1 in
+	// memory.oom_control.
+	for _, f := range []string{"memory.events", "memory.oom_control"} {
+		if n, ok := readKeyedValue(memoryDir, f, "oom_kill"); ok {
+			s.MemoryStats.OOMKills = n
+			break
+		}
+	}
+}
+
+// readPressure parses a PSI file, which looks like:
+//
+//	some avg10=0.00 avg60=0.00 avg300=0.00 total=0
+//	full avg10=0.00 avg60=0.00 avg300=0.00 total=0
+func readPressure(dir, file string) *types.Pressure {
+	if dir == "" {
+		return nil
+	}
+	f, err := os.Open(filepath.Join(dir, file))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var p types.Pressure
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		v, err := parsePressureValues(fields[1:])
+		if err != nil {
+			return nil
+		}
+		switch fields[0] {
+		case "some":
+			p.Some = v
+		case "full":
+			p.Full = v
+		}
+	}
+	if scanner.Err() != nil || p.Some == nil && p.Full == nil {
+		return nil
+	}
+	return &p
+}
+
+func parsePressureValues(fields []string) (*types.PressureValues, error) {
+	var v types.PressureValues
+	for _, field := range fields {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid pressure field: %q", field)
+		}
+		var err error
+		switch kv[0] {
+		case "avg10":
+			v.Avg10, err = strconv.ParseFloat(kv[1], 64)
+		case "avg60":
+			v.Avg60, err = strconv.ParseFloat(kv[1], 64)
+		case "avg300":
+			v.Avg300, err = strconv.ParseFloat(kv[1], 64)
+		case "total":
+			v.Total, err = strconv.ParseUint(kv[1], 10, 64)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &v, nil
+}
+
+// readKeyedValue returns the value of a key in a flat keyed cgroup file,
+// such as memory.events.
+func readKeyedValue(dir, file, key string) (uint64, bool) {
+	if dir == "" {
+		return 0, false
+	}
+	f, err := os.Open(filepath.Join(dir, file))
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == key {
+			n, err := strconv.ParseUint(fields[1], 10, 64)
+			return n, err == nil
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,37 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestReadPressure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pressure")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "memory.pressure"), []byte("some avg10=1.50 avg60=0.75 avg300=0.10 total=123456\nfull avg10=0.00 avg60=0.20 avg300=0.05 total=4567\n"), 0644)
+	assert.NilError(t, err)
+	err = ioutil.WriteFile(filepath.Join(dir, "cpu.pressure"), []byte("some avg10=invalid avg60=0.00 avg300=0.00 total=0\n"), 0644)
+	assert.NilError(t, err)
+	err = ioutil.WriteFile(filepath.Join(dir, "memory.events"), []byte("low 0\nhigh 0\nmax 12\noom 3\noom_kill 2\n"), 0644)
+	assert.NilError(t, err)
+
+	p := readPressure(dir, "memory.pressure")
+	assert.Check(t, is.DeepEqual(p, &types.Pressure{
+		Some: &types.PressureValues{Avg10: 1.5, Avg60: 0.75, Avg300: 0.1, Total: 123456},
+		Full: &types.PressureValues{Avg60: 0.2, Avg300: 0.05, Total: 4567},
+	}))
+	assert.Check(t, is.Nil(readPressure(dir, "cpu.pressure")))
+	assert.Check(t, is.Nil(readPressure(dir, "io.pressure")))
+
+	n, ok := readKeyedValue(dir, "memory.events", "oom_kill")
+	assert.Check(t, ok)
+	assert.Check(t, is.Equal(n, uint64(2)))
+}
@@ -4,14 +4,18 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"runtime"
+	"strconv"
 	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/backend"
+	timetypes "github.com/docker/docker/api/types/time"
 	"github.com/docker/docker/api/types/versions"
 	"github.com/docker/docker/api/types/versions/v1p20"
 	"github.com/docker/docker/container"
+	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/pkg/ioutils"
 )
 
@@ -26,6 +30,10 @@ func (daemon *Daemon) ContainerStats(ctx context.Context, prefixOrName string, c
 		return err
 	}
 
+	if config.Since != "" || config.Until != "" {
+		return daemon.containerStatsHistory(container, config)
+	}
+
 	// If the container is either not running or restarting and requires no stream, return an empty stats.
 	if (!container.IsRunning() || container.IsRestarting()) && !config.Stream {
 		return json.NewEncoder(config.OutStream).Encode(&types.StatsJSON{
@@ -129,6 +137,51 @@ func (daemon *Daemon) ContainerStats(ctx context.Context, prefixOrName string, c
 	}
 }
 
+// containerStatsHistory writes the stats history of the container between
+// config.Since and config.Until to the stream given in the config object.
+func (daemon *Daemon) containerStatsHistory(container *container.Container, config *backend.ContainerStatsConfig) error {
+	if !daemon.statsCollector.HistoryEnabled() {
+		return errdefs.InvalidParameter(errors.New("stats history is not enabled on the daemon"))
+	}
+
+	var since, until time.Time
+	if config.Since != "" {
+		s, n, err := timetypes.ParseTimestamps(config.Since, 0)
+		if err != nil {
+			return errdefs.InvalidParameter(err)
+		}
+		since = time.Unix(s, n)
+	}
+	if config.Until != "" && config.Until != "0" {
+		s, n, err := timetypes.ParseTimestamps(config.Until, 0)
+		if err != nil {
+			return errdefs.InvalidParameter(err)
+		}
+		until = time.Unix(s, n)
+	}
+	if !until.IsZero() && until.Before(since) {
+		return errdefs.InvalidParameter(errors.New("until must not be before since"))
+	}
+
+	var step time.Duration
+	if config.Step != "" {
+		s, err := strconv.ParseInt(config.Step, 10, 64)
+		if err != nil || s <= 0 {
+			return errdefs.InvalidParameter(fmt.Errorf("invalid step %q, must be a positive number of seconds", config.Step))
+		}
+		step = time.Duration(s) * time.Second
+	}
+
+	points, step := daemon.statsCollector.History(container, since, until, step)
+	history := types.StatsHistory{
+		Name:   container.Name,
+		ID:     container.ID,
+		Step:   int64(step / time.Second),
+		Points: points,
+	}
+	return json.NewEncoder(config.OutStream).Encode(&history)
+}
+
 func (daemon *Daemon) subscribeToContainerStats(c *container.Container) chan interface{} {
 	return daemon.statsCollector.Collect(c)
 }
@@ -0,0 +1,187 @@
+package stats // import "github.com/docker/docker/daemon/stats"
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/container"
+)
+
+// Actions of the events emitted for the resource alerts of the containers.
+const (
+	AlertExceededAction = "threshold-exceeded"
+	AlertClearedAction  = "threshold-cleared"
+)
+
+// AlertEventFunc is called when a resource alert of a container starts or
+// stops firing. action is one of AlertExceededAction and AlertClearedAction.
+type AlertEventFunc func(c *container.Container, action string, attributes map[string]string)
+
+// alertEvaluator evaluates the resource alerts of the containers against
+// their stats.
+type alertEvaluator struct {
+	mu         sync.Mutex
+	emit       AlertEventFunc
+	containers map[string]*containerAlerts
+}
+
+type containerAlerts struct {
+	container *container.Container
+	prev      *types.StatsJSON
+	alerts    []containertypes.ResourceAlert
+	states    []alertState
+}
+
+type alertState struct {
+	exceededSince time.Time // zero if the metric is below the threshold
+	firing        bool
+	value         float64 // last value of the metric
+}
+
+func newAlertEvaluator(emit AlertEventFunc) *alertEvaluator {
+	return &alertEvaluator{
+		emit:       emit,
+		containers: make(map[string]*containerAlerts),
+	}
+}
+
+// evaluate updates the state of the alerts of a container with its last
+// stats, and emits the events of the alerts which started or stopped firing.
+func (e *alertEvaluator) evaluate(c *container.Container, stats *types.StatsJSON) {
+	alerts := c.HostConfig.ResourceAlerts
+	now := stats.Read
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	ca, ok := e.containers[c.ID]
+	if len(alerts) == 0 {
+		if ok {
+			ca.clear(e.emit)
+			delete(e.containers, c.ID)
+		}
+		return
+	}
+	if !ok || !sameAlerts(ca.alerts, alerts) {
+		// The state of the alerts is reset when they are changed, and
+		// the alerts which were firing are cleared.
+		if ok {
+			ca.clear(e.emit)
+		}
+		ca = &containerAlerts{container: c, alerts: alerts, states: make([]alertState, len(alerts))}
+		e.containers[c.ID] = ca
+	}
+
+	for i, alert := range alerts {
+		state := &ca.states[i]
+		value, ok := alertValue(alert.Metric, ca.prev, stats)
+		if !ok {
+			continue
+		}
+		state.value = value
+		if value > alert.Threshold {
+			if state.exceededSince.IsZero() {
+				state.exceededSince = now
+			}
+			if !state.firing && now.Sub(state.exceededSince) >= alert.Duration {
+				state.firing = true
+				e.emit(c, AlertExceededAction, alertAttributes(alert, value))
+			}
+			continue
+		}
+		state.exceededSince = time.Time{}
+		if state.firing {
+			state.firing = false
+			e.emit(c, AlertClearedAction, alertAttributes(alert, value))
+		}
+	}
+	ca.prev = stats
+}
+
+// prune forgets the containers which are not in ids. The alerts which were
+// firing are cleared.
+func (e *alertEvaluator) prune(ids map[string]struct{}) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for id, ca := range e.containers {
+		if _, ok := ids[id]; ok {
+			continue
+		}
+		ca.clear(e.emit)
+		delete(e.containers, id)
+	}
+}
+
+// clear emits the events of the alerts of the container which are firing.
+func (ca *containerAlerts) clear(emit AlertEventFunc) {
+	for i, state := range ca.states {
+		if state.firing {
+			emit(ca.container, AlertClearedAction, alertAttributes(ca.alerts[i], state.value))
+		}
+	}
+}
+
+func sameAlerts(a, b []containertypes.ResourceAlert) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func alertAttributes(alert containertypes.ResourceAlert, value float64) map[string]string {
+	return map[string]string{
+		"alert":     alert.Name,
+		"metric":    alert.Metric,
+		"threshold": strconv.FormatFloat(alert.Threshold, 'f', -1, 64),
+		"value":     strconv.FormatFloat(value, 'f', 2, 64),
+	}
+}
+
+// alertValue returns the value of a metric, in percent. ok is false if the
+// value can't be computed, e.g. because the container has no limit or
+// because the previous stats are needed.
+func alertValue(metric string, prev, stats *types.StatsJSON) (value float64, ok bool) {
+	switch metric {
+	case containertypes.AlertMetricMemory:
+		if stats.MemoryStats.Limit == 0 {
+			return 0, false
+		}
+		// the page cache is reclaimed before the container runs out of memory
+		usage := stats.MemoryStats.Usage
+		if cache := stats.MemoryStats.Stats["cache"]; cache < usage {
+			usage -= cache
+		}
+		return float64(usage) / float64(stats.MemoryStats.Limit) * 100, true
+	case containertypes.AlertMetricCPU:
+		if prev == nil {
+			return 0, false
+		}
+		p, s := newHistorySample(prev), newHistorySample(stats)
+		return cpuPercent(&p, &s)
+	case containertypes.AlertMetricCPUThrottled:
+		if prev == nil {
+			return 0, false
+		}
+		cur, old := stats.CPUStats.ThrottlingData, prev.CPUStats.ThrottlingData
+		if cur.Periods <= old.Periods || cur.ThrottledPeriods < old.ThrottledPeriods {
+			return 0, false
+		}
+		return float64(cur.ThrottledPeriods-old.ThrottledPeriods) / float64(cur.Periods-old.Periods) * 100, true
+	case containertypes.AlertMetricPids:
+		if stats.PidsStats.Limit == 0 {
+			return 0, false
+		}
+		return float64(stats.PidsStats.Current) / float64(stats.PidsStats.Limit) * 100, true
+	}
+	return 0, false
+}
@@ -0,0 +1,69 @@
+package stats // import "github.com/docker/docker/daemon/stats"
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/container"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+type alertEvent struct {
+	Action string
+	Alert  string
+	Value  string
+}
+
+func TestAlertEvaluator(t *testing.T) {
+	var events []alertEvent
+	e := newAlertEvaluator(func(c *container.Container, action string, attributes map[string]string) {
+		events = append(events, alertEvent{Action: action, Alert: attributes["alert"], Value: attributes["value"]})
+	})
+
+	c := &container.Container{ID: "c", HostConfig: &containertypes.HostConfig{
+		ResourceAlerts: []containertypes.ResourceAlert{
+			{Name: "mem", Metric: containertypes.AlertMetricMemory, Threshold: 90, Duration: 20 * time.Second},
+			{Name: "throttled", Metric: containertypes.AlertMetricCPUThrottled, Threshold: 50},
+		},
+	}}
+	start := time.Unix(1500000000, 0)
+	sample := func(i int, mem, periods, throttled uint64) *types.StatsJSON {
+		stats := &types.StatsJSON{}
+		stats.Read = start.Add(time.Duration(i) * 10 * time.Second)
+		stats.MemoryStats.Usage = mem
+		stats.MemoryStats.Limit = 100
+		stats.CPUStats.ThrottlingData.Periods = periods
+		stats.CPUStats.ThrottlingData.ThrottledPeriods = throttled
+		return stats
+	}
+
+	e.evaluate(c, sample(0, 95, 0, 0))
+	e.evaluate(c, sample(1, 95, 10, 6))
+	assert.Check(t, is.DeepEqual(events, []alertEvent{{AlertExceededAction, "throttled", "60.00"}}))
+
+	// the memory must be above the threshold for the duration
+	e.evaluate(c, sample(2, 95, 20, 7))
+	assert.Check(t, is.DeepEqual(events[1:], []alertEvent{
+		{AlertExceededAction, "mem", "95.00"},
+		{AlertClearedAction, "throttled", "10.00"},
+	}))
+
+	// the alerts which are firing are cleared when the container stops
+	e.prune(map[string]struct{}{})
+	assert.Check(t, is.DeepEqual(events[3:], []alertEvent{{AlertClearedAction, "mem", "95.00"}}))
+	assert.Check(t, is.Len(e.containers, 0))
+
+	// changing an alert resets its state
+	e.evaluate(c, sample(3, 95, 30, 7))
+	e.evaluate(c, sample(5, 95, 40, 8))
+	assert.Check(t, is.DeepEqual(events[4:], []alertEvent{{AlertExceededAction, "mem", "95.00"}}))
+	c.HostConfig.ResourceAlerts = []containertypes.ResourceAlert{
+		{Name: "mem", Metric: containertypes.AlertMetricMemory, Threshold: 90, Duration: time.Minute},
+	}
+	e.evaluate(c, sample(6, 95, 50, 9))
+	e.evaluate(c, sample(9, 95, 60, 10))
+	assert.Check(t, is.DeepEqual(events[5:], []alertEvent{{AlertClearedAction, "mem", "95.00"}}))
+}
@@ -19,6 +19,14 @@ type Collector struct {
 	publishers map[*container.Container]*pubsub.Publisher
 	bufReader  *bufio.Reader
 
+	// running is set when the per-container metrics, the stats history or
+	// the resource alerts are enabled, in which case the stats of the
+	// containers it returns are collected even without subscribers.
+	running func() []*container.Container
+	metrics *containerMetrics
+	history *statsHistory
+	alerts  *alertEvaluator
+
 	// The following fields are not set on Windows currently.
 	clockTicksPerSecond uint64
 }
@@ -42,6 +50,52 @@ type supervisor interface {
 	GetContainerStats(container *container.Container) (*types.StatsJSON, error)
 }
 
+// EnableMetrics exports the stats of the containers returned by running as
+// Prometheus metrics. It must be called before Run, at most once.
+func (s *Collector) EnableMetrics(cfg MetricsConfig, running func() []*container.Container) {
+	s.metrics = newContainerMetrics(cfg)
+	s.running = running
+}
+
+// EnableHistory keeps the history of the stats of the containers returned by
+// running. It must be called before Run, at most once.
+func (s *Collector) EnableHistory(cfg HistoryConfig, running func() []*container.Container) error {
+	h, err := newStatsHistory(cfg)
+	if err != nil {
+		return err
+	}
+	s.history = h
+	s.running = running
+	return nil
+}
+
+// EnableAlerts evaluates the resource alerts of the containers and calls emit
+// when they start or stop firing. The stats of the containers returned by
+// running are collected, unless the metrics or the history are enabled, in
+// which case all the running containers are. It must be called before Run,
+// at most once.
+func (s *Collector) EnableAlerts(emit AlertEventFunc, running func() []*container.Container) {
+	s.alerts = newAlertEvaluator(emit)
+	if s.running == nil {
+		s.running = running
+	}
+}
+
+// HistoryEnabled returns whether the collector keeps the stats history.
+func (s *Collector) HistoryEnabled() bool {
+	return s.history != nil
+}
+
+// History returns the stats history of a container between since and until,
+// aggregated in intervals of step, and the step used. A zero until means up
+// to now. The step is rounded up to the resolution of the history.
+func (s *Collector) History(c *container.Container, since, until time.Time, step time.Duration) ([]types.StatsHistoryPoint, time.Duration) {
+	if s.history == nil {
+		return nil, 0
+	}
+	return s.history.query(c.ID, since, until, step)
+}
+
 // Collect registers the container with the collector and adds it to
 // the event loop for collection on the specified interval returning
 // a channel for the subscriber to receive on.
@@ -65,6 +119,9 @@ func (s *Collector) StopCollection(c *container.Container) {
 		delete(s.publishers, c)
 	}
 	s.m.Unlock()
+	if s.history != nil {
+		s.history.remove(c.ID)
+	}
 }
 
 // Unsubscribe removes a specific subscriber from receiving updates for a container's stats.
@@ -105,6 +162,28 @@ func (s *Collector) Run() {
 			pairs = append(pairs, publishersPair{container, publisher})
 		}
 		s.m.Unlock()
+
+		if s.running != nil {
+			// Containers without subscribers are collected for the metrics
+			// the history and the alerts only, with a nil publisher.
+			subscribed := make(map[*container.Container]bool, len(pairs))
+			for _, pair := range pairs {
+				subscribed[pair.container] = true
+			}
+			running := make(map[string]struct{})
+			for _, c := range s.running() {
+				running[c.ID] = struct{}{}
+				if !subscribed[c] {
+					pairs = append(pairs, publishersPair{container: c})
+				}
+			}
+			if s.metrics != nil {
+				s.metrics.prune(running)
+			}
+			if s.alerts != nil {
+				s.alerts.prune(running)
+			}
+		}
 		if len(pairs) == 0 {
 			continue
 		}
@@ -132,21 +211,36 @@ func (s *Collector) Run() {
 				stats.CPUStats.SystemUsage = systemUsage
 				stats.CPUStats.OnlineCPUs = onlineCPUs
 
-				pair.publisher.Publish(*stats)
+				if s.metrics != nil {
+					s.metrics.update(pair.container, *stats)
+				}
+				if s.history != nil {
+					s.history.add(pair.container.ID, stats)
+				}
+				if s.alerts != nil {
+					s.alerts.evaluate(pair.container, stats)
+				}
+				if pair.publisher != nil {
+					pair.publisher.Publish(*stats)
+				}
 
 			case notRunningErr, notFoundErr:
 				// publish empty stats containing only name and ID if not running or not found
-				pair.publisher.Publish(types.StatsJSON{
-					Name: pair.container.Name,
-					ID:   pair.container.ID,
-				})
+				if pair.publisher != nil {
+					pair.publisher.Publish(types.StatsJSON{
+						Name: pair.container.Name,
+						ID:   pair.container.ID,
+					})
+				}
 
 			default:
 				logrus.Errorf("collecting stats for %s: %v", pair.container.ID, err)
-				pair.publisher.Publish(types.StatsJSON{
-					Name: pair.container.Name,
-					ID:   pair.container.ID,
-				})
+				if pair.publisher != nil {
+					pair.publisher.Publish(types.StatsJSON{
+						Name: pair.container.Name,
+						ID:   pair.container.ID,
+					})
+				}
 			}
 		}
 	}
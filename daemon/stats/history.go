@@ -0,0 +1,418 @@
+package stats // import "github.com/docker/docker/daemon/stats"
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultHistoryResolution is the default interval between two samples of
+// the stats history.
+const DefaultHistoryResolution = 10 * time.Second
+
+// HistoryConfig configures the stats history kept for each container.
+type HistoryConfig struct {
+	// Retention is how long the samples are kept.
+	Retention time.Duration
+	// Resolution is the interval between two samples.
+	Resolution time.Duration
+	// Dir is the directory where the history is persisted, one file per
+	// container. The history is only kept in memory if it is empty.
+	Dir string
+}
+
+// historySample is a sample of the stats history. The counters are
+// cumulative since the container started.
+type historySample struct {
+	Time        time.Time `json:"t"`
+	CPUUsage    uint64    `json:"cpu"`
+	SystemUsage uint64    `json:"sys"`
+	OnlineCPUs  uint32    `json:"ncpu"`
+	MemoryUsage uint64    `json:"mem"`
+	MemoryLimit uint64    `json:"memlimit"`
+	Pids        uint64    `json:"pids"`
+	BlkioRead   uint64    `json:"rd"`
+	BlkioWrite  uint64    `json:"wr"`
+	NetworkRx   uint64    `json:"rx"`
+	NetworkTx   uint64    `json:"tx"`
+}
+
+func newHistorySample(stats *types.StatsJSON) historySample {
+	s := historySample{
+		Time:        stats.Read,
+		CPUUsage:    stats.CPUStats.CPUUsage.TotalUsage,
+		SystemUsage: stats.CPUStats.SystemUsage,
+		OnlineCPUs:  stats.CPUStats.OnlineCPUs,
+		MemoryUsage: stats.MemoryStats.Usage,
+		MemoryLimit: stats.MemoryStats.Limit,
+		Pids:        stats.PidsStats.Current,
+	}
+	if s.Time.IsZero() {
+		s.Time = time.Now()
+	}
+	if s.Pids == 0 {
+		s.Pids = uint64(stats.NumProcs)
+	}
+	s.BlkioRead, s.BlkioWrite = blkioBytes(stats)
+	for _, n := range stats.Networks {
+		s.NetworkRx += n.RxBytes
+		s.NetworkTx += n.TxBytes
+	}
+	return s
+}
+
+// containerHistory is a ring buffer of the samples of a container. The
+// buffer grows up to size samples, and is then reused.
+type containerHistory struct {
+	samples []historySample
+	start   int // index of the oldest sample
+	size    int // maximum number of samples
+
+	// file is the number of samples in the history file, which is
+	// rewritten once it holds twice as many samples as the buffer.
+	file int
+}
+
+func (h *containerHistory) add(s historySample) {
+	if len(h.samples) < h.size {
+		h.samples = append(h.samples, s)
+		return
+	}
+	h.samples[h.start] = s
+	h.start = (h.start + 1) % len(h.samples)
+}
+
+func (h *containerHistory) last() (historySample, bool) {
+	if len(h.samples) == 0 {
+		return historySample{}, false
+	}
+	return h.samples[(h.start+len(h.samples)-1)%len(h.samples)], true
+}
+
+// list returns the samples, oldest first.
+func (h *containerHistory) list() []historySample {
+	ls := make([]historySample, 0, len(h.samples))
+	for i := range h.samples {
+		ls = append(ls, h.samples[(h.start+i)%len(h.samples)])
+	}
+	return ls
+}
+
+// statsHistory keeps the stats history of the containers.
+type statsHistory struct {
+	mu         sync.Mutex
+	containers map[string]*containerHistory
+	retention  time.Duration
+	resolution time.Duration
+	size       int
+	dir        string
+}
+
+func newStatsHistory(cfg HistoryConfig) (*statsHistory, error) {
+	if cfg.Resolution <= 0 {
+		cfg.Resolution = DefaultHistoryResolution
+	}
+	if cfg.Dir != "" {
+		if err := os.MkdirAll(cfg.Dir, 0700); err != nil {
+			return nil, err
+		}
+	}
+	size := int(cfg.Retention / cfg.Resolution)
+	if size < 1 {
+		size = 1
+	}
+	return &statsHistory{
+		containers: make(map[string]*containerHistory),
+		retention:  cfg.Retention,
+		resolution: cfg.Resolution,
+		size:       size,
+		dir:        cfg.Dir,
+	}, nil
+}
+
+// add records the stats of a container, unless the last sample of the
+// container is more recent than the resolution of the history.
+func (sh *statsHistory) add(id string, stats *types.StatsJSON) {
+	s := newHistorySample(stats)
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	h := sh.get(id)
+	if last, ok := h.last(); ok && s.Time.Sub(last.Time) < sh.resolution {
+		return
+	}
+	h.add(s)
+	if sh.dir != "" {
+		sh.persist(id, h, s)
+	}
+}
+
+// get returns the history of a container, loading it from disk if needed.
+// It must be called with the lock held.
+func (sh *statsHistory) get(id string) *containerHistory {
+	h, ok := sh.containers[id]
+	if ok {
+		return h
+	}
+	h = &containerHistory{size: sh.size}
+	sh.containers[id] = h
+	h.file = sh.load(id, h.add)
+	return h
+}
+
+// load calls fn for the samples of the history file of a container which
+// are within the retention, oldest first, and returns the number of samples
+// in the file.
+func (sh *statsHistory) load(id string, fn func(historySample)) int {
+	if sh.dir == "" {
+		return 0
+	}
+	f, err := os.Open(sh.path(id))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logrus.WithError(err).WithField("container", id).Warn("failed to load stats history")
+		}
+		return 0
+	}
+	defer f.Close()
+	var n int
+	cutoff := time.Now().Add(-sh.retention)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var s historySample
+		if err := json.Unmarshal(scanner.Bytes(), &s); err != nil {
+			// ignore the last sample if it was partially written
+			continue
+		}
+		n++
+		if s.Time.After(cutoff) {
+			fn(s)
+		}
+	}
+	return n
+}
+
+func (sh *statsHistory) path(id string) string {
+	return filepath.Join(sh.dir, id+".json")
+}
+
+// persist appends a sample to the history file of a container. The file is
+// rewritten with the samples in memory when it grows too large.
+func (sh *statsHistory) persist(id string, h *containerHistory, s historySample) {
+	var err error
+	if h.file >= 2*sh.size {
+		err = sh.rewrite(id, h)
+	} else {
+		err = sh.append(id, s)
+		h.file++
+	}
+	if err != nil {
+		logrus.WithError(err).WithField("container", id).Warn("failed to persist stats history")
+	}
+}
+
+func (sh *statsHistory) append(id string, s historySample) error {
+	f, err := os.OpenFile(sh.path(id), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(s); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (sh *statsHistory) rewrite(id string, h *containerHistory) error {
+	tmp := sh.path(id) + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	samples := h.list()
+	for _, s := range samples {
+		if err := enc.Encode(s); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, sh.path(id)); err != nil {
+		return err
+	}
+	h.file = len(samples)
+	return nil
+}
+
+// remove deletes the history of a container.
+func (sh *statsHistory) remove(id string) {
+	sh.mu.Lock()
+	delete(sh.containers, id)
+	sh.mu.Unlock()
+	if sh.dir != "" {
+		if err := os.Remove(sh.path(id)); err != nil && !os.IsNotExist(err) {
+			logrus.WithError(err).WithField("container", id).Warn("failed to remove stats history")
+		}
+	}
+}
+
+// query aggregates the samples of a container between since and until in
+// intervals of step, and returns the step used. Intervals without samples are
+// omitted.
+func (sh *statsHistory) query(id string, since, until time.Time, step time.Duration) ([]types.StatsHistoryPoint, time.Duration) {
+	// The history of a container which is not tracked, for example because
+	// it is stopped, is read from its file without keeping it in memory.
+	sh.mu.Lock()
+	h, ok := sh.containers[id]
+	var samples []historySample
+	if ok {
+		samples = h.list()
+	}
+	sh.mu.Unlock()
+	if !ok {
+		sh.load(id, func(s historySample) {
+			samples = append(samples, s)
+		})
+		if len(samples) > sh.size {
+			samples = samples[len(samples)-sh.size:]
+		}
+	}
+
+	if step < sh.resolution {
+		step = sh.resolution
+	}
+	if since.IsZero() && len(samples) > 0 {
+		since = samples[0].Time
+	}
+	points := []types.StatsHistoryPoint{}
+	var (
+		prev  *historySample
+		point *types.StatsHistoryPoint
+		acc   aggregator
+	)
+	for i := range samples {
+		s := &samples[i]
+		if s.Time.Before(since) {
+			prev = s
+			continue
+		}
+		if !until.IsZero() && s.Time.After(until) {
+			break
+		}
+		start := since.Add(s.Time.Sub(since) / step * step)
+		if point == nil || !point.Start.Equal(start) {
+			if point != nil {
+				acc.finish(point)
+				points = append(points, *point)
+			}
+			point = &types.StatsHistoryPoint{Start: start, End: start.Add(step)}
+			acc = aggregator{}
+		}
+		acc.add(point, prev, s)
+		prev = s
+	}
+	if point != nil {
+		acc.finish(point)
+		points = append(points, *point)
+	}
+	return points, step
+}
+
+// aggregator accumulates the samples of an interval.
+type aggregator struct {
+	cpu, mem, pids   float64
+	hasCPU           bool
+	cpuN             int
+	minCPU, maxCPU   float64
+	minMem, maxMem   float64
+	minPids, maxPids float64
+}
+
+func (a *aggregator) add(p *types.StatsHistoryPoint, prev, s *historySample) {
+	mem, pids := float64(s.MemoryUsage), float64(s.Pids)
+	if p.Samples == 0 || mem < a.minMem {
+		a.minMem = mem
+	}
+	if p.Samples == 0 || mem > a.maxMem {
+		a.maxMem = mem
+	}
+	if p.Samples == 0 || pids < a.minPids {
+		a.minPids = pids
+	}
+	if p.Samples == 0 || pids > a.maxPids {
+		a.maxPids = pids
+	}
+	a.mem += mem
+	a.pids += pids
+	p.Samples++
+	p.MemoryLimit = s.MemoryLimit
+
+	if prev == nil {
+		return
+	}
+	if cpu, ok := cpuPercent(prev, s); ok {
+		if !a.hasCPU || cpu < a.minCPU {
+			a.minCPU = cpu
+		}
+		if !a.hasCPU || cpu > a.maxCPU {
+			a.maxCPU = cpu
+		}
+		a.hasCPU = true
+		a.cpu += cpu
+		a.cpuN++
+	}
+	p.BlkioReadBytes += counterDelta(prev.BlkioRead, s.BlkioRead)
+	p.BlkioWriteBytes += counterDelta(prev.BlkioWrite, s.BlkioWrite)
+	p.NetworkRxBytes += counterDelta(prev.NetworkRx, s.NetworkRx)
+	p.NetworkTxBytes += counterDelta(prev.NetworkTx, s.NetworkTx)
+}
+
+func (a *aggregator) finish(p *types.StatsHistoryPoint) {
+	n := float64(p.Samples)
+	p.MemoryUsage = types.StatsAggregate{Min: a.minMem, Max: a.maxMem, Avg: a.mem / n}
+	p.Pids = types.StatsAggregate{Min: a.minPids, Max: a.maxPids, Avg: a.pids / n}
+	if a.cpuN > 0 {
+		p.CPUPercent = types.StatsAggregate{Min: a.minCPU, Max: a.maxCPU, Avg: a.cpu / float64(a.cpuN)}
+	}
+}
+
+// cpuPercent returns the CPU usage between two samples, in percent of one
+// CPU. The system usage is only available on Linux, the elapsed time is used
+// otherwise.
+func cpuPercent(prev, s *historySample) (float64, bool) {
+	if s.CPUUsage < prev.CPUUsage {
+		// the container was restarted
+		return 0, false
+	}
+	cpuDelta := float64(s.CPUUsage - prev.CPUUsage)
+	if s.SystemUsage > prev.SystemUsage && s.OnlineCPUs > 0 {
+		return cpuDelta / float64(s.SystemUsage-prev.SystemUsage) * float64(s.OnlineCPUs) * 100, true
+	}
+	if elapsed := s.Time.Sub(prev.Time); elapsed > 0 {
+		return cpuDelta / float64(elapsed.Nanoseconds()) * 100, true
+	}
+	return 0, false
+}
+
+// counterDelta returns the increase of a counter between two samples,
+// assuming that the counter was reset if it decreased.
+func counterDelta(prev, cur uint64) uint64 {
+	if cur < prev {
+		return cur
+	}
+	return cur - prev
+}
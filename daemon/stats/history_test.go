@@ -0,0 +1,96 @@
+package stats // import "github.com/docker/docker/daemon/stats"
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func historyStats(t time.Time, cpu, mem uint64, rx uint64) *types.StatsJSON {
+	stats := &types.StatsJSON{
+		Networks: map[string]types.NetworkStats{"eth0": {RxBytes: rx}},
+	}
+	stats.Read = t
+	stats.CPUStats.CPUUsage.TotalUsage = cpu
+	stats.MemoryStats.Usage = mem
+	return stats
+}
+
+func TestStatsHistoryQuery(t *testing.T) {
+	h, err := newStatsHistory(HistoryConfig{Retention: time.Minute, Resolution: 10 * time.Second})
+	assert.NilError(t, err)
+
+	start := time.Unix(1500000000, 0)
+	for i := 0; i < 6; i++ {
+		ts := start.Add(time.Duration(i) * 10 * time.Second)
+		// one CPU at 50% and 10 bytes received per second
+		h.add("c", historyStats(ts, uint64(i)*5e9, uint64(100*(i+1)), uint64(i)*100))
+		// ignored, below the resolution
+		h.add("c", historyStats(ts.Add(time.Second), 0, 0, 0))
+	}
+
+	points, step := h.query("c", start, time.Time{}, 30*time.Second)
+	assert.Equal(t, step, 30*time.Second)
+	assert.Assert(t, is.Len(points, 2))
+
+	assert.Check(t, points[0].Start.Equal(start))
+	assert.Check(t, is.Equal(points[0].Samples, 3))
+	assert.Check(t, is.DeepEqual(points[0].MemoryUsage, types.StatsAggregate{Min: 100, Max: 300, Avg: 200}))
+	assert.Check(t, is.DeepEqual(points[0].CPUPercent, types.StatsAggregate{Min: 50, Max: 50, Avg: 50}))
+	assert.Check(t, is.Equal(points[0].NetworkRxBytes, uint64(200)))
+
+	assert.Check(t, points[1].Start.Equal(start.Add(30*time.Second)))
+	assert.Check(t, is.Equal(points[1].Samples, 3))
+	// the increase since the last sample of the previous interval is included
+	assert.Check(t, is.Equal(points[1].NetworkRxBytes, uint64(300)))
+
+	// the oldest samples are dropped past the retention
+	h.add("c", historyStats(start.Add(60*time.Second), 30e9, 700, 600))
+	points, _ = h.query("c", start, time.Time{}, time.Minute)
+	assert.Assert(t, is.Len(points, 2))
+	assert.Check(t, is.Equal(points[0].Samples, 5))
+	assert.Check(t, is.Len(h.containers["c"].samples, 6))
+}
+
+func TestStatsHistoryGrow(t *testing.T) {
+	h, err := newStatsHistory(HistoryConfig{Retention: 24 * time.Hour, Resolution: time.Second})
+	assert.NilError(t, err)
+
+	// the buffer is only allocated as samples are added
+	h.add("c", historyStats(time.Now(), 0, 0, 0))
+	assert.Check(t, cap(h.containers["c"].samples) < h.size)
+}
+
+func TestStatsHistoryPersist(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stats-history")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	now := time.Now().Truncate(time.Second)
+	h, err := newStatsHistory(HistoryConfig{Retention: time.Hour, Resolution: time.Second, Dir: dir})
+	assert.NilError(t, err)
+	for i := 0; i < 3; i++ {
+		h.add("c", historyStats(now.Add(time.Duration(i-3)*time.Second), 0, 100, 0))
+	}
+
+	h, err = newStatsHistory(HistoryConfig{Retention: time.Hour, Resolution: time.Second, Dir: dir})
+	assert.NilError(t, err)
+	points, _ := h.query("c", now.Add(-time.Minute), time.Time{}, time.Minute)
+	assert.Assert(t, is.Len(points, 1))
+	assert.Check(t, is.Equal(points[0].Samples, 3))
+	// querying a container doesn't keep its history in memory
+	assert.Check(t, is.Len(h.containers, 0))
+
+	points, _ = h.query("unknown", time.Time{}, time.Time{}, time.Minute)
+	assert.Check(t, is.Len(points, 0))
+	assert.Check(t, is.Len(h.containers, 0))
+
+	h.remove("c")
+	_, err = os.Stat(h.path("c"))
+	assert.Check(t, os.IsNotExist(err))
+}
@@ -0,0 +1,199 @@
+package stats // import "github.com/docker/docker/daemon/stats"
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/container"
+	"github.com/docker/go-metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// MetricsConfig configures the per-container resource metrics.
+type MetricsConfig struct {
+	// Labels are the container labels added as labels to the metrics, as
+	// "container_label_<name>". Other container labels are not exported.
+	Labels []string
+	// MaxContainers is the maximum number of containers for which metrics
+	// are exported, 0 meaning unlimited. Containers beyond the limit are
+	// skipped, in the order of their IDs.
+	MaxContainers int
+}
+
+// containerMetrics exports the last stats collected for each running
+// container as Prometheus metrics.
+type containerMetrics struct {
+	mu      sync.Mutex
+	samples map[string]*metricsSample
+	labels  []string // container labels exported
+	max     int
+
+	cpuUsage       *prometheus.Desc
+	memoryUsage    *prometheus.Desc
+	memoryLimit    *prometheus.Desc
+	pids           *prometheus.Desc
+	blkioRead      *prometheus.Desc
+	blkioWrite     *prometheus.Desc
+	networkRx      *prometheus.Desc
+	networkTx      *prometheus.Desc
+	networkRxPkts  *prometheus.Desc
+	networkTxPkts  *prometheus.Desc
+	skipContainers *prometheus.Desc
+}
+
+type metricsSample struct {
+	labelValues []string
+	stats       types.StatsJSON
+}
+
+func newContainerMetrics(cfg MetricsConfig) *containerMetrics {
+	ns := metrics.NewNamespace("engine", "container", nil)
+	labels := []string{"id", "name", "image"}
+	var containerLabels []string
+	seen := make(map[string]bool)
+	for _, l := range cfg.Labels {
+		name := "container_label_" + sanitizeLabelName(l)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		labels = append(labels, name)
+		containerLabels = append(containerLabels, l)
+	}
+	netLabels := append(append([]string{}, labels...), "interface")
+
+	m := &containerMetrics{
+		samples: make(map[string]*metricsSample),
+		labels:  containerLabels,
+		max:     cfg.MaxContainers,
+
+		cpuUsage:       ns.NewDesc("cpu_usage", "Total CPU time consumed by the container in seconds", metrics.Seconds, labels...),
+		memoryUsage:    ns.NewDesc("memory_usage", "Memory used by the container", metrics.Bytes, labels...),
+		memoryLimit:    ns.NewDesc("memory_limit", "Memory limit of the container", metrics.Bytes, labels...),
+		pids:           ns.NewDesc("pids", "Number of processes running in the container", metrics.Unit("current"), labels...),
+		blkioRead:      ns.NewDesc("blkio_read", "Number of bytes read from block devices by the container", metrics.Bytes, labels...),
+		blkioWrite:     ns.NewDesc("blkio_write", "Number of bytes written to block devices by the container", metrics.Bytes, labels...),
+		networkRx:      ns.NewDesc("network_receive", "Number of bytes received by the container on a network interface", metrics.Bytes, netLabels...),
+		networkTx:      ns.NewDesc("network_transmit", "Number of bytes sent by the container on a network interface", metrics.Bytes, netLabels...),
+		networkRxPkts:  ns.NewDesc("network_receive_packets", "Number of packets received by the container on a network interface", metrics.Total, netLabels...),
+		networkTxPkts:  ns.NewDesc("network_transmit_packets", "Number of packets sent by the container on a network interface", metrics.Total, netLabels...),
+		skipContainers: ns.NewDesc("metrics_skipped", "Number of running containers without metrics because of the maximum number of containers", metrics.Unit("containers")),
+	}
+	ns.Add(m)
+	metrics.Register(ns)
+	return m
+}
+
+// sanitizeLabelName replaces the characters which are not allowed in
+// Prometheus label names with underscores.
+func sanitizeLabelName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// update records the last stats of a container.
+func (m *containerMetrics) update(c *container.Container, stats types.StatsJSON) {
+	values := []string{c.ID, strings.TrimPrefix(c.Name, "/"), c.Config.Image}
+	for _, l := range m.labels {
+		values = append(values, c.Config.Labels[l])
+	}
+	m.mu.Lock()
+	m.samples[c.ID] = &metricsSample{labelValues: values, stats: stats}
+	m.mu.Unlock()
+}
+
+// prune removes the stats of the containers which are not in ids.
+func (m *containerMetrics) prune(ids map[string]struct{}) {
+	m.mu.Lock()
+	for id := range m.samples {
+		if _, ok := ids[id]; !ok {
+			delete(m.samples, id)
+		}
+	}
+	m.mu.Unlock()
+}
+
+func (m *containerMetrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- m.cpuUsage
+	ch <- m.memoryUsage
+	ch <- m.memoryLimit
+	ch <- m.pids
+	ch <- m.blkioRead
+	ch <- m.blkioWrite
+	ch <- m.networkRx
+	ch <- m.networkTx
+	ch <- m.networkRxPkts
+	ch <- m.networkTxPkts
+	ch <- m.skipContainers
+}
+
+func (m *containerMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.mu.Lock()
+	ids := make([]string, 0, len(m.samples))
+	for id := range m.samples {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var skipped int
+	if m.max > 0 && len(ids) > m.max {
+		skipped = len(ids) - m.max
+		ids = ids[:m.max]
+	}
+	samples := make([]*metricsSample, 0, len(ids))
+	for _, id := range ids {
+		samples = append(samples, m.samples[id])
+	}
+	m.mu.Unlock()
+
+	ch <- prometheus.MustNewConstMetric(m.skipContainers, prometheus.GaugeValue, float64(skipped))
+	for _, s := range samples {
+		m.collectSample(ch, s)
+	}
+}
+
+func (m *containerMetrics) collectSample(ch chan<- prometheus.Metric, s *metricsSample) {
+	stats, values := s.stats, s.labelValues
+
+	ch <- prometheus.MustNewConstMetric(m.cpuUsage, prometheus.CounterValue, float64(stats.CPUStats.CPUUsage.TotalUsage)/1e9, values...)
+	ch <- prometheus.MustNewConstMetric(m.memoryUsage, prometheus.GaugeValue, float64(stats.MemoryStats.Usage), values...)
+	ch <- prometheus.MustNewConstMetric(m.memoryLimit, prometheus.GaugeValue, float64(stats.MemoryStats.Limit), values...)
+	pids := stats.PidsStats.Current
+	if pids == 0 {
+		pids = uint64(stats.NumProcs)
+	}
+	ch <- prometheus.MustNewConstMetric(m.pids, prometheus.GaugeValue, float64(pids), values...)
+
+	read, write := blkioBytes(&stats)
+	ch <- prometheus.MustNewConstMetric(m.blkioRead, prometheus.CounterValue, float64(read), values...)
+	ch <- prometheus.MustNewConstMetric(m.blkioWrite, prometheus.CounterValue, float64(write), values...)
+
+	for iface, n := range stats.Networks {
+		netValues := append(append([]string{}, values...), iface)
+		ch <- prometheus.MustNewConstMetric(m.networkRx, prometheus.CounterValue, float64(n.RxBytes), netValues...)
+		ch <- prometheus.MustNewConstMetric(m.networkTx, prometheus.CounterValue, float64(n.TxBytes), netValues...)
+		ch <- prometheus.MustNewConstMetric(m.networkRxPkts, prometheus.CounterValue, float64(n.RxPackets), netValues...)
+		ch <- prometheus.MustNewConstMetric(m.networkTxPkts, prometheus.CounterValue, float64(n.TxPackets), netValues...)
+	}
+}
+
+// blkioBytes returns the number of bytes read and written by a container.
+// Linux reports the block I/O per device and operation, Windows reports the
+// storage totals.
+func blkioBytes(stats *types.StatsJSON) (read, write uint64) {
+	read, write = stats.StorageStats.ReadSizeBytes, stats.StorageStats.WriteSizeBytes
+	for _, e := range stats.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(e.Op) {
+		case "read":
+			read += e.Value
+		case "write":
+			write += e.Value
+		}
+	}
+	return read, write
+}
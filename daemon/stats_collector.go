@@ -1,9 +1,12 @@
 package daemon // import "github.com/docker/docker/daemon"
 
 import (
+	"path/filepath"
 	"runtime"
 	"time"
 
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/daemon/config"
 	"github.com/docker/docker/daemon/stats"
 	"github.com/docker/docker/pkg/system"
 )
@@ -12,7 +15,10 @@ import (
 // stats for a registered container at the specified interval.
 // The collector allows non-running containers to be added
 // and will start processing stats when they are started.
-func (daemon *Daemon) newStatsCollector(interval time.Duration) *stats.Collector {
+// When the per-container metrics or the stats history are enabled in the
+// configuration, the stats of all the running containers are collected.
+// Otherwise, only the running containers with resource alerts are.
+func (daemon *Daemon) newStatsCollector(interval time.Duration, config *config.Config) (*stats.Collector, error) {
 	// FIXME(vdemeester) move this elsewhere
 	if runtime.GOOS == "linux" {
 		meminfo, err := system.ReadMemInfo()
@@ -21,6 +27,62 @@ func (daemon *Daemon) newStatsCollector(interval time.Duration) *stats.Collector
 		}
 	}
 	s := stats.NewCollector(daemon, interval)
+	if config.ContainerMetrics {
+		s.EnableMetrics(stats.MetricsConfig{
+			Labels:        config.ContainerMetricsLabels,
+			MaxContainers: config.ContainerMetricsMaxContainers,
+		}, daemon.runningContainers)
+	}
+	if retention := config.GetStatsHistoryRetention(); retention > 0 {
+		cfg := stats.HistoryConfig{
+			Retention:  retention,
+			Resolution: config.GetStatsHistoryResolution(),
+		}
+		if config.StatsHistoryPersist {
+			cfg.Dir = filepath.Join(config.Root, "stats-history")
+		}
+		if err := s.EnableHistory(cfg, daemon.runningContainers); err != nil {
+			return nil, err
+		}
+	}
+	s.EnableAlerts(func(c *container.Container, action string, attributes map[string]string) {
+		daemon.LogContainerEventWithAttributes(c, action, attributes)
+	}, daemon.runningContainersWithAlerts)
 	go s.Run()
-	return s
+	return s, nil
+}
+
+// runningContainers returns the running containers of the daemon.
+func (daemon *Daemon) runningContainers() []*container.Container {
+	var running []*container.Container
+	for _, c := range daemon.List() {
+		if c.IsRunning() {
+			running = append(running, c)
+		}
+	}
+	return running
+}
+
+// trackResourceAlerts records whether a container has resource alerts, so
+// that only those containers are scanned for alerts. It must be called with
+// the container locked, whenever its HostConfig is set.
+func (daemon *Daemon) trackResourceAlerts(c *container.Container) {
+	if len(c.HostConfig.ResourceAlerts) > 0 {
+		daemon.alertContainers.Store(c.ID, c)
+	} else {
+		daemon.alertContainers.Delete(c.ID)
+	}
+}
+
+// runningContainersWithAlerts returns the running containers of the daemon
+// which have resource alerts.
+func (daemon *Daemon) runningContainersWithAlerts() []*container.Container {
+	var running []*container.Container
+	daemon.alertContainers.Range(func(_, v interface{}) bool {
+		if c := v.(*container.Container); c.IsRunning() {
+			running = append(running, c)
+		}
+		return true
+	})
+	return running
 }
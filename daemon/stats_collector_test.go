@@ -0,0 +1,31 @@
+package daemon // import "github.com/docker/docker/daemon"
+
+import (
+	"testing"
+
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/container"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestRunningContainersWithAlerts(t *testing.T) {
+	d := &Daemon{}
+	assert.Check(t, is.Len(d.runningContainersWithAlerts(), 0))
+
+	alerts := []containertypes.ResourceAlert{{Name: "mem", Metric: containertypes.AlertMetricMemory, Threshold: 90}}
+	running := &container.Container{ID: "running", State: &container.State{Running: true}, HostConfig: &containertypes.HostConfig{ResourceAlerts: alerts}}
+	stopped := &container.Container{ID: "stopped", State: container.NewState(), HostConfig: &containertypes.HostConfig{ResourceAlerts: alerts}}
+	noAlerts := &container.Container{ID: "no-alerts", State: &container.State{Running: true}, HostConfig: &containertypes.HostConfig{}}
+	for _, c := range []*container.Container{running, stopped, noAlerts} {
+		d.trackResourceAlerts(c)
+	}
+	ls := d.runningContainersWithAlerts()
+	assert.Assert(t, is.Len(ls, 1))
+	assert.Check(t, ls[0] == running)
+
+	// the alerts of a container can be removed
+	running.HostConfig = &containertypes.HostConfig{}
+	d.trackResourceAlerts(running)
+	assert.Check(t, is.Len(d.runningContainersWithAlerts(), 0))
+}
@@ -22,6 +22,7 @@ func (daemon *Daemon) ContainerUpdate(name string, hostConfig *container.HostCon
 	if err != nil {
 		return container.ContainerUpdateOKBody{Warnings: warnings}, errdefs.InvalidParameter(err)
 	}
+	daemon.mergeRestartPolicy(&hostConfig.RestartPolicy)
 
 	if err := daemon.update(name, hostConfig); err != nil {
 		return container.ContainerUpdateOKBody{Warnings: warnings}, err
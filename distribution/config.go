@@ -11,9 +11,11 @@ import (
 	"github.com/docker/distribution/manifest/schema2"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/distribution/metadata"
+	"github.com/docker/docker/distribution/trust"
 	"github.com/docker/docker/distribution/xfer"
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/layer"
+	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/progress"
 	"github.com/docker/docker/pkg/system"
 	refstore "github.com/docker/docker/reference"
@@ -61,6 +63,9 @@ type ImagePullConfig struct {
 	Schema2Types []string
 	// Platform is the requested platform of the image being pulled
 	Platform *specs.Platform
+	// TrustPolicy is the trust policy the pulled images are checked
+	// against. Every image is accepted if it is nil.
+	TrustPolicy *trust.Policy
 }
 
 // ImagePushConfig stores push configuration.
@@ -70,6 +75,18 @@ type ImagePushConfig struct {
 	// ConfigMediaType is the configuration media type for
 	// schema2 manifests.
 	ConfigMediaType string
+	// OCIManifest pushes an OCI image manifest instead of a schema2
+	// manifest.
+	OCIManifest bool
+	// LayerCompression is the compression of the pushed layers, either
+	// archive.Gzip or archive.Zstd. The layers are compressed with gzip
+	// if it is not set, or if OCIManifest is not set.
+	LayerCompression archive.Compression
+	// ManifestListImages are the IDs of the images pushed in a manifest
+	// list, or an OCI index with OCIManifest, with the tag of the pushed
+	// reference. The local tags of the reference are not pushed if it is
+	// set.
+	ManifestListImages []digest.Digest
 	// LayerStores (indexed by operating system) manages layers.
 	LayerStores map[string]PushLayerProvider
 	// UploadManager dispatches uploads.
@@ -166,7 +183,7 @@ func (s *imageConfigStore) PlatformFromConfig(c []byte) (*specs.Platform, error)
 	if !system.IsOSSupported(os) {
 		return nil, system.ErrNotSupportedOperatingSystem
 	}
-	return &specs.Platform{OS: os, Architecture: unmarshalledConfig.Architecture, OSVersion: unmarshalledConfig.OSVersion}, nil
+	return &specs.Platform{OS: os, Architecture: unmarshalledConfig.Architecture, Variant: unmarshalledConfig.Variant, OSVersion: unmarshalledConfig.OSVersion, OSFeatures: unmarshalledConfig.OSFeatures}, nil
 }
 
 type storeLayerProvider struct {
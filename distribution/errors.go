@@ -107,6 +107,8 @@ func TranslatePullError(err error, ref reference.Named) error {
 		}
 	case xfer.DoNotRetry:
 		return TranslatePullError(v.Err, ref)
+	case errdefs.ErrForbidden:
+		return err
 	}
 
 	return errdefs.Unknown(err)
@@ -133,6 +135,9 @@ func continueOnError(err error, mirrorEndpoint bool) bool {
 		// Failures from a mirror endpoint should result in fallback to the
 		// canonical repo.
 		return mirrorEndpoint
+	case errdefs.ErrForbidden:
+		// The image is rejected by the trust policy, whatever the endpoint.
+		return false
 	case error:
 		return !strings.Contains(err.Error(), strings.ToLower(syscall.ESRCH.Error()))
 	}
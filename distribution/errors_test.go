@@ -9,6 +9,7 @@ import (
 	"github.com/docker/distribution/registry/api/errcode"
 	"github.com/docker/distribution/registry/api/v2"
 	"github.com/docker/distribution/registry/client"
+	"github.com/docker/docker/errdefs"
 )
 
 var alwaysContinue = []error{
@@ -31,6 +32,7 @@ var continueFromMirrorEndpoint = []error{
 
 var neverContinue = []error{
 	errors.New(strings.ToLower(syscall.ESRCH.Error())), // No such process
+	errdefs.Forbidden(errors.New("rejected by the trust policy")),
 }
 
 func TestContinueOnError_NonMirrorEndpoint(t *testing.T) {
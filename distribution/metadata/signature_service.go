@@ -0,0 +1,106 @@
+package metadata // import "github.com/docker/docker/distribution/metadata"
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// ImageSignature is a verified signature of the manifest an image was pulled
+// with.
+type ImageSignature struct {
+	// Repository is the name of the repository the image was pulled from.
+	Repository string `json:"repository"`
+	// Digest is the digest of the signed manifest.
+	Digest digest.Digest `json:"digest"`
+	// Payload is the signed payload.
+	Payload []byte `json:"payload"`
+	// Signature is the signature of the payload.
+	Signature []byte `json:"signature"`
+}
+
+// SignatureService maps image IDs to the verified signatures of the
+// manifests the images were pulled with.
+type SignatureService struct {
+	store Store
+}
+
+// NewSignatureService creates a new image ID to signatures mapping service.
+func NewSignatureService(store Store) *SignatureService {
+	return &SignatureService{
+		store: store,
+	}
+}
+
+func (serv *SignatureService) namespace() string {
+	return "signatures-by-image-id"
+}
+
+func (serv *SignatureService) key(id digest.Digest) string {
+	return string(id.Algorithm()) + "/" + id.Hex()
+}
+
+// Get returns the signatures of an image. There are no signatures if the
+// image was not pulled with a verified signature.
+func (serv *SignatureService) Get(id digest.Digest) ([]ImageSignature, error) {
+	if serv.store == nil {
+		return nil, nil
+	}
+	jsonBytes, err := serv.store.Get(serv.namespace(), serv.key(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var signatures []ImageSignature
+	if err := json.Unmarshal(jsonBytes, &signatures); err != nil {
+		return nil, err
+	}
+	return signatures, nil
+}
+
+// Add associates verified signatures with an image. They replace the
+// signatures of the image for the same repository and manifest.
+func (serv *SignatureService) Add(id digest.Digest, signatures []ImageSignature) error {
+	if len(signatures) == 0 || serv.store == nil {
+		return nil
+	}
+	oldSignatures, err := serv.Get(id)
+	if err != nil {
+		oldSignatures = nil
+	}
+	newSignatures := make([]ImageSignature, 0, len(oldSignatures)+len(signatures))
+	for _, old := range oldSignatures {
+		replaced := false
+		for _, s := range signatures {
+			if old.Repository == s.Repository && old.Digest == s.Digest {
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			newSignatures = append(newSignatures, old)
+		}
+	}
+	newSignatures = append(newSignatures, signatures...)
+
+	jsonBytes, err := json.Marshal(newSignatures)
+	if err != nil {
+		return err
+	}
+	return serv.store.Set(serv.namespace(), serv.key(id), jsonBytes)
+}
+
+// Delete removes the signatures of an image.
+func (serv *SignatureService) Delete(id digest.Digest) error {
+	if serv.store == nil {
+		return nil
+	}
+	if err := serv.store.Delete(serv.namespace(), serv.key(id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
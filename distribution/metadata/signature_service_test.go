@@ -0,0 +1,61 @@
+package metadata // import "github.com/docker/docker/distribution/metadata"
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+func TestSignatureService(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "signature-service-test")
+	if err != nil {
+		t.Fatalf("could not create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	metadataStore, err := NewFSMetadataStore(tmpDir)
+	if err != nil {
+		t.Fatalf("could not create metadata store: %v", err)
+	}
+	signatureService := NewSignatureService(metadataStore)
+
+	id := digest.FromString("image")
+	signatures, err := signatureService.Get(id)
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(signatures, 0))
+
+	busybox := ImageSignature{
+		Repository: "docker.io/library/busybox",
+		Digest:     digest.FromString("manifest"),
+		Payload:    []byte("payload"),
+		Signature:  []byte("signature"),
+	}
+	mirror := ImageSignature{
+		Repository: "registry.example.com/busybox",
+		Digest:     digest.FromString("manifest"),
+		Payload:    []byte("payload"),
+		Signature:  []byte("signature"),
+	}
+	assert.NilError(t, signatureService.Add(id, []ImageSignature{busybox, mirror}))
+
+	// the signatures for the same repository and manifest are replaced
+	resigned := busybox
+	resigned.Signature = []byte("new signature")
+	assert.NilError(t, signatureService.Add(id, []ImageSignature{resigned}))
+
+	signatures, err = signatureService.Get(id)
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(signatures, []ImageSignature{mirror, resigned}))
+
+	assert.NilError(t, signatureService.Delete(id))
+	signatures, err = signatureService.Get(id)
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(signatures, 0))
+
+	// deleting the signatures of an image without signatures succeeds
+	assert.NilError(t, signatureService.Delete(id))
+}
@@ -1,9 +1,13 @@
 package distribution
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/docker/distribution"
+	"github.com/docker/distribution/manifest"
 	"github.com/docker/distribution/manifest/schema2"
 	digest "github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
@@ -13,7 +17,7 @@ func init() {
 	// TODO: Remove this registration if distribution is included with OCI support
 
 	ocischemaFunc := func(b []byte) (distribution.Manifest, distribution.Descriptor, error) {
-		m := new(schema2.DeserializedManifest)
+		m := new(ociManifest)
 		err := m.UnmarshalJSON(b)
 		if err != nil {
 			return nil, distribution.Descriptor{}, err
@@ -27,3 +31,192 @@ func init() {
 		panic(fmt.Sprintf("Unable to register manifest: %s", err))
 	}
 }
+
+// ociMediaTypeImageLayerZstd is the media type of the layers compressed with
+// zstd.
+const ociMediaTypeImageLayerZstd = "application/vnd.oci.image.layer.v1.tar+zstd"
+
+// ociAnnotationPrefix is the prefix of the pre-defined OCI annotation keys.
+// The image labels with this prefix are copied to the annotations of the
+// manifests pushed in the OCI format.
+const ociAnnotationPrefix = "org.opencontainers.image."
+
+// ociManifestContent is the content of an OCI image manifest.
+type ociManifestContent struct {
+	manifest.Versioned
+
+	// Config references the image configuration as a blob.
+	Config distribution.Descriptor `json:"config"`
+
+	// Layers lists descriptors for the layers referenced by the
+	// configuration.
+	Layers []distribution.Descriptor `json:"layers"`
+
+	// Annotations contains arbitrary metadata for the image manifest.
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// ociManifest is an OCI image manifest, with its original JSON. It satisfies
+// the distribution.Manifest interface.
+type ociManifest struct {
+	ociManifestContent
+
+	// canonical is the canonical byte representation of the manifest.
+	canonical []byte
+}
+
+// newOCIManifest marshals the content of an OCI image manifest to JSON, and
+// returns the manifest.
+func newOCIManifest(m ociManifestContent) (*ociManifest, error) {
+	m.Versioned = manifest.Versioned{SchemaVersion: 2, MediaType: ocispec.MediaTypeImageManifest}
+	canonical, err := json.MarshalIndent(&m, "", "   ")
+	if err != nil {
+		return nil, err
+	}
+	return &ociManifest{ociManifestContent: m, canonical: canonical}, nil
+}
+
+// UnmarshalJSON populates the manifest from JSON data. The media type is
+// optional in OCI manifests.
+func (m *ociManifest) UnmarshalJSON(b []byte) error {
+	var content ociManifestContent
+	if err := json.Unmarshal(b, &content); err != nil {
+		return err
+	}
+	if content.MediaType != "" && content.MediaType != ocispec.MediaTypeImageManifest {
+		return fmt.Errorf("if present, mediaType in manifest should be '%s' not '%s'", ocispec.MediaTypeImageManifest, content.MediaType)
+	}
+	m.ociManifestContent = content
+	m.canonical = make([]byte, len(b))
+	copy(m.canonical, b)
+	return nil
+}
+
+// MarshalJSON returns the original JSON of the manifest.
+func (m *ociManifest) MarshalJSON() ([]byte, error) {
+	if len(m.canonical) == 0 {
+		return nil, fmt.Errorf("JSON representation not initialized in OCI manifest")
+	}
+	return m.canonical, nil
+}
+
+// References returns the descriptors of the config and the layers.
+func (m *ociManifest) References() []distribution.Descriptor {
+	references := make([]distribution.Descriptor, 0, 1+len(m.Layers))
+	references = append(references, m.Config)
+	references = append(references, m.Layers...)
+	return references
+}
+
+// Target returns the descriptor of the image config.
+func (m *ociManifest) Target() distribution.Descriptor {
+	return m.Config
+}
+
+// Payload returns the media type and the original JSON of the manifest.
+func (m *ociManifest) Payload() (string, []byte, error) {
+	return ocispec.MediaTypeImageManifest, m.canonical, nil
+}
+
+// ociManifestBuilder builds OCI image manifests. The layers appended with
+// schema2 media types are converted to their OCI equivalents.
+type ociManifestBuilder struct {
+	bs          distribution.BlobService
+	configJSON  []byte
+	annotations map[string]string
+	layers      []distribution.Descriptor
+}
+
+// newOCIManifestBuilder returns a builder of OCI image manifests, which
+// publishes the image config to bs when the manifest is built.
+func newOCIManifestBuilder(bs distribution.BlobService, configJSON []byte, annotations map[string]string) distribution.ManifestBuilder {
+	mb := &ociManifestBuilder{
+		bs:          bs,
+		configJSON:  make([]byte, len(configJSON)),
+		annotations: annotations,
+	}
+	copy(mb.configJSON, configJSON)
+	return mb
+}
+
+// Build publishes the image config if needed, and returns the manifest.
+func (mb *ociManifestBuilder) Build(ctx context.Context) (distribution.Manifest, error) {
+	m := ociManifestContent{
+		Layers:      make([]distribution.Descriptor, len(mb.layers)),
+		Annotations: mb.annotations,
+	}
+	copy(m.Layers, mb.layers)
+
+	var err error
+	m.Config, err = mb.bs.Stat(ctx, digest.FromBytes(mb.configJSON))
+	switch err {
+	case nil:
+	case distribution.ErrBlobUnknown:
+		m.Config, err = mb.bs.Put(ctx, ocispec.MediaTypeImageConfig, mb.configJSON)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+	// Override MediaType, since Put always replaces the specified media
+	// type with application/octet-stream in the descriptor it returns.
+	m.Config.MediaType = ocispec.MediaTypeImageConfig
+
+	return newOCIManifest(m)
+}
+
+// AppendReference adds a layer to the manifest.
+func (mb *ociManifestBuilder) AppendReference(d distribution.Describable) error {
+	desc := d.Descriptor()
+	desc.MediaType = ociLayerMediaType(desc.MediaType)
+	mb.layers = append(mb.layers, desc)
+	return nil
+}
+
+// References returns the layers added to the builder.
+func (mb *ociManifestBuilder) References() []distribution.Descriptor {
+	return mb.layers
+}
+
+// ociLayerMediaType returns the OCI media type of a layer for its schema2
+// media type.
+func ociLayerMediaType(mediaType string) string {
+	switch mediaType {
+	case schema2.MediaTypeLayer:
+		return ocispec.MediaTypeImageLayerGzip
+	case schema2.MediaTypeUncompressedLayer:
+		return ocispec.MediaTypeImageLayer
+	case schema2.MediaTypeForeignLayer:
+		return ocispec.MediaTypeImageLayerNonDistributableGzip
+	}
+	return mediaType
+}
+
+// ociAnnotations returns the annotations of the manifest of an image: the
+// creation time and the labels of the image which are pre-defined OCI
+// annotations.
+func ociAnnotations(configJSON []byte) (map[string]string, error) {
+	var config struct {
+		Created string `json:"created"`
+		Config  struct {
+			Labels map[string]string `json:"Labels"`
+		} `json:"config"`
+	}
+	if err := json.Unmarshal(configJSON, &config); err != nil {
+		return nil, err
+	}
+	annotations := make(map[string]string)
+	for k, v := range config.Config.Labels {
+		if strings.HasPrefix(k, ociAnnotationPrefix) {
+			annotations[k] = v
+		}
+	}
+	if _, ok := annotations[ocispec.AnnotationCreated]; !ok && config.Created != "" {
+		annotations[ocispec.AnnotationCreated] = config.Created
+	}
+	if len(annotations) == 0 {
+		return nil, nil
+	}
+	return annotations, nil
+}
@@ -0,0 +1,575 @@
+package distribution // import "github.com/docker/docker/distribution"
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/docker/distribution/manifest/schema2"
+	"github.com/docker/distribution/reference"
+	"github.com/docker/distribution/registry/client"
+	"github.com/docker/docker/distribution/metadata"
+	"github.com/docker/docker/distribution/xfer"
+	"github.com/docker/docker/image"
+	"github.com/docker/docker/layer"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/progress"
+	refstore "github.com/docker/docker/reference"
+	"github.com/docker/docker/registry"
+	digest "github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+// testRegistry is a minimal in-process registry, which stores the blobs and
+// manifests of a single repository in memory.
+type testRegistry struct {
+	mu        sync.Mutex
+	blobs     map[digest.Digest][]byte
+	uploads   map[string][]byte
+	manifests map[string]testRegistryManifest
+}
+
+type testRegistryManifest struct {
+	mediaType string
+	content   []byte
+}
+
+func newTestRegistry(t *testing.T, name string) (distribution.Repository, func()) {
+	r := &testRegistry{
+		blobs:     make(map[digest.Digest][]byte),
+		uploads:   make(map[string][]byte),
+		manifests: make(map[string]testRegistryManifest),
+	}
+	prefix := "/v2/" + name
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !strings.HasPrefix(req.URL.Path, prefix+"/") {
+			http.NotFound(w, req)
+			return
+		}
+		path := strings.TrimPrefix(req.URL.Path, prefix)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		switch {
+		case strings.HasPrefix(path, "/blobs/uploads/"):
+			r.serveUpload(w, req, prefix, strings.TrimPrefix(path, "/blobs/uploads/"))
+		case strings.HasPrefix(path, "/blobs/"):
+			r.serveBlob(w, req, digest.Digest(strings.TrimPrefix(path, "/blobs/")))
+		case strings.HasPrefix(path, "/manifests/"):
+			r.serveManifest(w, req, strings.TrimPrefix(path, "/manifests/"))
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+
+	named, err := reference.WithName(name)
+	assert.NilError(t, err)
+	repo, err := client.NewRepository(named, server.URL, http.DefaultTransport)
+	assert.NilError(t, err)
+	return repo, server.Close
+}
+
+func (r *testRegistry) serveUpload(w http.ResponseWriter, req *http.Request, prefix, id string) {
+	switch req.Method {
+	case http.MethodPost:
+		id = strconv.Itoa(len(r.uploads))
+		r.uploads[id] = nil
+		w.Header().Set("Location", prefix+"/blobs/uploads/"+id)
+		w.Header().Set("Range", "0-0")
+		w.WriteHeader(http.StatusAccepted)
+	case http.MethodPatch:
+		b, _ := ioutil.ReadAll(req.Body)
+		r.uploads[id] = append(r.uploads[id], b...)
+		w.Header().Set("Location", prefix+"/blobs/uploads/"+id)
+		w.Header().Set("Range", fmt.Sprintf("0-%d", len(r.uploads[id])-1))
+		w.WriteHeader(http.StatusAccepted)
+	case http.MethodPut:
+		b, _ := ioutil.ReadAll(req.Body)
+		content := append(r.uploads[id], b...)
+		dgst := digest.Digest(req.URL.Query().Get("digest"))
+		if digest.FromBytes(content) != dgst {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		delete(r.uploads, id)
+		r.blobs[dgst] = content
+		w.Header().Set("Docker-Content-Digest", dgst.String())
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (r *testRegistry) serveBlob(w http.ResponseWriter, req *http.Request, dgst digest.Digest) {
+	content, ok := r.blobs[dgst]
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Docker-Content-Digest", dgst.String())
+	w.WriteHeader(http.StatusOK)
+	if req.Method == http.MethodGet {
+		w.Write(content)
+	}
+}
+
+func (r *testRegistry) serveManifest(w http.ResponseWriter, req *http.Request, ref string) {
+	switch req.Method {
+	case http.MethodPut:
+		content, _ := ioutil.ReadAll(req.Body)
+		m := testRegistryManifest{mediaType: req.Header.Get("Content-Type"), content: content}
+		dgst := digest.FromBytes(content)
+		r.manifests[ref] = m
+		r.manifests[dgst.String()] = m
+		w.Header().Set("Docker-Content-Digest", dgst.String())
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodGet, http.MethodHead:
+		m, ok := r.manifests[ref]
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(m.content)))
+		w.Header().Set("Content-Type", m.mediaType)
+		w.Header().Set("Docker-Content-Digest", digest.FromBytes(m.content).String())
+		w.WriteHeader(http.StatusOK)
+		if req.Method == http.MethodGet {
+			w.Write(m.content)
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// testImageConfigStore is an in-memory ImageConfigStore.
+type testImageConfigStore struct {
+	configs map[digest.Digest][]byte
+}
+
+func (s *testImageConfigStore) Put(c []byte) (digest.Digest, error) {
+	dgst := digest.FromBytes(c)
+	s.configs[dgst] = c
+	return dgst, nil
+}
+
+func (s *testImageConfigStore) Get(dgst digest.Digest) ([]byte, error) {
+	c, ok := s.configs[dgst]
+	if !ok {
+		return nil, fmt.Errorf("image config %s does not exist", dgst)
+	}
+	return c, nil
+}
+
+func (s *testImageConfigStore) RootFSFromConfig(c []byte) (*image.RootFS, error) {
+	img, err := image.NewFromJSON(c)
+	if err != nil {
+		return nil, err
+	}
+	return img.RootFS, nil
+}
+
+func (s *testImageConfigStore) PlatformFromConfig(c []byte) (*ocispec.Platform, error) {
+	img, err := image.NewFromJSON(c)
+	if err != nil {
+		return nil, err
+	}
+	return &ocispec.Platform{OS: img.OS, Architecture: img.Architecture, Variant: img.Variant}, nil
+}
+
+// testImageConfig returns the config of an image without layers.
+func testImageConfig(t *testing.T, architecture, variant string, labels map[string]string) []byte {
+	configJSON, err := json.Marshal(map[string]interface{}{
+		"architecture": architecture,
+		"variant":      variant,
+		"os":           "linux",
+		"created":      "2019-01-02T03:04:05Z",
+		"config":       map[string]interface{}{"Labels": labels},
+		"rootfs":       map[string]interface{}{"type": "layers", "diff_ids": []string{}},
+	})
+	assert.NilError(t, err)
+	return configJSON
+}
+
+// pushTestOCIManifest pushes an OCI image manifest for configJSON with a
+// single layer, and returns it.
+func pushTestOCIManifest(ctx context.Context, t *testing.T, repo distribution.Repository, configJSON []byte, tag string) *ociManifest {
+	layer, err := repo.Blobs(ctx).Put(ctx, schema2.MediaTypeLayer, []byte("layer"))
+	assert.NilError(t, err)
+	layer.MediaType = schema2.MediaTypeLayer
+
+	annotations, err := ociAnnotations(configJSON)
+	assert.NilError(t, err)
+	builder := newOCIManifestBuilder(repo.Blobs(ctx), configJSON, annotations)
+	assert.NilError(t, builder.AppendReference(layer))
+	m, err := builder.Build(ctx)
+	assert.NilError(t, err)
+
+	manSvc, err := repo.Manifests(ctx)
+	assert.NilError(t, err)
+	var options []distribution.ManifestServiceOption
+	if tag != "" {
+		options = append(options, distribution.WithTag(tag))
+	}
+	_, err = manSvc.Put(ctx, m, options...)
+	assert.NilError(t, err)
+	return m.(*ociManifest)
+}
+
+func TestOCIManifestRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	repo, closeRegistry := newTestRegistry(t, "test/image")
+	defer closeRegistry()
+
+	l := newTestPushLayer(t, "file", "content")
+	configJSON := testLayerImageConfig(t, l, map[string]string{
+		"org.opencontainers.image.source": "https://example.com/source",
+		"other":                           "label",
+	})
+	pushStore := &testImageConfigStore{configs: make(map[digest.Digest][]byte)}
+	id, err := pushStore.Put(configJSON)
+	assert.NilError(t, err)
+
+	ref, err := reference.ParseNormalizedNamed("test/image:latest")
+	assert.NilError(t, err)
+	lp := testPushLayerProvider{layers: map[layer.ChainID]PushLayer{l.ChainID(): l}}
+	tmpDir, err := ioutil.TempDir("", "oci-round-trip")
+	assert.NilError(t, err)
+	defer os.RemoveAll(tmpDir)
+	referenceStore, err := refstore.NewReferenceStore(filepath.Join(tmpDir, "repositories.json"))
+	assert.NilError(t, err)
+	pusher := newTestPusher(repo, ref, pushStore, lp, true, archive.Gzip)
+	pusher.config.ReferenceStore = referenceStore
+	assert.NilError(t, pusher.pushV2Tag(ctx, ref.(reference.NamedTagged), id))
+
+	manSvc, err := repo.Manifests(ctx)
+	assert.NilError(t, err)
+	m, err := manSvc.Get(ctx, "", distribution.WithTag("latest"))
+	assert.NilError(t, err)
+
+	pushed, ok := m.(*ociManifest)
+	assert.Assert(t, ok, "unexpected manifest type %T", m)
+	mediaType, _, err := pushed.Payload()
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(mediaType, ocispec.MediaTypeImageManifest))
+	assert.Check(t, is.Equal(pushed.MediaType, ocispec.MediaTypeImageManifest))
+	assert.Check(t, is.Equal(pushed.Config.MediaType, ocispec.MediaTypeImageConfig))
+	assert.Check(t, is.Equal(pushed.Config.Digest, id))
+	assert.Assert(t, is.Len(pushed.Layers, 1))
+	assert.Check(t, is.Equal(pushed.Layers[0].MediaType, ocispec.MediaTypeImageLayerGzip))
+	assert.Check(t, is.DeepEqual(pushed.Annotations, map[string]string{
+		ocispec.AnnotationCreated:         "2019-01-02T03:04:05Z",
+		"org.opencontainers.image.source": "https://example.com/source",
+	}))
+
+	// the pulled image is the pushed image, with the same layer
+	pullStore := &testImageConfigStore{configs: make(map[digest.Digest][]byte)}
+	puller := &v2Puller{
+		V2MetadataService: metadata.NewV2MetadataService(nil),
+		config: &ImagePullConfig{
+			Config: Config{
+				ProgressOutput: progress.DiscardOutput(),
+				ImageStore:     pullStore,
+			},
+			DownloadManager: testDownloadManager{},
+			Schema2Types:    ImageTypes,
+		},
+		repo: repo,
+	}
+	_, err = puller.pullV2Tag(ctx, ref, nil)
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(pullStore.configs, map[digest.Digest][]byte{id: configJSON}))
+}
+
+func TestOCIManifestUnmarshalMediaType(t *testing.T) {
+	m := new(ociManifest)
+	assert.NilError(t, m.UnmarshalJSON([]byte(`{"schemaVersion":2,"config":{},"layers":[]}`)))
+	err := m.UnmarshalJSON([]byte(`{"schemaVersion":2,"mediaType":"` + schema2.MediaTypeManifest + `"}`))
+	assert.Check(t, is.ErrorContains(err, "mediaType in manifest should be"))
+}
+
+func TestPullOCIIndex(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("platform matching of manifest lists differs on Windows")
+	}
+	ctx := context.Background()
+	repo, closeRegistry := newTestRegistry(t, "test/image")
+	defer closeRegistry()
+
+	var descriptors []manifestlist.ManifestDescriptor
+	for _, p := range []ocispec.Platform{
+		{OS: "linux", Architecture: "arm", Variant: "v6"},
+		{OS: "linux", Architecture: "arm", Variant: "v7"},
+		{OS: "linux", Architecture: "arm64"},
+	} {
+		m := pushTestOCIManifest(ctx, t, repo, testImageConfig(t, p.Architecture, p.Variant, nil), "")
+		_, payload, err := m.Payload()
+		assert.NilError(t, err)
+		descriptors = append(descriptors, manifestlist.ManifestDescriptor{
+			Descriptor: distribution.Descriptor{
+				MediaType: ocispec.MediaTypeImageManifest,
+				Size:      int64(len(payload)),
+				Digest:    digest.FromBytes(payload),
+			},
+			Platform: manifestlist.PlatformSpec{OS: p.OS, Architecture: p.Architecture, Variant: p.Variant},
+		})
+	}
+	index, err := manifestlist.FromDescriptors(descriptors)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(index.MediaType, ocispec.MediaTypeImageIndex))
+	manSvc, err := repo.Manifests(ctx)
+	assert.NilError(t, err)
+	_, err = manSvc.Put(ctx, index, distribution.WithTag("latest"))
+	assert.NilError(t, err)
+
+	ref, err := reference.ParseNormalizedNamed("test/image:latest")
+	assert.NilError(t, err)
+
+	for _, tc := range []struct {
+		platform ocispec.Platform
+		expected string
+	}{
+		// arm/v7 also matches arm/v6, but arm/v7 is the best match
+		{platform: ocispec.Platform{OS: "linux", Architecture: "arm", Variant: "v7"}, expected: "v7"},
+		{platform: ocispec.Platform{OS: "linux", Architecture: "arm", Variant: "v6"}, expected: "v6"},
+		{platform: ocispec.Platform{OS: "linux", Architecture: "arm64"}, expected: ""},
+	} {
+		store := &testImageConfigStore{configs: make(map[digest.Digest][]byte)}
+		p := &v2Puller{
+			config: &ImagePullConfig{
+				Config: Config{
+					ProgressOutput: progress.DiscardOutput(),
+					ImageStore:     store,
+				},
+				Schema2Types: ImageTypes,
+			},
+			repo: repo,
+		}
+		_, err := p.pullV2Tag(ctx, ref, &tc.platform)
+		assert.NilError(t, err)
+
+		assert.Assert(t, is.Len(store.configs, 1))
+		for _, c := range store.configs {
+			var config struct {
+				Architecture string `json:"architecture"`
+				Variant      string `json:"variant"`
+			}
+			assert.NilError(t, json.Unmarshal(c, &config))
+			assert.Check(t, is.Equal(config.Architecture, tc.platform.Architecture))
+			assert.Check(t, is.Equal(config.Variant, tc.expected))
+		}
+	}
+}
+
+// testPushLayerProvider provides the layers of the test images, and the
+// empty layer, which is the top layer of the images without layers.
+type testPushLayerProvider struct {
+	layers map[layer.ChainID]PushLayer
+}
+
+func (lp testPushLayerProvider) Get(chainID layer.ChainID) (PushLayer, error) {
+	if l, ok := lp.layers[chainID]; ok {
+		return l, nil
+	}
+	return &storeLayer{Layer: layer.EmptyLayer}, nil
+}
+
+// testPushLayer is an uncompressed base layer.
+type testPushLayer struct {
+	content []byte
+}
+
+// newTestPushLayer returns a layer with a single file.
+func newTestPushLayer(t *testing.T, name, content string) *testPushLayer {
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	assert.NilError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+	_, err := tw.Write([]byte(content))
+	assert.NilError(t, err)
+	assert.NilError(t, tw.Close())
+	return &testPushLayer{content: buf.Bytes()}
+}
+
+func (l *testPushLayer) ChainID() layer.ChainID {
+	return layer.CreateChainID([]layer.DiffID{l.DiffID()})
+}
+
+func (l *testPushLayer) DiffID() layer.DiffID {
+	return layer.DiffID(digest.FromBytes(l.content))
+}
+
+func (l *testPushLayer) Parent() PushLayer {
+	return nil
+}
+
+func (l *testPushLayer) Open() (io.ReadCloser, error) {
+	return ioutil.NopCloser(bytes.NewReader(l.content)), nil
+}
+
+func (l *testPushLayer) Size() (int64, error) {
+	return int64(len(l.content)), nil
+}
+
+func (l *testPushLayer) MediaType() string {
+	return schema2.MediaTypeUncompressedLayer
+}
+
+func (l *testPushLayer) Release() {}
+
+// testLayerImageConfig returns the config of an image with a single layer.
+func testLayerImageConfig(t *testing.T, l *testPushLayer, labels map[string]string) []byte {
+	configJSON, err := json.Marshal(map[string]interface{}{
+		"architecture": "amd64",
+		"os":           "linux",
+		"created":      "2019-01-02T03:04:05Z",
+		"config":       map[string]interface{}{"Labels": labels},
+		"rootfs":       map[string]interface{}{"type": "layers", "diff_ids": []string{l.DiffID().String()}},
+	})
+	assert.NilError(t, err)
+	return configJSON
+}
+
+// newTestPusher returns a pusher of ref to repo, for the images of store
+// with the layers of lp.
+func newTestPusher(repo distribution.Repository, ref reference.Named, store ImageConfigStore, lp PushLayerProvider, oci bool, compression archive.Compression) *v2Pusher {
+	return &v2Pusher{
+		v2MetadataService: &mockV2MetadataService{},
+		ref:               ref,
+		repoInfo:          &registry.RepositoryInfo{Name: ref},
+		config: &ImagePushConfig{
+			Config: Config{
+				ProgressOutput: progress.DiscardOutput(),
+				ImageStore:     store,
+			},
+			ConfigMediaType:  schema2.MediaTypeImageConfig,
+			OCIManifest:      oci,
+			LayerCompression: compression,
+			LayerStores:      map[string]PushLayerProvider{"linux": lp},
+			UploadManager:    xfer.NewLayerUploadManager(1),
+		},
+		repo:      repo,
+		pushState: pushState{remoteLayers: make(map[layer.DiffID]distribution.Descriptor)},
+	}
+}
+
+// testDownloadManager downloads the layers and computes their DiffIDs,
+// without registering them.
+type testDownloadManager struct{}
+
+func (testDownloadManager) Download(ctx context.Context, initialRootFS image.RootFS, os string, layers []xfer.DownloadDescriptor, progressOutput progress.Output) (image.RootFS, func(), error) {
+	rootFS := initialRootFS
+	for _, l := range layers {
+		rc, _, err := l.Download(ctx, progressOutput)
+		if err != nil {
+			return rootFS, nil, err
+		}
+		uncompressed, err := archive.DecompressStream(rc)
+		if err != nil {
+			rc.Close()
+			return rootFS, nil, err
+		}
+		content, err := ioutil.ReadAll(uncompressed)
+		uncompressed.Close()
+		rc.Close()
+		l.Close()
+		if err != nil {
+			return rootFS, nil, err
+		}
+		rootFS.Append(layer.DiffID(digest.FromBytes(content)))
+	}
+	return rootFS, func() {}, nil
+}
+
+func TestPushManifestList(t *testing.T) {
+	for _, tc := range []struct {
+		oci               bool
+		expectedMediaType string
+	}{
+		{oci: false, expectedMediaType: manifestlist.MediaTypeManifestList},
+		{oci: true, expectedMediaType: ocispec.MediaTypeImageIndex},
+	} {
+		ctx := context.Background()
+		repo, closeRegistry := newTestRegistry(t, "test/image")
+		defer closeRegistry()
+
+		store := &testImageConfigStore{configs: make(map[digest.Digest][]byte)}
+		var ids []digest.Digest
+		for _, p := range []ocispec.Platform{
+			{OS: "linux", Architecture: "amd64"},
+			{OS: "linux", Architecture: "arm", Variant: "v7"},
+		} {
+			id, err := store.Put(testImageConfig(t, p.Architecture, p.Variant, nil))
+			assert.NilError(t, err)
+			ids = append(ids, id)
+		}
+
+		ref, err := reference.ParseNormalizedNamed("test/image:latest")
+		assert.NilError(t, err)
+		p := newTestPusher(repo, ref, store, testPushLayerProvider{}, tc.oci, archive.Gzip)
+		p.config.ManifestListImages = ids
+		assert.NilError(t, p.pushV2Repository(ctx))
+
+		manSvc, err := repo.Manifests(ctx)
+		assert.NilError(t, err)
+		m, err := manSvc.Get(ctx, "", distribution.WithTag("latest"))
+		assert.NilError(t, err)
+		list, ok := m.(*manifestlist.DeserializedManifestList)
+		assert.Assert(t, ok, "expected a manifest list, got %T", m)
+		assert.Check(t, is.Equal(list.MediaType, tc.expectedMediaType))
+		assert.Assert(t, is.Len(list.Manifests, 2))
+		assert.Check(t, is.Equal(list.Manifests[0].Platform.Architecture, "amd64"))
+		assert.Check(t, is.Equal(list.Manifests[1].Platform.Architecture, "arm"))
+		assert.Check(t, is.Equal(list.Manifests[1].Platform.Variant, "v7"))
+		for _, desc := range list.Manifests {
+			// the images are pushed by digest
+			_, err := manSvc.Get(ctx, desc.Digest)
+			assert.Check(t, err)
+		}
+	}
+}
+
+func TestPushManifestListDuplicatePlatform(t *testing.T) {
+	ctx := context.Background()
+	repo, closeRegistry := newTestRegistry(t, "test/image")
+	defer closeRegistry()
+
+	store := &testImageConfigStore{configs: make(map[digest.Digest][]byte)}
+	id1, err := store.Put(testImageConfig(t, "amd64", "", nil))
+	assert.NilError(t, err)
+	id2, err := store.Put(testImageConfig(t, "amd64", "", map[string]string{"foo": "bar"}))
+	assert.NilError(t, err)
+
+	ref, err := reference.ParseNormalizedNamed("test/image:latest")
+	assert.NilError(t, err)
+	p := &v2Pusher{
+		ref:      ref,
+		repoInfo: &registry.RepositoryInfo{Name: ref},
+		config: &ImagePushConfig{
+			Config: Config{
+				ProgressOutput: progress.DiscardOutput(),
+				ImageStore:     store,
+			},
+			ManifestListImages: []digest.Digest{id1, id2},
+		},
+		repo: repo,
+	}
+	err = p.pushV2Repository(ctx)
+	assert.Check(t, is.ErrorContains(err, "have the same platform linux/amd64"))
+}
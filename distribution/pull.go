@@ -34,6 +34,7 @@ func newPuller(endpoint registry.APIEndpoint, repoInfo *registry.RepositoryInfo,
 	case registry.APIVersion2:
 		return &v2Puller{
 			V2MetadataService: metadata.NewV2MetadataService(imagePullConfig.MetadataStore),
+			signatureService:  metadata.NewSignatureService(imagePullConfig.MetadataStore),
 			endpoint:          endpoint,
 			config:            imagePullConfig,
 			repoInfo:          repoInfo,
@@ -63,6 +64,11 @@ func Pull(ctx context.Context, ref reference.Named, imagePullConfig *ImagePullCo
 		return err
 	}
 
+	rule := imagePullConfig.TrustPolicy.Rule(repoInfo.Name)
+	if err := rule.Check(repoInfo.Name); err != nil {
+		return err
+	}
+
 	endpoints, err := imagePullConfig.RegistryService.LookupPullEndpoints(reference.Domain(repoInfo.Name))
 	if err != nil {
 		return err
@@ -95,6 +101,12 @@ func Pull(ctx context.Context, ref reference.Named, imagePullConfig *ImagePullCo
 			continue
 		}
 
+		// signatures are only verified on v2 endpoints
+		if rule.RequiresSignature() && endpoint.Version == registry.APIVersion1 {
+			logrus.Debugf("Skipping v1 endpoint %s because the trust policy requires signatures", endpoint.URL)
+			continue
+		}
+
 		if confirmedV2 && endpoint.Version == registry.APIVersion1 {
 			logrus.Debugf("Skipping v1 endpoint %s because v2 registry was detected", endpoint.URL)
 			continue
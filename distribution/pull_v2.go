@@ -25,6 +25,7 @@ import (
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/image/v1"
 	"github.com/docker/docker/layer"
+	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/ioutils"
 	"github.com/docker/docker/pkg/progress"
 	"github.com/docker/docker/pkg/stringid"
@@ -55,6 +56,7 @@ func (e ImageConfigPullError) Error() string {
 
 type v2Puller struct {
 	V2MetadataService metadata.V2MetadataService
+	signatureService  *metadata.SignatureService
 	endpoint          registry.APIEndpoint
 	config            *ImagePullConfig
 	repoInfo          *registry.RepositoryInfo
@@ -272,7 +274,10 @@ func (ld *v2LayerDescriptor) Download(ctx context.Context, progressOutput progre
 
 	logrus.Debugf("Downloaded %s to tempfile %s", ld.ID(), tmpFile.Name())
 
-	_, err = tmpFile.Seek(0, os.SEEK_SET)
+	err = checkLayerCompression(tmpFile, ld.src.MediaType)
+	if err == nil {
+		_, err = tmpFile.Seek(0, os.SEEK_SET)
+	}
 	if err != nil {
 		tmpFile.Close()
 		if err := os.Remove(tmpFile.Name()); err != nil {
@@ -325,7 +330,24 @@ func (ld *v2LayerDescriptor) truncateDownloadFile() error {
 
 func (ld *v2LayerDescriptor) Registered(diffID layer.DiffID) {
 	// Cache mapping from this layer's DiffID to the blobsum
-	ld.V2MetadataService.Add(diffID, metadata.V2Metadata{Digest: ld.digest, SourceRepository: ld.repoInfo.Name.Name()})
+	ld.V2MetadataService.Add(diffID, metadata.V2Metadata{Digest: ld.digest, SourceRepository: ld.repoInfo.Name.Name(), MediaType: v2MetadataMediaType(ld.src.MediaType)})
+}
+
+// checkLayerCompression checks that a downloaded layer compressed with zstd,
+// according to its media type, is a zstd stream.
+func checkLayerCompression(f io.ReaderAt, mediaType string) error {
+	if mediaType != ociMediaTypeImageLayerZstd {
+		return nil
+	}
+	header := make([]byte, 10)
+	n, err := f.ReadAt(header, 0)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	if archive.DetectCompression(header[:n]) != archive.Zstd {
+		return fmt.Errorf("layer with media type %s is not compressed with zstd", mediaType)
+	}
+	return nil
 }
 
 func (p *v2Puller) pullV2Tag(ctx context.Context, ref reference.Named, platform *specs.Platform) (tagUpdated bool, err error) {
@@ -358,20 +380,27 @@ func (p *v2Puller) pullV2Tag(ctx context.Context, ref reference.Named, platform
 		return false, fmt.Errorf("image manifest does not exist for tag or digest %q", tagOrDigest)
 	}
 
-	if m, ok := manifest.(*schema2.DeserializedManifest); ok {
+	var configMediaType *string
+	switch m := manifest.(type) {
+	case *schema2.DeserializedManifest:
+		configMediaType = &m.Manifest.Config.MediaType
+	case *ociManifest:
+		configMediaType = &m.Config.MediaType
+	}
+	if configMediaType != nil {
 		var allowedMediatype bool
 		for _, t := range p.config.Schema2Types {
-			if m.Manifest.Config.MediaType == t {
+			if *configMediaType == t {
 				allowedMediatype = true
 				break
 			}
 		}
 		if !allowedMediatype {
-			configClass := mediaTypeClasses[m.Manifest.Config.MediaType]
+			configClass := mediaTypeClasses[*configMediaType]
 			if configClass == "" {
 				configClass = "unknown"
 			}
-			return false, invalidManifestClassError{m.Manifest.Config.MediaType, configClass}
+			return false, invalidManifestClassError{*configMediaType, configClass}
 		}
 	}
 
@@ -379,6 +408,11 @@ func (p *v2Puller) pullV2Tag(ctx context.Context, ref reference.Named, platform
 	// the other side speaks the v2 protocol.
 	p.confirmedV2 = true
 
+	signatures, err := p.verifySignatures(ctx, ref, manifest)
+	if err != nil {
+		return false, err
+	}
+
 	logrus.Debugf("Pulling ref from V2 registry: %s", reference.FamiliarString(ref))
 	progress.Message(p.config.ProgressOutput, tagOrDigest, "Pulling from "+reference.FamiliarName(p.repo.Named()))
 
@@ -401,6 +435,11 @@ func (p *v2Puller) pullV2Tag(ctx context.Context, ref reference.Named, platform
 		if err != nil {
 			return false, err
 		}
+	case *ociManifest:
+		id, manifestDigest, err = p.pullOCI(ctx, ref, v, platform)
+		if err != nil {
+			return false, err
+		}
 	case *manifestlist.DeserializedManifestList:
 		id, manifestDigest, err = p.pullManifestList(ctx, ref, v, platform)
 		if err != nil {
@@ -412,6 +451,10 @@ func (p *v2Puller) pullV2Tag(ctx context.Context, ref reference.Named, platform
 
 	progress.Message(p.config.ProgressOutput, "", "Digest: "+manifestDigest.String())
 
+	if err := p.signatureService.Add(id, signatures); err != nil {
+		return false, err
+	}
+
 	if p.config.ReferenceStore != nil {
 		oldTagID, err := p.config.ReferenceStore.Get(ref)
 		if err == nil {
@@ -554,18 +597,48 @@ func (p *v2Puller) pullSchema2(ctx context.Context, ref reference.Named, mfst *s
 		return "", "", err
 	}
 
-	target := mfst.Target()
+	id, err = p.pullImage(ctx, mfst.Target(), mfst.Layers, platform)
+	if err != nil {
+		return "", "", err
+	}
+	return id, manifestDigest, nil
+}
+
+// pullOCI pulls the image of an OCI image manifest. The OCI layer media
+// types are handled like their schema2 equivalents.
+func (p *v2Puller) pullOCI(ctx context.Context, ref reference.Named, mfst *ociManifest, platform *specs.Platform) (id digest.Digest, manifestDigest digest.Digest, err error) {
+	manifestDigest, err = schema2ManifestDigest(ref, mfst)
+	if err != nil {
+		return "", "", err
+	}
+
+	id, err = p.pullImage(ctx, mfst.Target(), mfst.Layers, platform)
+	if err != nil {
+		return "", "", err
+	}
+	return id, manifestDigest, nil
+}
+
+// pullImage pulls the config and the layers of an image, and creates it in
+// the image store. It returns the image ID.
+func (p *v2Puller) pullImage(ctx context.Context, target distribution.Descriptor, layers []distribution.Descriptor, platform *specs.Platform) (id digest.Digest, err error) {
 	if _, err := p.config.ImageStore.Get(target.Digest); err == nil {
 		// If the image already exists locally, no need to pull
 		// anything.
-		return target.Digest, manifestDigest, nil
+		return target.Digest, nil
 	}
 
 	var descriptors []xfer.DownloadDescriptor
 
 	// Note that the order of this loop is in the direction of bottom-most
 	// to top-most, so that the downloads slice gets ordered correctly.
-	for _, d := range mfst.Layers {
+	for _, d := range layers {
+		if d.MediaType == ociMediaTypeImageLayerZstd {
+			// fail before downloading layers which cannot be extracted
+			if err := archive.CheckZstd(); err != nil {
+				return "", errors.Wrapf(err, "cannot pull layer %s", d.Digest)
+			}
+		}
 		layerDescriptor := &v2LayerDescriptor{
 			digest:            d.Digest,
 			repo:              p.repo,
@@ -620,23 +693,23 @@ func (p *v2Puller) pullSchema2(ctx context.Context, ref reference.Named, mfst *s
 	if runtime.GOOS == "windows" {
 		configJSON, configRootFS, configPlatform, err = receiveConfig(p.config.ImageStore, configChan, configErrChan)
 		if err != nil {
-			return "", "", err
+			return "", err
 		}
 		if configRootFS == nil {
-			return "", "", errRootFSInvalid
+			return "", errRootFSInvalid
 		}
 		if err := checkImageCompatibility(configPlatform.OS, configPlatform.OSVersion); err != nil {
-			return "", "", err
+			return "", err
 		}
 
 		if len(descriptors) != len(configRootFS.DiffIDs) {
-			return "", "", errRootFSMismatch
+			return "", errRootFSMismatch
 		}
 		if platform == nil {
 			// Early bath if the requested OS doesn't match that of the configuration.
 			// This avoids doing the download, only to potentially fail later.
 			if !system.IsOSSupported(configPlatform.OS) {
-				return "", "", fmt.Errorf("cannot download image with operating system %q when requesting %q", configPlatform.OS, layerStoreOS)
+				return "", fmt.Errorf("cannot download image with operating system %q when requesting %q", configPlatform.OS, layerStoreOS)
 			}
 			layerStoreOS = configPlatform.OS
 		}
@@ -683,14 +756,14 @@ func (p *v2Puller) pullSchema2(ctx context.Context, ref reference.Named, mfst *s
 			case <-downloadsDone:
 			case <-layerErrChan:
 			}
-			return "", "", err
+			return "", err
 		}
 	}
 
 	select {
 	case <-downloadsDone:
 	case err = <-layerErrChan:
-		return "", "", err
+		return "", err
 	}
 
 	if release != nil {
@@ -702,22 +775,22 @@ func (p *v2Puller) pullSchema2(ctx context.Context, ref reference.Named, mfst *s
 		// Otherwise the image config could be referencing layers that aren't
 		// included in the manifest.
 		if len(downloadedRootFS.DiffIDs) != len(configRootFS.DiffIDs) {
-			return "", "", errRootFSMismatch
+			return "", errRootFSMismatch
 		}
 
 		for i := range downloadedRootFS.DiffIDs {
 			if downloadedRootFS.DiffIDs[i] != configRootFS.DiffIDs[i] {
-				return "", "", errRootFSMismatch
+				return "", errRootFSMismatch
 			}
 		}
 	}
 
 	imageID, err := p.config.ImageStore.Put(configJSON)
 	if err != nil {
-		return "", "", err
+		return "", err
 	}
 
-	return imageID, manifestDigest, nil
+	return imageID, nil
 }
 
 func receiveConfig(s ImageConfigStore, configChan <-chan []byte, errChan <-chan error) ([]byte, *image.RootFS, *specs.Platform, error) {
@@ -798,6 +871,12 @@ func (p *v2Puller) pullManifestList(ctx context.Context, ref reference.Named, mf
 		if err != nil {
 			return "", "", err
 		}
+	case *ociManifest:
+		platform := toOCIPlatform(manifestMatches[0].Platform)
+		id, _, err = p.pullOCI(ctx, manifestRef, v, &platform)
+		if err != nil {
+			return "", "", err
+		}
 	default:
 		return "", "", errors.New("unsupported manifest format")
 	}
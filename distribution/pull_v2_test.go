@@ -1,6 +1,7 @@
 package distribution // import "github.com/docker/docker/distribution"
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -11,6 +12,7 @@ import (
 	"testing"
 
 	"github.com/docker/distribution/manifest/schema1"
+	"github.com/docker/distribution/manifest/schema2"
 	"github.com/docker/distribution/reference"
 	"github.com/opencontainers/go-digest"
 	specs "github.com/opencontainers/image-spec/specs-go/v1"
@@ -205,3 +207,12 @@ func TestFormatPlatform(t *testing.T) {
 		}
 	}
 }
+
+func TestCheckLayerCompression(t *testing.T) {
+	zstdBlob := []byte{0x28, 0xB5, 0x2F, 0xFD, 0x00}
+	gzipBlob := []byte{0x1F, 0x8B, 0x08, 0x00}
+
+	assert.Check(t, checkLayerCompression(bytes.NewReader(zstdBlob), ociMediaTypeImageLayerZstd))
+	assert.Check(t, is.ErrorContains(checkLayerCompression(bytes.NewReader(gzipBlob), ociMediaTypeImageLayerZstd), "not compressed with zstd"))
+	assert.Check(t, checkLayerCompression(bytes.NewReader(gzipBlob), schema2.MediaTypeLayer))
+}
@@ -4,6 +4,7 @@ package distribution // import "github.com/docker/docker/distribution"
 
 import (
 	"context"
+	"sort"
 
 	"github.com/containerd/containerd/platforms"
 	"github.com/docker/distribution"
@@ -28,6 +29,13 @@ func filterManifests(manifests []manifestlist.ManifestDescriptor, p specs.Platfo
 		}
 	}
 
+	// sort the matches so that the best match, such as the exact variant of
+	// an arm platform, comes first
+	c := platforms.Only(p)
+	sort.SliceStable(matches, func(i, j int) bool {
+		return c.Less(toOCIPlatform(matches[i].Platform), toOCIPlatform(matches[j].Platform))
+	})
+
 	// deprecated: backwards compatibility with older versions that didn't compare variant
 	if len(matches) == 0 && p.Architecture == "arm" {
 		p = platforms.Normalize(p)
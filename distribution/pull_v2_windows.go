@@ -24,12 +24,22 @@ import (
 var _ distribution.Describable = &v2LayerDescriptor{}
 
 func (ld *v2LayerDescriptor) Descriptor() distribution.Descriptor {
-	if ld.src.MediaType == schema2.MediaTypeForeignLayer && len(ld.src.URLs) > 0 {
+	if isForeignLayer(ld.src.MediaType) && len(ld.src.URLs) > 0 {
 		return ld.src
 	}
 	return distribution.Descriptor{}
 }
 
+// isForeignLayer returns whether a layer is a foreign layer, which is
+// downloaded from its URLs rather than from the registry.
+func isForeignLayer(mediaType string) bool {
+	switch mediaType {
+	case schema2.MediaTypeForeignLayer, specs.MediaTypeImageLayerNonDistributable, specs.MediaTypeImageLayerNonDistributableGzip:
+		return true
+	}
+	return false
+}
+
 func (ld *v2LayerDescriptor) open(ctx context.Context) (distribution.ReadSeekCloser, error) {
 	blobs := ld.repo.Blobs(ctx)
 	rsc, err := blobs.Open(ctx, ld.digest)
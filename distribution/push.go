@@ -2,13 +2,13 @@ package distribution // import "github.com/docker/docker/distribution"
 
 import (
 	"bufio"
-	"compress/gzip"
 	"context"
 	"fmt"
 	"io"
 
 	"github.com/docker/distribution/reference"
 	"github.com/docker/docker/distribution/metadata"
+	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/progress"
 	"github.com/docker/docker/registry"
 	"github.com/sirupsen/logrus"
@@ -71,9 +71,11 @@ func Push(ctx context.Context, ref reference.Named, imagePushConfig *ImagePushCo
 
 	progress.Messagef(imagePushConfig.ProgressOutput, "", "The push refers to repository [%s]", repoInfo.Name.Name())
 
-	associations := imagePushConfig.ReferenceStore.ReferencesByName(repoInfo.Name)
-	if len(associations) == 0 {
-		return fmt.Errorf("An image does not exist locally with the tag: %s", reference.FamiliarName(repoInfo.Name))
+	if len(imagePushConfig.ManifestListImages) == 0 {
+		associations := imagePushConfig.ReferenceStore.ReferencesByName(repoInfo.Name)
+		if len(associations) == 0 {
+			return fmt.Errorf("An image does not exist locally with the tag: %s", reference.FamiliarName(repoInfo.Name))
+		}
 	}
 
 	var (
@@ -91,7 +93,7 @@ func Push(ctx context.Context, ref reference.Named, imagePushConfig *ImagePushCo
 	)
 
 	for _, endpoint := range endpoints {
-		if imagePushConfig.RequireSchema2 && endpoint.Version == registry.APIVersion1 {
+		if (imagePushConfig.RequireSchema2 || len(imagePushConfig.ManifestListImages) > 0) && endpoint.Version == registry.APIVersion1 {
 			continue
 		}
 		if confirmedV2 && endpoint.Version == registry.APIVersion1 {
@@ -158,19 +160,30 @@ func Push(ctx context.Context, ref reference.Named, imagePushConfig *ImagePushCo
 // is finished. This allows the caller to make sure the goroutine finishes
 // before it releases any resources connected with the reader that was
 // passed in.
-func compress(in io.Reader) (io.ReadCloser, chan struct{}) {
+func compress(in io.Reader, compression archive.Compression) (io.ReadCloser, chan struct{}) {
 	compressionDone := make(chan struct{})
 
 	pipeReader, pipeWriter := io.Pipe()
 	// Use a bufio.Writer to avoid excessive chunking in HTTP request.
 	bufWriter := bufio.NewWriterSize(pipeWriter, compressionBufSize)
-	compressor := gzip.NewWriter(bufWriter)
 
 	go func() {
-		_, err := io.Copy(compressor, in)
-		if err == nil {
-			err = compressor.Close()
+		compressor, err := archive.CompressStream(bufWriter, compression)
+		if err != nil {
+			pipeWriter.CloseWithError(err)
+			close(compressionDone)
+			return
+		}
+		_, err = io.Copy(compressor, in)
+		if err != nil {
+			// The compressor must still be closed to release it. Close the
+			// pipe first, so that it fails to write its remaining output.
+			pipeWriter.CloseWithError(err)
+			compressor.Close()
+			close(compressionDone)
+			return
 		}
+		err = compressor.Close()
 		if err == nil {
 			err = bufWriter.Flush()
 		}
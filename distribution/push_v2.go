@@ -9,7 +9,9 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/containerd/containerd/platforms"
 	"github.com/docker/distribution"
+	"github.com/docker/distribution/manifest/manifestlist"
 	"github.com/docker/distribution/manifest/schema1"
 	"github.com/docker/distribution/manifest/schema2"
 	"github.com/docker/distribution/reference"
@@ -19,11 +21,13 @@ import (
 	"github.com/docker/docker/distribution/metadata"
 	"github.com/docker/docker/distribution/xfer"
 	"github.com/docker/docker/layer"
+	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/ioutils"
 	"github.com/docker/docker/pkg/progress"
 	"github.com/docker/docker/pkg/stringid"
 	"github.com/docker/docker/registry"
 	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/sirupsen/logrus"
 )
 
@@ -80,6 +84,14 @@ func (p *v2Pusher) Push(ctx context.Context) (err error) {
 }
 
 func (p *v2Pusher) pushV2Repository(ctx context.Context) (err error) {
+	if len(p.config.ManifestListImages) > 0 {
+		namedTagged, isNamedTagged := p.ref.(reference.NamedTagged)
+		if !isNamedTagged {
+			return errors.New("a manifest list can only be pushed with a tag")
+		}
+		return p.pushV2ManifestList(ctx, namedTagged)
+	}
+
 	if namedTagged, isNamedTagged := p.ref.(reference.NamedTagged); isNamedTagged {
 		imageID, err := p.config.ReferenceStore.Get(p.ref)
 		if err != nil {
@@ -114,30 +126,133 @@ func (p *v2Pusher) pushV2Repository(ctx context.Context) (err error) {
 func (p *v2Pusher) pushV2Tag(ctx context.Context, ref reference.NamedTagged, id digest.Digest) error {
 	logrus.Debugf("Pushing repository: %s", reference.FamiliarString(ref))
 
+	desc, _, err := p.pushV2Image(ctx, ref, id, ref.Tag())
+	if err != nil {
+		return err
+	}
+
+	progress.Messagef(p.config.ProgressOutput, "", "%s: digest: %s size: %d", ref.Tag(), desc.Digest, desc.Size)
+
+	if err := addDigestReference(p.config.ReferenceStore, ref, desc.Digest, id); err != nil {
+		return err
+	}
+
+	// Signal digest to the trust client so it can sign the
+	// push, if appropriate.
+	progress.Aux(p.config.ProgressOutput, apitypes.PushResult{Tag: ref.Tag(), Digest: desc.Digest.String(), Size: int(desc.Size)})
+
+	return nil
+}
+
+// pushV2ManifestList pushes the images of the manifest list by digest, and
+// the manifest list, or the OCI index, referencing them with the tag. The
+// layers shared by the images are pushed once, and the layers known to be in
+// other repositories of the registry are mounted.
+func (p *v2Pusher) pushV2ManifestList(ctx context.Context, ref reference.NamedTagged) error {
+	logrus.Debugf("Pushing manifest list: %s", reference.FamiliarString(ref))
+
+	// check that the platforms are unique before pushing anything
+	seen := make(map[string]digest.Digest)
+	for _, id := range p.config.ManifestListImages {
+		imgConfig, err := p.config.ImageStore.Get(id)
+		if err != nil {
+			return fmt.Errorf("could not find image %s: %v", id, err)
+		}
+		platform, err := p.config.ImageStore.PlatformFromConfig(imgConfig)
+		if err != nil {
+			return fmt.Errorf("unable to get platform for image %s: %s", id, err)
+		}
+		key := platforms.Format(*platform) + ":" + platform.OSVersion
+		if other, ok := seen[key]; ok {
+			return fmt.Errorf("images %s and %s have the same platform %s", other, id, platforms.Format(*platform))
+		}
+		seen[key] = id
+	}
+
+	descriptors := make([]manifestlist.ManifestDescriptor, 0, len(p.config.ManifestListImages))
+	for _, id := range p.config.ManifestListImages {
+		desc, platform, err := p.pushV2Image(ctx, ref, id, "")
+		if err != nil {
+			return err
+		}
+		descriptors = append(descriptors, manifestlist.ManifestDescriptor{
+			Descriptor: desc,
+			Platform: manifestlist.PlatformSpec{
+				Architecture: platform.Architecture,
+				OS:           platform.OS,
+				OSVersion:    platform.OSVersion,
+				OSFeatures:   platform.OSFeatures,
+				Variant:      platform.Variant,
+			},
+		})
+	}
+
+	// the media type of the list is the OCI index if the manifests are OCI
+	// image manifests
+	list, err := manifestlist.FromDescriptors(descriptors)
+	if err != nil {
+		return err
+	}
+
+	manSvc, err := p.repo.Manifests(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err = manSvc.Put(ctx, list, distribution.WithTag(ref.Tag())); err != nil {
+		logrus.Warnf("failed to upload manifest list: %v", err)
+		return err
+	}
+
+	_, canonicalList, err := list.Payload()
+	if err != nil {
+		return err
+	}
+	listDigest := digest.FromBytes(canonicalList)
+	progress.Messagef(p.config.ProgressOutput, "", "%s: digest: %s size: %d", ref.Tag(), listDigest, len(canonicalList))
+
+	// Signal digest to the trust client so it can sign the
+	// push, if appropriate.
+	progress.Aux(p.config.ProgressOutput, apitypes.PushResult{Tag: ref.Tag(), Digest: listDigest.String(), Size: len(canonicalList)})
+
+	return nil
+}
+
+// pushV2Image pushes the layers and the manifest of an image, with the given
+// tag, or by digest if the tag is empty. It returns the descriptor of the
+// manifest and the platform of the image.
+func (p *v2Pusher) pushV2Image(ctx context.Context, ref reference.Named, id digest.Digest, tag string) (distribution.Descriptor, *ocispec.Platform, error) {
 	imgConfig, err := p.config.ImageStore.Get(id)
 	if err != nil {
-		return fmt.Errorf("could not find image from tag %s: %v", reference.FamiliarString(ref), err)
+		return distribution.Descriptor{}, nil, fmt.Errorf("could not find image from tag %s: %v", reference.FamiliarString(ref), err)
 	}
 
 	rootfs, err := p.config.ImageStore.RootFSFromConfig(imgConfig)
 	if err != nil {
-		return fmt.Errorf("unable to get rootfs for image %s: %s", reference.FamiliarString(ref), err)
+		return distribution.Descriptor{}, nil, fmt.Errorf("unable to get rootfs for image %s: %s", reference.FamiliarString(ref), err)
 	}
 
 	platform, err := p.config.ImageStore.PlatformFromConfig(imgConfig)
 	if err != nil {
-		return fmt.Errorf("unable to get platform for image %s: %s", reference.FamiliarString(ref), err)
+		return distribution.Descriptor{}, nil, fmt.Errorf("unable to get platform for image %s: %s", reference.FamiliarString(ref), err)
 	}
 
 	l, err := p.config.LayerStores[platform.OS].Get(rootfs.ChainID())
 	if err != nil {
-		return fmt.Errorf("failed to get top layer from image: %v", err)
+		return distribution.Descriptor{}, nil, fmt.Errorf("failed to get top layer from image: %v", err)
 	}
 	defer l.Release()
 
 	hmacKey, err := metadata.ComputeV2MetadataHMACKey(p.config.AuthConfig)
 	if err != nil {
-		return fmt.Errorf("failed to compute hmac key of auth config: %v", err)
+		return distribution.Descriptor{}, nil, fmt.Errorf("failed to compute hmac key of auth config: %v", err)
+	}
+
+	// zstd compressed layers are OCI layers, which cannot be referenced by
+	// schema2 manifests.
+	compression := p.config.LayerCompression
+	if compression == archive.Zstd && !p.config.OCIManifest {
+		logrus.Warnf("zstd layer compression requires the oci manifest format, compressing the layers of %s with gzip", reference.FamiliarString(ref))
+		compression = archive.Gzip
 	}
 
 	var descriptors []xfer.UploadDescriptor
@@ -150,6 +265,7 @@ func (p *v2Pusher) pushV2Tag(ctx context.Context, ref reference.NamedTagged, id
 		endpoint:          p.endpoint,
 		repo:              p.repo,
 		pushState:         &p.pushState,
+		compression:       compression,
 	}
 
 	// Loop bounds condition is to avoid pushing the base layer on Windows.
@@ -163,51 +279,64 @@ func (p *v2Pusher) pushV2Tag(ctx context.Context, ref reference.NamedTagged, id
 	}
 
 	if err := p.config.UploadManager.Upload(ctx, descriptors, p.config.ProgressOutput); err != nil {
-		return err
+		return distribution.Descriptor{}, nil, err
 	}
 
-	// Try schema2 first
-	builder := schema2.NewManifestBuilder(p.repo.Blobs(ctx), p.config.ConfigMediaType, imgConfig)
+	var builder distribution.ManifestBuilder
+	if p.config.OCIManifest {
+		annotations, err := ociAnnotations(imgConfig)
+		if err != nil {
+			return distribution.Descriptor{}, nil, err
+		}
+		builder = newOCIManifestBuilder(p.repo.Blobs(ctx), imgConfig, annotations)
+	} else {
+		builder = schema2.NewManifestBuilder(p.repo.Blobs(ctx), p.config.ConfigMediaType, imgConfig)
+	}
 	manifest, err := manifestFromBuilder(ctx, builder, descriptors)
 	if err != nil {
-		return err
+		return distribution.Descriptor{}, nil, err
 	}
 
 	manSvc, err := p.repo.Manifests(ctx)
 	if err != nil {
-		return err
+		return distribution.Descriptor{}, nil, err
 	}
 
-	putOptions := []distribution.ManifestServiceOption{distribution.WithTag(ref.Tag())}
+	var putOptions []distribution.ManifestServiceOption
+	if tag != "" {
+		putOptions = append(putOptions, distribution.WithTag(tag))
+	}
 	if _, err = manSvc.Put(ctx, manifest, putOptions...); err != nil {
-		logrus.Warnf("failed to upload schema2 manifest: %v", err)
-		return err
+		logrus.Warnf("failed to upload manifest: %v", err)
+		return distribution.Descriptor{}, nil, err
 	}
 
-	var canonicalManifest []byte
+	var (
+		mediaType         string
+		canonicalManifest []byte
+	)
 
 	switch v := manifest.(type) {
 	case *schema1.SignedManifest:
-		canonicalManifest = v.Canonical
+		mediaType, canonicalManifest = schema1.MediaTypeSignedManifest, v.Canonical
 	case *schema2.DeserializedManifest:
-		_, canonicalManifest, err = v.Payload()
+		mediaType, canonicalManifest, err = v.Payload()
 		if err != nil {
-			return err
+			return distribution.Descriptor{}, nil, err
+		}
+	case *ociManifest:
+		mediaType, canonicalManifest, err = v.Payload()
+		if err != nil {
+			return distribution.Descriptor{}, nil, err
 		}
 	}
 
-	manifestDigest := digest.FromBytes(canonicalManifest)
-	progress.Messagef(p.config.ProgressOutput, "", "%s: digest: %s size: %d", ref.Tag(), manifestDigest, len(canonicalManifest))
-
-	if err := addDigestReference(p.config.ReferenceStore, ref, manifestDigest, id); err != nil {
-		return err
+	desc := distribution.Descriptor{
+		MediaType: mediaType,
+		Size:      int64(len(canonicalManifest)),
+		Digest:    digest.FromBytes(canonicalManifest),
 	}
-
-	// Signal digest to the trust client so it can sign the
-	// push, if appropriate.
-	progress.Aux(p.config.ProgressOutput, apitypes.PushResult{Tag: ref.Tag(), Digest: manifestDigest.String(), Size: len(canonicalManifest)})
-
-	return nil
+	return desc, platform, nil
 }
 
 func manifestFromBuilder(ctx context.Context, builder distribution.ManifestBuilder, descriptors []xfer.UploadDescriptor) (distribution.Manifest, error) {
@@ -232,6 +361,8 @@ type v2PushDescriptor struct {
 	repo              distribution.Repository
 	pushState         *pushState
 	remoteDescriptor  distribution.Descriptor
+	// compression is the compression of the uncompressed layers
+	compression archive.Compression
 	// a set of digests whose presence has been checked in a target repository
 	checkedDigests map[digest.Digest]struct{}
 }
@@ -276,6 +407,8 @@ func (pd *v2PushDescriptor) Upload(ctx context.Context, progressOutput progress.
 	// Do we have any metadata associated with this layer's DiffID?
 	v2Metadata, err := pd.v2MetadataService.GetMetadata(diffID)
 	if err == nil {
+		// only the blobs with the same compression can be reused
+		v2Metadata = filterV2MetadataByMediaType(v2Metadata, pd.layerMediaType())
 		// check for blob existence in the target repository
 		descriptor, exists, err := pd.layerAlreadyExists(ctx, progressOutput, diffID, true, 1, v2Metadata)
 		if exists || err != nil {
@@ -329,7 +462,7 @@ func (pd *v2PushDescriptor) Upload(ctx context.Context, progressOutput progress.
 		case distribution.ErrBlobMounted:
 			progress.Updatef(progressOutput, pd.ID(), "Mounted from %s", err.From.Name())
 
-			err.Descriptor.MediaType = schema2.MediaTypeLayer
+			err.Descriptor.MediaType = pd.layerMediaType()
 
 			pd.pushState.Lock()
 			pd.pushState.confirmedV2 = true
@@ -340,6 +473,7 @@ func (pd *v2PushDescriptor) Upload(ctx context.Context, progressOutput progress.
 			if err := pd.v2MetadataService.TagAndAdd(diffID, pd.hmacKey, metadata.V2Metadata{
 				Digest:           err.Descriptor.Digest,
 				SourceRepository: pd.repoInfo.Name(),
+				MediaType:        v2MetadataMediaType(err.Descriptor.MediaType),
 			}); err != nil {
 				return distribution.Descriptor{}, xfer.DoNotRetry{Err: err}
 			}
@@ -428,7 +562,7 @@ func (pd *v2PushDescriptor) uploadUsingSession(
 
 	switch m := pd.layer.MediaType(); m {
 	case schema2.MediaTypeUncompressedLayer:
-		compressedReader, compressionDone := compress(reader)
+		compressedReader, compressionDone := compress(reader, pd.compressionOrDefault())
 		defer func(closer io.Closer) {
 			closer.Close()
 			<-compressionDone
@@ -461,13 +595,14 @@ func (pd *v2PushDescriptor) uploadUsingSession(
 	if err := pd.v2MetadataService.TagAndAdd(diffID, pd.hmacKey, metadata.V2Metadata{
 		Digest:           pushDigest,
 		SourceRepository: pd.repoInfo.Name(),
+		MediaType:        v2MetadataMediaType(pd.layerMediaType()),
 	}); err != nil {
 		return distribution.Descriptor{}, xfer.DoNotRetry{Err: err}
 	}
 
 	desc := distribution.Descriptor{
 		Digest:    pushDigest,
-		MediaType: schema2.MediaTypeLayer,
+		MediaType: pd.layerMediaType(),
 		Size:      nn,
 	}
 
@@ -480,6 +615,46 @@ func (pd *v2PushDescriptor) uploadUsingSession(
 	return desc, nil
 }
 
+// compressionOrDefault returns the compression of the uncompressed layers,
+// gzip if it is not set.
+func (pd *v2PushDescriptor) compressionOrDefault() archive.Compression {
+	if pd.compression == archive.Zstd {
+		return archive.Zstd
+	}
+	return archive.Gzip
+}
+
+// layerMediaType returns the media type of the pushed blob of the layer. The
+// layers which are stored compressed are always pushed as is, with gzip.
+func (pd *v2PushDescriptor) layerMediaType() string {
+	if pd.layer.MediaType() == schema2.MediaTypeUncompressedLayer && pd.compressionOrDefault() == archive.Zstd {
+		return ociMediaTypeImageLayerZstd
+	}
+	return schema2.MediaTypeLayer
+}
+
+// v2MetadataMediaType returns the media type stored in the V2 metadata of a
+// blob, which is empty for gzip compressed layers.
+func v2MetadataMediaType(mediaType string) string {
+	switch mediaType {
+	case schema2.MediaTypeLayer, schema2.MediaTypeForeignLayer, ocispec.MediaTypeImageLayerGzip, ocispec.MediaTypeImageLayerNonDistributableGzip:
+		return ""
+	}
+	return mediaType
+}
+
+// filterV2MetadataByMediaType returns the metadata of the blobs with the
+// given media type.
+func filterV2MetadataByMediaType(v2Metadata []metadata.V2Metadata, mediaType string) []metadata.V2Metadata {
+	var filtered []metadata.V2Metadata
+	for _, meta := range v2Metadata {
+		if meta.MediaType == v2MetadataMediaType(mediaType) {
+			filtered = append(filtered, meta)
+		}
+	}
+	return filtered
+}
+
 // layerAlreadyExists checks if the registry already knows about any of the metadata passed in the "metadata"
 // slice. If it finds one that the registry knows about, it returns the known digest and "true". If
 // "checkOtherRepositories" is true, stat will be performed also with digests mapped to any other repository
@@ -536,11 +711,12 @@ attempts:
 				if err := pd.v2MetadataService.TagAndAdd(diffID, pd.hmacKey, metadata.V2Metadata{
 					Digest:           desc.Digest,
 					SourceRepository: pd.repoInfo.Name(),
+					MediaType:        v2MetadataMediaType(pd.layerMediaType()),
 				}); err != nil {
 					return distribution.Descriptor{}, false, xfer.DoNotRetry{Err: err}
 				}
 			}
-			desc.MediaType = schema2.MediaTypeLayer
+			desc.MediaType = pd.layerMediaType()
 			exists = true
 			break attempts
 		case distribution.ErrBlobUnknown:
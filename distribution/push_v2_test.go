@@ -2,10 +2,14 @@ package distribution // import "github.com/docker/docker/distribution"
 
 import (
 	"context"
+	"errors"
+	"io/ioutil"
 	"net/http"
 	"net/url"
+	"os/exec"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/docker/distribution"
 	"github.com/docker/distribution/manifest/schema2"
@@ -14,10 +18,14 @@ import (
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/distribution/metadata"
 	"github.com/docker/docker/layer"
+	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/progress"
 	refstore "github.com/docker/docker/reference"
 	"github.com/docker/docker/registry"
 	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
 )
 
 func TestGetRepositoryMountCandidates(t *testing.T) {
@@ -738,3 +746,164 @@ func (s *progressSink) WriteProgress(p progress.Progress) error {
 	s.t.Logf("progress update: %#+v", p)
 	return nil
 }
+
+type layerMetadataService struct {
+	mockV2MetadataService
+	metadata []metadata.V2Metadata
+}
+
+func (m *layerMetadataService) GetMetadata(diffID layer.DiffID) ([]metadata.V2Metadata, error) {
+	return m.metadata, nil
+}
+
+func TestPushLayerCompression(t *testing.T) {
+	if _, err := exec.LookPath("zstd"); err != nil {
+		t.Skip("zstd not installed")
+	}
+	ctx := context.Background()
+	repo, closeRegistry := newTestRegistry(t, "user/app")
+	defer closeRegistry()
+	repoInfo, _ := reference.ParseNormalizedNamed("user/app")
+
+	// the layer was already pushed with gzip
+	gzipDesc, err := repo.Blobs(ctx).Put(ctx, schema2.MediaTypeLayer, []byte("gzip layer"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ms := &layerMetadataService{metadata: []metadata.V2Metadata{
+		{Digest: gzipDesc.Digest, SourceRepository: repoInfo.Name()},
+	}}
+
+	for _, tc := range []struct {
+		compression       archive.Compression
+		expectedMediaType string
+	}{
+		{compression: archive.Zstd, expectedMediaType: ociMediaTypeImageLayerZstd},
+		{compression: archive.Gzip, expectedMediaType: schema2.MediaTypeLayer},
+	} {
+		ms.added = nil
+		pd := &v2PushDescriptor{
+			repoInfo: repoInfo,
+			ref:      repoInfo,
+			layer: &storeLayer{
+				Layer: layer.EmptyLayer,
+			},
+			repo:              repo,
+			v2MetadataService: ms,
+			pushState:         &pushState{remoteLayers: make(map[layer.DiffID]distribution.Descriptor)},
+			checkedDigests:    make(map[digest.Digest]struct{}),
+			compression:       tc.compression,
+		}
+		desc, err := pd.Upload(ctx, &progressSink{t})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if desc.MediaType != tc.expectedMediaType {
+			t.Errorf("expected media type %s, got %s", tc.expectedMediaType, desc.MediaType)
+		}
+
+		if tc.compression == archive.Gzip {
+			// the gzip blob is reused
+			if desc.Digest != gzipDesc.Digest {
+				t.Errorf("expected the gzip blob %s to be reused, got %s", gzipDesc.Digest, desc.Digest)
+			}
+			continue
+		}
+
+		if desc.Digest == gzipDesc.Digest {
+			t.Fatalf("the gzip blob was reused for a zstd push")
+		}
+		blob, err := repo.Blobs(ctx).Get(ctx, desc.Digest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c := archive.DetectCompression(blob); c != archive.Zstd {
+			t.Errorf("expected the pushed blob to be compressed with zstd, got %s", c.Extension())
+		}
+		if len(ms.added) != 1 || ms.added[0].MediaType != ociMediaTypeImageLayerZstd {
+			t.Errorf("expected the zstd blob to be added to the metadata, got %v", ms.added)
+		}
+	}
+}
+
+func TestPushLayerCompressionFormat(t *testing.T) {
+	for _, tc := range []struct {
+		oci                      bool
+		expectedManifestType     string
+		expectedLayerMediaType   string
+		expectedLayerCompression archive.Compression
+	}{
+		// zstd layers cannot be referenced by schema2 manifests
+		{oci: false, expectedManifestType: schema2.MediaTypeManifest, expectedLayerMediaType: schema2.MediaTypeLayer, expectedLayerCompression: archive.Gzip},
+		{oci: true, expectedManifestType: ocispec.MediaTypeImageManifest, expectedLayerMediaType: ociMediaTypeImageLayerZstd, expectedLayerCompression: archive.Zstd},
+	} {
+		if _, err := exec.LookPath("zstd"); err != nil && tc.oci {
+			t.Log("zstd not installed")
+			continue
+		}
+		ctx := context.Background()
+		repo, closeRegistry := newTestRegistry(t, "user/app")
+		defer closeRegistry()
+
+		l := newTestPushLayer(t, "file", "content")
+		store := &testImageConfigStore{configs: make(map[digest.Digest][]byte)}
+		id, err := store.Put(testLayerImageConfig(t, l, nil))
+		assert.NilError(t, err)
+
+		ref, err := reference.ParseNormalizedNamed("user/app:latest")
+		assert.NilError(t, err)
+		lp := testPushLayerProvider{layers: map[layer.ChainID]PushLayer{l.ChainID(): l}}
+		p := newTestPusher(repo, ref, store, lp, tc.oci, archive.Zstd)
+		desc, _, err := p.pushV2Image(ctx, ref, id, "latest")
+		assert.NilError(t, err)
+		assert.Check(t, is.Equal(desc.MediaType, tc.expectedManifestType))
+
+		manSvc, err := repo.Manifests(ctx)
+		assert.NilError(t, err)
+		m, err := manSvc.Get(ctx, desc.Digest)
+		assert.NilError(t, err)
+		var layers []distribution.Descriptor
+		switch v := m.(type) {
+		case *schema2.DeserializedManifest:
+			layers = v.Layers
+		case *ociManifest:
+			layers = v.Layers
+		}
+		assert.Assert(t, is.Len(layers, 1))
+		assert.Check(t, is.Equal(layers[0].MediaType, tc.expectedLayerMediaType))
+		blob, err := repo.Blobs(ctx).Get(ctx, layers[0].Digest)
+		assert.NilError(t, err)
+		assert.Check(t, is.Equal(archive.DetectCompression(blob), tc.expectedLayerCompression))
+	}
+}
+
+type failingReader struct{ n int }
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.n <= 0 {
+		return 0, errors.New("read failed")
+	}
+	if len(p) > r.n {
+		p = p[:r.n]
+	}
+	r.n -= len(p)
+	return len(p), nil
+}
+
+func TestCompressReadError(t *testing.T) {
+	for _, compression := range []archive.Compression{archive.Gzip, archive.Zstd} {
+		if compression == archive.Zstd {
+			if _, err := exec.LookPath("zstd"); err != nil {
+				continue
+			}
+		}
+		r, compressionDone := compress(&failingReader{n: 1024 * 1024}, compression)
+		_, err := ioutil.ReadAll(r)
+		assert.Check(t, is.Error(err, "read failed"), compression.Extension())
+		select {
+		case <-compressionDone:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("compression %s did not complete in 5 seconds", compression.Extension())
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package distribution // import "github.com/docker/docker/distribution"
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/manifest/schema2"
+	"github.com/docker/distribution/reference"
+	"github.com/docker/distribution/registry/api/errcode"
+	v2 "github.com/docker/distribution/registry/api/v2"
+	"github.com/docker/distribution/registry/client"
+	"github.com/docker/docker/distribution/metadata"
+	"github.com/docker/docker/distribution/trust"
+	"github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// verifySignatures checks the manifest against the rule of the trust policy
+// for ref. If the rule requires signatures, the signatures of the manifest
+// are fetched from the repository, and the ones which are valid are
+// returned.
+func (p *v2Puller) verifySignatures(ctx context.Context, ref reference.Named, manifest distribution.Manifest) ([]metadata.ImageSignature, error) {
+	rule := p.config.TrustPolicy.Rule(ref)
+	if !rule.RequiresSignature() {
+		return nil, rule.Check(ref)
+	}
+
+	_, payload, err := manifest.Payload()
+	if err != nil {
+		return nil, err
+	}
+	dgst := digest.FromBytes(payload)
+	if digested, isDigested := ref.(reference.Canonical); isDigested && digested.Digest() != dgst {
+		return nil, errors.Errorf("manifest digest %s does not match the reference digest %s", dgst, digested.Digest())
+	}
+
+	signatures, err := fetchSignatures(ctx, p.repo, dgst)
+	if err != nil {
+		return nil, err
+	}
+	verified, err := rule.Verify(ref, dgst, signatures)
+	if err != nil {
+		return nil, err
+	}
+	logrus.Debugf("Verified %d signature(s) of %s@%s", len(verified), reference.FamiliarName(ref), dgst)
+
+	imageSignatures := make([]metadata.ImageSignature, 0, len(verified))
+	for _, s := range verified {
+		imageSignatures = append(imageSignatures, metadata.ImageSignature{
+			Repository: ref.Name(),
+			Digest:     dgst,
+			Payload:    s.Payload,
+			Signature:  s.Signature,
+		})
+	}
+	return imageSignatures, nil
+}
+
+// fetchSignatures returns the signatures of the manifest with the digest
+// dgst. They are the layers of the manifest tagged with trust.SignatureTag,
+// with the signature in the trust.SignatureAnnotation annotation. There are
+// no signatures if the tag does not exist.
+func fetchSignatures(ctx context.Context, repo distribution.Repository, dgst digest.Digest) ([]trust.Signature, error) {
+	manSvc, err := repo.Manifests(ctx)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := manSvc.Get(ctx, "", distribution.WithTag(trust.SignatureTag(dgst)))
+	if err != nil {
+		if isManifestUnknown(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "failed to fetch the signatures")
+	}
+
+	var layers []distribution.Descriptor
+	switch m := manifest.(type) {
+	case *ociManifest:
+		layers = m.Layers
+	case *schema2.DeserializedManifest:
+		layers = m.Layers
+	default:
+		return nil, errors.Errorf("unsupported signature manifest type %T", manifest)
+	}
+
+	bs := repo.Blobs(ctx)
+	var signatures []trust.Signature
+	for _, l := range layers {
+		encoded, ok := l.Annotations[trust.SignatureAnnotation]
+		if !ok {
+			continue
+		}
+		sig, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			logrus.Debugf("Ignoring invalid signature of %s: %v", dgst, err)
+			continue
+		}
+		payload, err := bs.Get(ctx, l.Digest)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to fetch a signature payload")
+		}
+		if digest.FromBytes(payload) != l.Digest {
+			return nil, errors.Errorf("signature payload does not match digest %s", l.Digest)
+		}
+		signatures = append(signatures, trust.Signature{Payload: payload, Signature: sig})
+	}
+	return signatures, nil
+}
+
+// isManifestUnknown returns whether err is the error of the registry for an
+// unknown manifest or tag.
+func isManifestUnknown(err error) bool {
+	switch v := err.(type) {
+	case errcode.Errors:
+		return len(v) > 0 && isManifestUnknown(v[0])
+	case errcode.Error:
+		return v.Code == v2.ErrorCodeManifestUnknown
+	case *client.UnexpectedHTTPResponseError:
+		return v.StatusCode == http.StatusNotFound
+	case distribution.ErrManifestUnknownRevision, distribution.ErrManifestUnknown:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+package distribution // import "github.com/docker/docker/distribution"
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/distribution/metadata"
+	"github.com/docker/docker/distribution/trust"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/progress"
+	digest "github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+// pushTestSignature signs the manifest dgst of the repository name with key,
+// and pushes the signature to repo.
+func pushTestSignature(ctx context.Context, t *testing.T, repo distribution.Repository, name string, dgst digest.Digest, key crypto.Signer) {
+	payload := []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":%q},"image":{"docker-manifest-digest":%q},"type":"cosign container image signature"},"optional":null}`, name, dgst))
+	hashed := sha256.Sum256(payload)
+	sig, err := key.Sign(rand.Reader, hashed[:], crypto.SHA256)
+	assert.NilError(t, err)
+
+	bs := repo.Blobs(ctx)
+	config, err := bs.Put(ctx, ocispec.MediaTypeImageConfig, []byte("{}"))
+	assert.NilError(t, err)
+	config.MediaType = ocispec.MediaTypeImageConfig
+	layer, err := bs.Put(ctx, "application/vnd.dev.cosign.simplesigning.v1+json", payload)
+	assert.NilError(t, err)
+	layer.MediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+	layer.Annotations = map[string]string{trust.SignatureAnnotation: base64.StdEncoding.EncodeToString(sig)}
+
+	m, err := newOCIManifest(ociManifestContent{Config: config, Layers: []distribution.Descriptor{layer}})
+	assert.NilError(t, err)
+	manSvc, err := repo.Manifests(ctx)
+	assert.NilError(t, err)
+	_, err = manSvc.Put(ctx, m, distribution.WithTag(trust.SignatureTag(dgst)))
+	assert.NilError(t, err)
+}
+
+func TestPullSignedImage(t *testing.T) {
+	ctx := context.Background()
+	repo, closeRegistry := newTestRegistry(t, "test/image")
+	defer closeRegistry()
+
+	tmpDir, err := ioutil.TempDir("", "pull-signed-image")
+	assert.NilError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	assert.NilError(t, err)
+	keyPath := filepath.Join(tmpDir, "key.pub")
+	assert.NilError(t, ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0644))
+	policyJSON, err := json.Marshal(trust.Policy{
+		Default: trust.Rule{Type: trust.RuleAccept},
+		Rules:   []trust.Rule{{Scope: "test/image", Type: trust.RuleSigned, Keys: []string{keyPath}}},
+	})
+	assert.NilError(t, err)
+	policyPath := filepath.Join(tmpDir, "policy.json")
+	assert.NilError(t, ioutil.WriteFile(policyPath, policyJSON, 0644))
+	policy, err := trust.LoadPolicy(policyPath)
+	assert.NilError(t, err)
+
+	m := pushTestOCIManifest(ctx, t, repo, testImageConfig(t, "amd64", "", nil), "latest")
+	_, payload, err := m.Payload()
+	assert.NilError(t, err)
+	dgst := digest.FromBytes(payload)
+
+	ref, err := reference.ParseNormalizedNamed("test/image:latest")
+	assert.NilError(t, err)
+
+	metadataStore, err := metadata.NewFSMetadataStore(filepath.Join(tmpDir, "metadata"))
+	assert.NilError(t, err)
+	signatureService := metadata.NewSignatureService(metadataStore)
+	pull := func() (*testImageConfigStore, error) {
+		store := &testImageConfigStore{configs: make(map[digest.Digest][]byte)}
+		p := &v2Puller{
+			signatureService: signatureService,
+			config: &ImagePullConfig{
+				Config: Config{
+					ProgressOutput: progress.DiscardOutput(),
+					ImageStore:     store,
+				},
+				Schema2Types: ImageTypes,
+				TrustPolicy:  policy,
+			},
+			repo: repo,
+		}
+		_, err := p.pullV2Tag(ctx, ref, nil)
+		return store, err
+	}
+
+	// the image is not signed
+	store, err := pull()
+	assert.Check(t, is.ErrorContains(err, "no signature found"))
+	assert.Check(t, errdefs.IsForbidden(err))
+	assert.Check(t, is.Len(store.configs, 0))
+
+	// the image is signed with another key
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	pushTestSignature(ctx, t, repo, "test/image", dgst, otherKey)
+	store, err = pull()
+	assert.Check(t, is.ErrorContains(err, "signature does not match any trusted key"))
+	assert.Check(t, errdefs.IsForbidden(err))
+	assert.Check(t, is.Len(store.configs, 0))
+
+	pushTestSignature(ctx, t, repo, "test/image", dgst, key)
+	store, err = pull()
+	assert.NilError(t, err)
+	assert.Assert(t, is.Len(store.configs, 1))
+	for id := range store.configs {
+		signatures, err := signatureService.Get(id)
+		assert.NilError(t, err)
+		assert.Assert(t, is.Len(signatures, 1))
+		assert.Check(t, is.Equal(signatures[0].Repository, "docker.io/test/image"))
+		assert.Check(t, is.Equal(signatures[0].Digest, dgst))
+	}
+}
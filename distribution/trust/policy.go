@@ -0,0 +1,191 @@
+// Package trust implements the image trust policy of the daemon, which
+// decides, per registry, namespace or repository, whether images are
+// accepted, rejected, or must be signed with one of the configured keys.
+package trust // import "github.com/docker/docker/distribution/trust"
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/errdefs"
+	"github.com/pkg/errors"
+)
+
+// RuleType is the type of a rule of the trust policy.
+type RuleType string
+
+const (
+	// RuleAccept accepts the images, signed or not.
+	RuleAccept RuleType = "accept"
+	// RuleReject rejects the images.
+	RuleReject RuleType = "reject"
+	// RuleSigned accepts the images signed with one of the keys of the rule.
+	RuleSigned RuleType = "signed"
+)
+
+// Rule is a rule of the trust policy.
+type Rule struct {
+	// Scope is the registry (for example "registry.example.com"), the
+	// namespace (for example "docker.io/library") or the repository (for
+	// example "docker.io/library/busybox") the rule applies to. It is empty
+	// for the default rule.
+	Scope string `json:"scope,omitempty"`
+	// Type is the type of the rule. The images are accepted if it is empty.
+	Type RuleType `json:"type,omitempty"`
+	// Keys are the paths of the PEM encoded public keys the images must be
+	// signed with, for the RuleSigned rules.
+	Keys []string `json:"keys,omitempty"`
+
+	publicKeys []crypto.PublicKey
+}
+
+// Policy is the trust policy of the daemon. The rule with the longest scope
+// matching the name of a repository applies to its images, or the default
+// rule if no scope matches.
+type Policy struct {
+	Default Rule   `json:"default"`
+	Rules   []Rule `json:"rules,omitempty"`
+}
+
+// acceptRule is the rule of the images when there is no trust policy.
+var acceptRule = &Rule{Type: RuleAccept}
+
+// LoadPolicy reads the trust policy from the JSON file at path, and the
+// public keys of its rules.
+func LoadPolicy(path string) (*Policy, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read trust policy")
+	}
+	var p Policy
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse trust policy %s", path)
+	}
+	if p.Default.Scope != "" {
+		return nil, errors.Errorf("invalid trust policy %s: the default rule cannot have a scope", path)
+	}
+	if err := p.Default.load(); err != nil {
+		return nil, errors.Wrapf(err, "invalid trust policy %s", path)
+	}
+	scopes := make(map[string]struct{})
+	for i := range p.Rules {
+		r := &p.Rules[i]
+		scope, err := normalizeScope(r.Scope)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid trust policy %s", path)
+		}
+		if _, ok := scopes[scope]; ok {
+			return nil, errors.Errorf("invalid trust policy %s: duplicate scope %s", path, scope)
+		}
+		scopes[scope] = struct{}{}
+		r.Scope = scope
+		if err := r.load(); err != nil {
+			return nil, errors.Wrapf(err, "invalid trust policy %s: scope %s", path, scope)
+		}
+	}
+	return &p, nil
+}
+
+// normalizeScope returns the fully qualified name of a scope. A scope
+// without a "/" is a registry.
+func normalizeScope(scope string) (string, error) {
+	if scope == "" {
+		return "", errors.New("empty scope")
+	}
+	if !strings.Contains(scope, "/") {
+		return scope, nil
+	}
+	named, err := reference.ParseNormalizedNamed(scope)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid scope %s", scope)
+	}
+	if !reference.IsNameOnly(named) {
+		return "", errors.Errorf("invalid scope %s: cannot have a tag or a digest", scope)
+	}
+	return named.Name(), nil
+}
+
+// load validates the type of the rule and reads its public keys.
+func (r *Rule) load() error {
+	switch r.Type {
+	case "":
+		r.Type = RuleAccept
+	case RuleAccept, RuleReject:
+	case RuleSigned:
+		if len(r.Keys) == 0 {
+			return errors.Errorf("a %s rule requires at least one key", r.Type)
+		}
+	default:
+		return errors.Errorf("invalid rule type %q: must be %s, %s or %s", r.Type, RuleAccept, RuleReject, RuleSigned)
+	}
+	if r.Type != RuleSigned && len(r.Keys) > 0 {
+		return errors.Errorf("a %s rule cannot have keys", r.Type)
+	}
+	for _, path := range r.Keys {
+		key, err := loadPublicKey(path)
+		if err != nil {
+			return err
+		}
+		r.publicKeys = append(r.publicKeys, key)
+	}
+	return nil
+}
+
+// loadPublicKey reads a PEM encoded PKIX public key.
+func loadPublicKey(path string) (crypto.PublicKey, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read public key")
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errors.Errorf("no PEM data in public key %s", path)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse public key %s", path)
+	}
+	if !isSupportedKey(key) {
+		return nil, errors.Errorf("unsupported public key type %T in %s", key, path)
+	}
+	return key, nil
+}
+
+// Rule returns the rule which applies to the images of the repository of ref.
+// Every image is accepted if the policy is nil.
+func (p *Policy) Rule(ref reference.Named) *Rule {
+	if p == nil {
+		return acceptRule
+	}
+	name := ref.Name()
+	rule := &p.Default
+	for i := range p.Rules {
+		r := &p.Rules[i]
+		if name != r.Scope && !strings.HasPrefix(name, r.Scope+"/") {
+			continue
+		}
+		if rule == &p.Default || len(r.Scope) > len(rule.Scope) {
+			rule = r
+		}
+	}
+	return rule
+}
+
+// Check returns an errdefs.Forbidden error if the rule rejects the images.
+func (r *Rule) Check(ref reference.Named) error {
+	if r.Type == RuleReject {
+		return errdefs.Forbidden(fmt.Errorf("images from %s are rejected by the trust policy", reference.FamiliarName(ref)))
+	}
+	return nil
+}
+
+// RequiresSignature returns whether the images must be signed.
+func (r *Rule) RequiresSignature() bool {
+	return r.Type == RuleSigned
+}
@@ -0,0 +1,132 @@
+package trust // import "github.com/docker/docker/distribution/trust"
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/distribution/reference"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+// writePublicKey writes the PEM encoded public key to a file in dir, and
+// returns its path.
+func writePublicKey(t *testing.T, dir, name string, key crypto.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	assert.NilError(t, err)
+	path := filepath.Join(dir, name)
+	assert.NilError(t, ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0644))
+	return path
+}
+
+// writePolicy writes the policy to a file in dir, and returns its path.
+func writePolicy(t *testing.T, dir string, p Policy) string {
+	b, err := json.Marshal(p)
+	assert.NilError(t, err)
+	path := filepath.Join(dir, "policy.json")
+	assert.NilError(t, ioutil.WriteFile(path, b, 0644))
+	return path
+}
+
+func TestLoadPolicy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trust-policy")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	keyPath := writePublicKey(t, dir, "key.pub", key.Public())
+
+	p, err := LoadPolicy(writePolicy(t, dir, Policy{
+		Default: Rule{Type: RuleReject},
+		Rules: []Rule{
+			{Scope: "registry.example.com"},
+			{Scope: "library/busybox", Type: RuleSigned, Keys: []string{keyPath}},
+		},
+	}))
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(p.Rules[0].Type, RuleAccept))
+	assert.Check(t, is.Equal(p.Rules[1].Scope, "docker.io/library/busybox"))
+	assert.Check(t, is.Len(p.Rules[1].publicKeys, 1))
+
+	for _, tc := range []struct {
+		policy        Policy
+		expectedError string
+	}{
+		{
+			policy:        Policy{Default: Rule{Type: "maybe"}},
+			expectedError: `invalid rule type "maybe"`,
+		},
+		{
+			policy:        Policy{Default: Rule{Scope: "docker.io"}},
+			expectedError: "the default rule cannot have a scope",
+		},
+		{
+			policy:        Policy{Rules: []Rule{{Type: RuleAccept}}},
+			expectedError: "empty scope",
+		},
+		{
+			policy:        Policy{Rules: []Rule{{Scope: "docker.io/library/busybox:latest"}}},
+			expectedError: "cannot have a tag or a digest",
+		},
+		{
+			policy:        Policy{Rules: []Rule{{Scope: "library/busybox"}, {Scope: "docker.io/library/busybox"}}},
+			expectedError: "duplicate scope docker.io/library/busybox",
+		},
+		{
+			policy:        Policy{Rules: []Rule{{Scope: "docker.io", Type: RuleSigned}}},
+			expectedError: "requires at least one key",
+		},
+		{
+			policy:        Policy{Rules: []Rule{{Scope: "docker.io", Type: RuleAccept, Keys: []string{keyPath}}}},
+			expectedError: "cannot have keys",
+		},
+		{
+			policy:        Policy{Rules: []Rule{{Scope: "docker.io", Type: RuleSigned, Keys: []string{filepath.Join(dir, "missing.pub")}}}},
+			expectedError: "failed to read public key",
+		},
+	} {
+		_, err := LoadPolicy(writePolicy(t, dir, tc.policy))
+		assert.Check(t, is.ErrorContains(err, tc.expectedError))
+	}
+}
+
+func TestPolicyRule(t *testing.T) {
+	p := &Policy{
+		Default: Rule{Type: RuleReject},
+		Rules: []Rule{
+			{Scope: "docker.io/library", Type: RuleAccept},
+			{Scope: "docker.io/library/busybox", Type: RuleSigned},
+			{Scope: "registry.example.com", Type: RuleAccept},
+		},
+	}
+	for _, tc := range []struct {
+		ref      string
+		expected RuleType
+	}{
+		{ref: "busybox:latest", expected: RuleSigned},
+		{ref: "busybox-extra", expected: RuleAccept},
+		{ref: "alpine", expected: RuleAccept},
+		{ref: "user/app", expected: RuleReject},
+		{ref: "registry.example.com/team/app", expected: RuleAccept},
+		{ref: "registry.example.com.evil/app", expected: RuleReject},
+	} {
+		ref, err := reference.ParseNormalizedNamed(tc.ref)
+		assert.NilError(t, err)
+		assert.Check(t, is.Equal(p.Rule(ref).Type, tc.expected), tc.ref)
+	}
+
+	var nilPolicy *Policy
+	ref, err := reference.ParseNormalizedNamed("busybox")
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(nilPolicy.Rule(ref).Type, RuleAccept))
+}
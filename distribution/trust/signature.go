@@ -0,0 +1,144 @@
+package trust // import "github.com/docker/docker/distribution/trust"
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/errdefs"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+)
+
+// SignatureAnnotation is the annotation of the layers of a signature
+// manifest which holds the base64 encoded signature of the layer, which is
+// the signed payload.
+const SignatureAnnotation = "dev.cosignproject.cosign/signature"
+
+// payloadTypes are the types of the signed payloads which are accepted.
+var payloadTypes = map[string]bool{
+	"cosign container image signature": true,
+	"atomic container signature":       true,
+}
+
+// Signature is a detached signature of an image manifest.
+type Signature struct {
+	// Payload is the signed payload, in the simple signing format, which
+	// holds the digest of the manifest and the repository of the image.
+	Payload []byte `json:"payload"`
+	// Signature is the signature of the SHA-256 digest of the payload.
+	Signature []byte `json:"signature"`
+}
+
+// payload is the content of a simple signing payload.
+type payload struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest digest.Digest `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+}
+
+// SignatureTag returns the tag of the manifest holding the signatures of the
+// manifest with the digest dgst, in the repository of the manifest.
+func SignatureTag(dgst digest.Digest) string {
+	return fmt.Sprintf("%s-%s.sig", dgst.Algorithm(), dgst.Hex())
+}
+
+// Verify returns the signatures of the manifest with the digest dgst, in the
+// repository of ref, which are valid for the rule. It returns an
+// errdefs.Forbidden error if the rule rejects the image, or if the rule
+// requires a signature and none is valid.
+func (r *Rule) Verify(ref reference.Named, dgst digest.Digest, signatures []Signature) ([]Signature, error) {
+	if err := r.Check(ref); err != nil {
+		return nil, err
+	}
+	if !r.RequiresSignature() {
+		return nil, nil
+	}
+	var (
+		verified []Signature
+		lastErr  error
+	)
+	for _, s := range signatures {
+		if err := r.verify(ref, dgst, s); err != nil {
+			lastErr = err
+			continue
+		}
+		verified = append(verified, s)
+	}
+	if len(verified) == 0 {
+		if lastErr == nil {
+			lastErr = errors.New("no signature found")
+		}
+		return nil, errdefs.Forbidden(errors.Wrapf(lastErr, "image %s@%s is not signed with a trusted key", reference.FamiliarName(ref), dgst))
+	}
+	return verified, nil
+}
+
+// verify checks that the payload of a signature is for the manifest, and
+// that the signature is from one of the keys of the rule.
+func (r *Rule) verify(ref reference.Named, dgst digest.Digest, s Signature) error {
+	var p payload
+	if err := json.Unmarshal(s.Payload, &p); err != nil {
+		return errors.Wrap(err, "invalid signature payload")
+	}
+	if !payloadTypes[p.Critical.Type] {
+		return errors.Errorf("invalid signature payload type %q", p.Critical.Type)
+	}
+	if p.Critical.Image.DockerManifestDigest != dgst {
+		return errors.Errorf("signature is for manifest %s", p.Critical.Image.DockerManifestDigest)
+	}
+	identity, err := reference.ParseNormalizedNamed(p.Critical.Identity.DockerReference)
+	if err != nil {
+		return errors.Wrap(err, "invalid signature identity")
+	}
+	if identity.Name() != ref.Name() {
+		return errors.Errorf("signature is for repository %s", reference.FamiliarName(identity))
+	}
+
+	hashed := sha256.Sum256(s.Payload)
+	for _, key := range r.publicKeys {
+		if verifySignature(key, hashed[:], s.Signature) {
+			return nil
+		}
+	}
+	return errors.New("signature does not match any trusted key")
+}
+
+// isSupportedKey returns whether signatures can be verified with key.
+func isSupportedKey(key crypto.PublicKey) bool {
+	switch key.(type) {
+	case *ecdsa.PublicKey, *rsa.PublicKey:
+		return true
+	}
+	return false
+}
+
+// verifySignature verifies an ASN.1 encoded ECDSA signature, or a PKCS #1
+// v1.5 RSA signature, of a SHA-256 digest.
+func verifySignature(key crypto.PublicKey, hashed, sig []byte) bool {
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		var esig struct {
+			R, S *big.Int
+		}
+		if rest, err := asn1.Unmarshal(sig, &esig); err != nil || len(rest) != 0 {
+			return false
+		}
+		return ecdsa.Verify(k, hashed, esig.R, esig.S)
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, hashed, sig) == nil
+	}
+	return false
+}
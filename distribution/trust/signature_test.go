@@ -0,0 +1,113 @@
+package trust // import "github.com/docker/docker/distribution/trust"
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/errdefs"
+	digest "github.com/opencontainers/go-digest"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+)
+
+// testPayload returns a simple signing payload for the manifest dgst in the
+// repository name.
+func testPayload(name string, dgst digest.Digest) []byte {
+	return []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":%q},"image":{"docker-manifest-digest":%q},"type":"cosign container image signature"},"optional":null}`, name, dgst))
+}
+
+func TestVerify(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NilError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+
+	sign := func(key crypto.Signer, payload []byte) Signature {
+		hashed := sha256.Sum256(payload)
+		sig, err := key.Sign(rand.Reader, hashed[:], crypto.SHA256)
+		assert.NilError(t, err)
+		return Signature{Payload: payload, Signature: sig}
+	}
+
+	ref, err := reference.ParseNormalizedNamed("busybox:latest")
+	assert.NilError(t, err)
+	dgst := digest.FromString("manifest")
+	rule := &Rule{Type: RuleSigned, publicKeys: []crypto.PublicKey{ecKey.Public(), rsaKey.Public()}}
+
+	for _, tc := range []struct {
+		doc           string
+		signatures    []Signature
+		expectedError string
+	}{
+		{
+			doc:        "ecdsa",
+			signatures: []Signature{sign(ecKey, testPayload("busybox", dgst))},
+		},
+		{
+			doc:        "rsa",
+			signatures: []Signature{sign(rsaKey, testPayload("docker.io/library/busybox", dgst))},
+		},
+		{
+			doc: "one valid signature",
+			signatures: []Signature{
+				sign(otherKey, testPayload("busybox", dgst)),
+				sign(ecKey, testPayload("busybox", dgst)),
+			},
+		},
+		{
+			doc:           "no signature",
+			expectedError: "no signature found",
+		},
+		{
+			doc:           "untrusted key",
+			signatures:    []Signature{sign(otherKey, testPayload("busybox", dgst))},
+			expectedError: "signature does not match any trusted key",
+		},
+		{
+			doc:           "other manifest",
+			signatures:    []Signature{sign(ecKey, testPayload("busybox", digest.FromString("other")))},
+			expectedError: "signature is for manifest",
+		},
+		{
+			doc:           "other repository",
+			signatures:    []Signature{sign(ecKey, testPayload("alpine", dgst))},
+			expectedError: "signature is for repository alpine",
+		},
+		{
+			doc:           "invalid payload",
+			signatures:    []Signature{sign(ecKey, []byte("busybox"))},
+			expectedError: "invalid signature payload",
+		},
+	} {
+		verified, err := rule.Verify(ref, dgst, tc.signatures)
+		if tc.expectedError == "" {
+			assert.Check(t, err, tc.doc)
+			assert.Check(t, is.Len(verified, 1), tc.doc)
+			continue
+		}
+		assert.Check(t, is.ErrorContains(err, tc.expectedError), tc.doc)
+		assert.Check(t, errdefs.IsForbidden(err), tc.doc)
+	}
+
+	verified, err := (&Rule{Type: RuleAccept}).Verify(ref, dgst, nil)
+	assert.Check(t, err)
+	assert.Check(t, is.Len(verified, 0))
+
+	_, err = (&Rule{Type: RuleReject}).Verify(ref, dgst, []Signature{sign(ecKey, testPayload("busybox", dgst))})
+	assert.Check(t, is.ErrorContains(err, "rejected by the trust policy"))
+	assert.Check(t, errdefs.IsForbidden(err))
+}
+
+func TestSignatureTag(t *testing.T) {
+	dgst := digest.Digest("sha256:4dca0fd5f424a31b03ab807cbae77eb32bf2d089eed1cee154b3afed458de0dc")
+	assert.Check(t, is.Equal(SignatureTag(dgst), "sha256-4dca0fd5f424a31b03ab807cbae77eb32bf2d089eed1cee154b3afed458de0dc.sig"))
+}
@@ -67,6 +67,8 @@ type Image struct {
 	History    []History `json:"history,omitempty"`
 	OSVersion  string    `json:"os.version,omitempty"`
 	OSFeatures []string  `json:"os.features,omitempty"`
+	// Variant is the variant of the architecture, such as "v7" for arm
+	Variant string `json:"variant,omitempty"`
 
 	// rawJSON caches the immutable JSON associated with this image.
 	rawJSON []byte
@@ -177,6 +179,7 @@ func NewChildImage(img *Image, child ChildConfig, os string) *Image {
 		History:    append(img.History, imgHistory),
 		OSFeatures: img.OSFeatures,
 		OSVersion:  img.OSVersion,
+		Variant:    img.Variant,
 	}
 }
 
@@ -212,7 +215,8 @@ func NewHistory(author, comment, createdBy string, isEmptyLayer bool) History {
 type Exporter interface {
 	Load(io.ReadCloser, io.Writer, bool) error
 	// TODO: Load(net.Context, io.ReadCloser, <- chan StatusMessage) error
-	Save([]string, io.Writer) error
+	// Save writes the images to the writer, in the given archive format.
+	Save(names []string, format string, outStream io.Writer) error
 }
 
 // NewFromJSON creates an Image configuration from json.
@@ -0,0 +1,405 @@
+package tarexport // import "github.com/docker/docker/image/tarexport"
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/containerd/containerd/platforms"
+	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/docker/distribution/manifest/schema2"
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/image"
+	"github.com/docker/docker/layer"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/progress"
+	"github.com/docker/docker/pkg/system"
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+)
+
+const (
+	ociIndexFileName = "index.json"
+	ociBlobsDirName  = "blobs"
+
+	// imageNameAnnotation is the annotation used by containerd for the full
+	// reference of an image, the OCI ref name annotation only holding the
+	// tag.
+	imageNameAnnotation = "io.containerd.image.name"
+
+	// mediaTypeImageLayerZstd is the media type of the OCI layers
+	// compressed with zstd.
+	mediaTypeImageLayerZstd = "application/vnd.oci.image.layer.v1.tar+zstd"
+)
+
+// ociLayerMediaTypes are the media types of the layers which can be loaded.
+// The compression of the layers is detected when they are decompressed.
+var ociLayerMediaTypes = map[string]bool{
+	ocispec.MediaTypeImageLayer:                     true,
+	ocispec.MediaTypeImageLayerGzip:                 true,
+	mediaTypeImageLayerZstd:                         true,
+	ocispec.MediaTypeImageLayerNonDistributable:     true,
+	ocispec.MediaTypeImageLayerNonDistributableGzip: true,
+	schema2.MediaTypeLayer:                          true,
+	schema2.MediaTypeUncompressedLayer:              true,
+	schema2.MediaTypeForeignLayer:                   true,
+}
+
+// saveOCI writes the images as an OCI image layout. Each reference of an
+// image has an entry in the index, annotated with its name; the images
+// without references have a single entry without annotations. The config
+// and the uncompressed layers are stored as blobs, by digest.
+func (s *saveSession) saveOCI(outStream io.Writer) error {
+	tempDir, err := ioutil.TempDir("", "docker-export-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tempDir)
+	s.outDir = tempDir
+	s.diffIDPaths = make(map[layer.DiffID]string)
+
+	index := ocispec.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		Manifests: []ocispec.Descriptor{},
+	}
+	for id, imageDescr := range s.images {
+		desc, err := s.saveOCIImage(id)
+		if err != nil {
+			return err
+		}
+		if len(imageDescr.refs) == 0 {
+			index.Manifests = append(index.Manifests, desc)
+		}
+		for _, ref := range imageDescr.refs {
+			d := desc
+			d.Annotations = map[string]string{
+				ocispec.AnnotationRefName: ref.Tag(),
+				imageNameAnnotation:       ref.String(),
+			}
+			index.Manifests = append(index.Manifests, d)
+		}
+		s.tarexporter.loggerImgEvent.LogImageEvent(id.String(), id.String(), "save")
+	}
+
+	if err := writeJSONFile(filepath.Join(tempDir, ocispec.ImageLayoutFile), ocispec.ImageLayout{Version: ocispec.ImageLayoutVersion}); err != nil {
+		return err
+	}
+	if err := writeJSONFile(filepath.Join(tempDir, ociIndexFileName), index); err != nil {
+		return err
+	}
+
+	fs, err := archive.Tar(tempDir, archive.Uncompressed)
+	if err != nil {
+		return err
+	}
+	defer fs.Close()
+
+	_, err = io.Copy(outStream, fs)
+	return err
+}
+
+// saveOCIImage writes the config, the layers and the manifest of an image as
+// blobs, and returns the descriptor of the manifest.
+func (s *saveSession) saveOCIImage(id image.ID) (ocispec.Descriptor, error) {
+	img := s.images[id].image
+	if len(img.RootFS.DiffIDs) == 0 {
+		return ocispec.Descriptor{}, fmt.Errorf("empty export - not implemented")
+	}
+
+	config := img.RawJSON()
+	manifest := ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		Config: ocispec.Descriptor{
+			MediaType: ocispec.MediaTypeImageConfig,
+			Digest:    id.Digest(),
+			Size:      int64(len(config)),
+		},
+		Layers: make([]ocispec.Descriptor, 0, len(img.RootFS.DiffIDs)),
+	}
+	if err := s.writeBlob(id.Digest(), config); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+
+	rootFS := *img.RootFS
+	for i := range img.RootFS.DiffIDs {
+		rootFS.DiffIDs = img.RootFS.DiffIDs[:i+1]
+		desc, err := s.saveOCILayer(rootFS.ChainID(), img.OperatingSystem())
+		if err != nil {
+			return ocispec.Descriptor{}, err
+		}
+		manifest.Layers = append(manifest.Layers, desc)
+	}
+
+	b, err := json.Marshal(manifest)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	dgst := digest.FromBytes(b)
+	if err := s.writeBlob(dgst, b); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	return ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    dgst,
+		Size:      int64(len(b)),
+		Platform: &ocispec.Platform{
+			Architecture: img.Architecture,
+			OS:           img.OperatingSystem(),
+			OSVersion:    img.OSVersion,
+			OSFeatures:   img.OSFeatures,
+		},
+	}, nil
+}
+
+// saveOCILayer writes a layer as an uncompressed blob, whose digest is the
+// DiffID of the layer.
+func (s *saveSession) saveOCILayer(id layer.ChainID, os string) (ocispec.Descriptor, error) {
+	l, err := s.lss[os].Get(id)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	defer layer.ReleaseAndLog(s.lss[os], l)
+
+	dgst := digest.Digest(l.DiffID())
+	blobPath := s.blobPath(dgst)
+	if _, exists := s.diffIDPaths[l.DiffID()]; !exists {
+		if err := s.writeLayerBlob(l, blobPath); err != nil {
+			return ocispec.Descriptor{}, err
+		}
+		s.diffIDPaths[l.DiffID()] = blobPath
+	}
+	fi, err := system.Stat(blobPath)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	return ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageLayer,
+		Digest:    dgst,
+		Size:      fi.Size(),
+	}, nil
+}
+
+func (s *saveSession) writeLayerBlob(l layer.Layer, blobPath string) error {
+	if err := os.MkdirAll(filepath.Dir(blobPath), 0755); err != nil {
+		return err
+	}
+	// Use system.CreateSequential rather than os.Create. This ensures sequential
+	// file access on Windows to avoid eating into MM standby list.
+	// On Linux, this equates to a regular os.Create.
+	f, err := system.CreateSequential(blobPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	arch, err := l.TarStream()
+	if err != nil {
+		return err
+	}
+	defer arch.Close()
+
+	if _, err := io.Copy(f, arch); err != nil {
+		return err
+	}
+	return system.Chtimes(blobPath, time.Unix(0, 0), time.Unix(0, 0))
+}
+
+func (s *saveSession) blobPath(dgst digest.Digest) string {
+	return filepath.Join(s.outDir, ociBlobsDirName, dgst.Algorithm().String(), dgst.Hex())
+}
+
+func (s *saveSession) writeBlob(dgst digest.Digest, b []byte) error {
+	p := s.blobPath(dgst)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(p, b, 0644); err != nil {
+		return err
+	}
+	return system.Chtimes(p, time.Unix(0, 0), time.Unix(0, 0))
+}
+
+func writeJSONFile(p string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(p, b, 0644); err != nil {
+		return err
+	}
+	return system.Chtimes(p, time.Unix(0, 0), time.Unix(0, 0))
+}
+
+// isOCILayout returns whether an extracted archive is an OCI image layout.
+func isOCILayout(dir string) bool {
+	p, err := safePath(dir, ocispec.ImageLayoutFile)
+	if err != nil {
+		return false
+	}
+	b, err := ioutil.ReadFile(p)
+	if err != nil {
+		return false
+	}
+	var layout ocispec.ImageLayout
+	return json.Unmarshal(b, &layout) == nil && layout.Version != ""
+}
+
+// ociLoad loads the images of an OCI image layout. The nested indexes, such
+// as multi-platform images, are resolved to the manifest which best matches
+// the platform of the daemon. The images are tagged with the references
+// found in the annotations of the index.
+func (l *tarexporter) ociLoad(tmpDir string, outStream io.Writer, progressOutput progress.Output) error {
+	var index ocispec.Index
+	if err := readOCIJSON(tmpDir, ociIndexFileName, &index); err != nil {
+		return err
+	}
+
+	var imageIDsStr string
+	var imageRefCount int
+	for _, desc := range index.Manifests {
+		manifestDesc, err := resolveOCIManifest(tmpDir, desc)
+		if err != nil {
+			return err
+		}
+		imgID, err := l.ociLoadImage(tmpDir, manifestDesc, progressOutput)
+		if err != nil {
+			return err
+		}
+		imageIDsStr += fmt.Sprintf("Loaded image ID: %s\n", imgID)
+
+		if ref := ociRefName(desc); ref != nil {
+			l.setLoadedTag(ref, imgID.Digest(), outStream)
+			outStream.Write([]byte(fmt.Sprintf("Loaded image: %s\n", reference.FamiliarString(ref))))
+			imageRefCount++
+		}
+		l.loggerImgEvent.LogImageEvent(imgID.String(), imgID.String(), "load")
+	}
+
+	if imageRefCount == 0 {
+		outStream.Write([]byte(imageIDsStr))
+	}
+	return nil
+}
+
+// resolveOCIManifest returns the descriptor of the image manifest of an
+// index entry, selecting the best match for the platform of the daemon in
+// nested indexes.
+func resolveOCIManifest(tmpDir string, desc ocispec.Descriptor) (ocispec.Descriptor, error) {
+	for {
+		switch desc.MediaType {
+		case ocispec.MediaTypeImageManifest, schema2.MediaTypeManifest:
+			return desc, nil
+		case ocispec.MediaTypeImageIndex, manifestlist.MediaTypeManifestList:
+		default:
+			return ocispec.Descriptor{}, fmt.Errorf("unsupported media type %q for %s", desc.MediaType, desc.Digest)
+		}
+
+		var index ocispec.Index
+		if err := readOCIBlob(tmpDir, desc.Digest, &index); err != nil {
+			return ocispec.Descriptor{}, err
+		}
+		matcher := platforms.Default()
+		var best *ocispec.Descriptor
+		for i, d := range index.Manifests {
+			if d.Platform != nil && !matcher.Match(*d.Platform) {
+				continue
+			}
+			if best == nil || d.Platform != nil && best.Platform != nil && matcher.Less(*d.Platform, *best.Platform) {
+				best = &index.Manifests[i]
+			}
+		}
+		if best == nil {
+			return ocispec.Descriptor{}, fmt.Errorf("no image for %s in %s", platforms.DefaultString(), desc.Digest)
+		}
+		desc = *best
+	}
+}
+
+func (l *tarexporter) ociLoadImage(tmpDir string, desc ocispec.Descriptor, progressOutput progress.Output) (image.ID, error) {
+	var manifest ocispec.Manifest
+	if err := readOCIBlob(tmpDir, desc.Digest, &manifest); err != nil {
+		return "", err
+	}
+	configPath, err := ociBlobPath(tmpDir, manifest.Config.Digest)
+	if err != nil {
+		return "", err
+	}
+	config, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return "", err
+	}
+	if actual := digest.FromBytes(config); actual != manifest.Config.Digest {
+		return "", fmt.Errorf("invalid config digest: expected %q, got %q", manifest.Config.Digest, actual)
+	}
+
+	layerPaths := make([]string, 0, len(manifest.Layers))
+	for _, d := range manifest.Layers {
+		if !ociLayerMediaTypes[d.MediaType] {
+			return "", fmt.Errorf("unsupported media type %q for layer %s", d.MediaType, d.Digest)
+		}
+		layerPath, err := ociBlobPath(tmpDir, d.Digest)
+		if err != nil {
+			return "", err
+		}
+		layerPaths = append(layerPaths, layerPath)
+	}
+	return l.loadImage(config, layerPaths, nil, progressOutput)
+}
+
+// ociRefName returns the reference of an index entry, from the containerd
+// image name annotation or from the OCI ref name annotation when it holds a
+// full reference. nil is returned if the entry has no tagged reference.
+func ociRefName(desc ocispec.Descriptor) reference.NamedTagged {
+	for _, a := range []string{imageNameAnnotation, ocispec.AnnotationRefName} {
+		name, ok := desc.Annotations[a]
+		if !ok {
+			continue
+		}
+		named, err := reference.ParseNormalizedNamed(name)
+		if err != nil {
+			continue
+		}
+		if ref, ok := named.(reference.NamedTagged); ok {
+			return ref
+		}
+	}
+	return nil
+}
+
+func ociBlobPath(tmpDir string, dgst digest.Digest) (string, error) {
+	if err := dgst.Validate(); err != nil {
+		return "", errors.Wrap(err, "invalid blob digest")
+	}
+	return safePath(tmpDir, filepath.Join(ociBlobsDirName, dgst.Algorithm().String(), dgst.Hex()))
+}
+
+func readOCIBlob(tmpDir string, dgst digest.Digest, v interface{}) error {
+	p, err := ociBlobPath(tmpDir, dgst)
+	if err != nil {
+		return err
+	}
+	return readJSONFile(p, v)
+}
+
+func readOCIJSON(tmpDir, name string, v interface{}) error {
+	p, err := safePath(tmpDir, name)
+	if err != nil {
+		return err
+	}
+	return readJSONFile(p, v)
+}
+
+func readJSONFile(p string, v interface{}) error {
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewDecoder(f).Decode(v)
+}
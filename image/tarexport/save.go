@@ -13,6 +13,7 @@ import (
 
 	"github.com/docker/distribution"
 	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/image/v1"
 	"github.com/docker/docker/layer"
@@ -37,7 +38,15 @@ type saveSession struct {
 	diffIDPaths map[layer.DiffID]string // cache every diffID blob to avoid duplicates
 }
 
-func (l *tarexporter) Save(names []string, outStream io.Writer) error {
+// Save writes the images to outStream, in the legacy Docker layout or as an
+// OCI image layout, depending on format.
+func (l *tarexporter) Save(names []string, format string, outStream io.Writer) error {
+	if format == "" {
+		format = FormatDocker
+	}
+	if format != FormatDocker && format != FormatOCI {
+		return errdefs.InvalidParameter(errors.Errorf("unsupported image archive format: %q", format))
+	}
 	images, err := l.parseNames(names)
 	if err != nil {
 		return err
@@ -45,7 +54,11 @@ func (l *tarexporter) Save(names []string, outStream io.Writer) error {
 
 	// Release all the image top layer references
 	defer l.releaseLayerReferences(images)
-	return (&saveSession{tarexporter: l, images: images}).save(outStream)
+	s := &saveSession{tarexporter: l, images: images}
+	if format == FormatOCI {
+		return s.saveOCI(outStream)
+	}
+	return s.save(outStream)
 }
 
 // parseNames will parse the image names to a map which contains image.ID to *imageDescriptor.
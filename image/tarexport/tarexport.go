@@ -15,6 +15,16 @@ const (
 	legacyRepositoriesFileName = "repositories"
 )
 
+// Formats of the archives written by Save.
+const (
+	// FormatDocker is the legacy Docker layout, with a manifest.json file
+	// and a directory per layer. It is the default.
+	FormatDocker = "docker"
+	// FormatOCI is the OCI image layout, with an index.json file and the
+	// blobs stored by digest.
+	FormatOCI = "oci"
+)
+
 type manifestItem struct {
 	Config       string
 	RepoTags     []string
@@ -0,0 +1,69 @@
+package image // import "github.com/docker/docker/integration/image"
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/versions"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+	"gotest.tools/skip"
+)
+
+// an image saved as an OCI image layout can be loaded back, with its tags
+func TestSaveLoadOCILayout(t *testing.T) {
+	skip.If(t, versions.LessThan(testEnv.DaemonAPIVersion(), "1.40"), "OCI image layout not supported before API v1.40")
+	skip.If(t, testEnv.OSType == "windows", "TODO: test on Windows")
+	defer setupTest(t)()
+	client := testEnv.APIClient()
+	ctx := context.Background()
+
+	insp, _, err := client.ImageInspectWithRaw(ctx, "busybox:latest")
+	assert.NilError(t, err)
+
+	rdr, err := client.ImageSaveWithFormat(ctx, []string{"busybox:latest"}, "oci")
+	assert.NilError(t, err)
+	b, err := ioutil.ReadAll(rdr)
+	rdr.Close()
+	assert.NilError(t, err)
+
+	var index ocispec.Index
+	var hasLayout bool
+	tr := tar.NewReader(bytes.NewReader(b))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NilError(t, err)
+		switch hdr.Name {
+		case ocispec.ImageLayoutFile:
+			hasLayout = true
+		case "index.json":
+			assert.NilError(t, json.NewDecoder(tr).Decode(&index))
+		}
+	}
+	assert.Check(t, hasLayout)
+	assert.Assert(t, is.Len(index.Manifests, 1))
+	assert.Check(t, is.Equal(index.Manifests[0].Annotations[ocispec.AnnotationRefName], "latest"))
+
+	_, err = client.ImageRemove(ctx, "busybox:latest", types.ImageRemoveOptions{})
+	assert.NilError(t, err)
+	resp, err := client.ImageLoad(ctx, bytes.NewReader(b), true)
+	assert.NilError(t, err)
+	out, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.NilError(t, err)
+	assert.Check(t, is.Contains(string(out), "Loaded image: busybox:latest"))
+
+	loaded, _, err := client.ImageInspectWithRaw(ctx, "busybox:latest")
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(loaded.ID, insp.ID))
+}
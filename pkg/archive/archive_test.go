@@ -4,6 +4,8 @@ import (
 	"archive/tar"
 	"bytes"
 	"compress/gzip"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -135,6 +137,47 @@ func TestDecompressStreamXz(t *testing.T) {
 	testDecompressStream(t, "xz", "xz -f")
 }
 
+func TestDecompressStreamZstd(t *testing.T) {
+	if _, err := exec.LookPath("zstd"); err != nil {
+		t.Skip("zstd not installed")
+	}
+	testDecompressStream(t, "zst", "zstd -f -q")
+}
+
+func TestCompressStreamZstd(t *testing.T) {
+	if _, err := exec.LookPath("zstd"); err != nil {
+		t.Skip("zstd not installed")
+	}
+	var dest bytes.Buffer
+	w, err := CompressStream(&dest, Zstd)
+	if err != nil {
+		t.Fatalf("Failed to create the zstd compression stream: %v", err)
+	}
+	content := bytes.Repeat([]byte("zstd"), 1024)
+	if _, err := w.Write(content); err != nil {
+		t.Fatalf("Failed to write the compression stream: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close the compression stream: %v", err)
+	}
+	if compression := DetectCompression(dest.Bytes()); compression != Zstd {
+		t.Fatalf("Expected the compressed stream to be detected as zstd, got %s", compression.Extension())
+	}
+
+	r, err := DecompressStream(&dest)
+	if err != nil {
+		t.Fatalf("Failed to decompress the zstd stream: %v", err)
+	}
+	defer r.Close()
+	decompressed, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("Failed to read the decompressed stream: %v", err)
+	}
+	if !bytes.Equal(decompressed, content) {
+		t.Fatalf("The decompressed stream differs from the original content")
+	}
+}
+
 func TestCompressStreamXzUnsupported(t *testing.T) {
 	dest, err := os.Create(tmp + "dest")
 	if err != nil {
@@ -211,6 +254,14 @@ func TestExtensionXz(t *testing.T) {
 	}
 }
 
+func TestExtensionZstd(t *testing.T) {
+	compression := Zstd
+	output := compression.Extension()
+	if output != "tar.zst" {
+		t.Fatalf("The extension of a zstd archive should be 'tar.zst'")
+	}
+}
+
 func TestCmdStreamLargeStderr(t *testing.T) {
 	cmd := exec.Command("sh", "-c", "dd if=/dev/zero bs=1k count=1000 of=/dev/stderr; echo hello")
 	out, err := cmdStream(cmd, nil)
@@ -264,6 +315,38 @@ func TestCmdStreamGood(t *testing.T) {
 	}
 }
 
+type errWriter struct{}
+
+func (errWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestCmdWriterOutputError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd := exec.CommandContext(ctx, "cat")
+	w, err := cmdWriter(cmd, errWriter{}, cancel)
+	assert.NilError(t, err)
+
+	errCh := make(chan error)
+	go func() {
+		// the command is killed once its output fails, so writing to it
+		// eventually fails too
+		for {
+			if _, err := w.Write(make([]byte, 32*1024)); err != nil {
+				break
+			}
+		}
+		errCh <- w.Close()
+	}()
+	select {
+	case err := <-errCh:
+		assert.Check(t, err != nil)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Command did not complete in 5 seconds; probable deadlock")
+	}
+	assert.Check(t, cmd.ProcessState != nil)
+}
+
 func TestUntarPathWithInvalidDest(t *testing.T) {
 	tempFolder, err := ioutil.TempDir("", "docker-archive-test")
 	assert.NilError(t, err)
@@ -3,6 +3,7 @@ package restartmanager // import "github.com/docker/docker/restartmanager"
 import (
 	"errors"
 	"fmt"
+	"math/rand"
 	"sync"
 	"time"
 
@@ -10,9 +11,10 @@ import (
 )
 
 const (
-	backoffMultiplier = 2
-	defaultTimeout    = 100 * time.Millisecond
-	maxRestartTimeout = 1 * time.Minute
+	backoffMultiplier   = 2
+	defaultTimeout      = 100 * time.Millisecond
+	maxRestartTimeout   = 1 * time.Minute
+	defaultResetTimeout = 10 * time.Second
 )
 
 // ErrRestartCanceled is returned when the restart manager has been
@@ -23,6 +25,9 @@ var ErrRestartCanceled = errors.New("restart canceled")
 type RestartManager interface {
 	Cancel() error
 	ShouldRestart(exitCode uint32, hasBeenManuallyStopped bool, executionDuration time.Duration) (bool, chan error, error)
+	ShouldRestartUnhealthy(unhealthyDuration time.Duration) bool
+	ResetUnhealthy()
+	NextRestart() time.Time
 }
 
 type restartManager struct {
@@ -34,6 +39,8 @@ type restartManager struct {
 	active       bool
 	cancel       chan struct{}
 	canceled     bool
+	unhealthy    bool
+	nextRestart  time.Time
 }
 
 // New returns a new restartManager based on a policy.
@@ -66,19 +73,30 @@ func (rm *restartManager) ShouldRestart(exitCode uint32, hasBeenManuallyStopped
 	if rm.active {
 		return false, nil, fmt.Errorf("invalid call on an active restart manager")
 	}
-	// if the container ran for more than 10s, regardless of status and policy reset the
-	// the timeout back to the default.
-	if executionDuration.Seconds() >= 10 {
+	// if the container ran for more than the reset timeout (10s by default), regardless
+	// of status and policy reset the the timeout back to the initial delay.
+	if executionDuration >= durationWithDefault(rm.policy.ResetAfter, defaultResetTimeout) {
 		rm.timeout = 0
 	}
+	// Without a MaxDelay, the delay is capped at maxRestartTimeout, unless
+	// the InitialDelay is longer.
+	defaultMaxTimeout := maxRestartTimeout
+	if rm.policy.InitialDelay > defaultMaxTimeout {
+		defaultMaxTimeout = rm.policy.InitialDelay
+	}
+	maxTimeout := durationWithDefault(rm.policy.MaxDelay, defaultMaxTimeout)
 	switch {
 	case rm.timeout == 0:
-		rm.timeout = defaultTimeout
-	case rm.timeout < maxRestartTimeout:
-		rm.timeout *= backoffMultiplier
+		rm.timeout = durationWithDefault(rm.policy.InitialDelay, defaultTimeout)
+	case rm.timeout < maxTimeout:
+		multiplier := rm.policy.Multiplier
+		if multiplier == 0 {
+			multiplier = backoffMultiplier
+		}
+		rm.timeout = time.Duration(float64(rm.timeout) * multiplier)
 	}
-	if rm.timeout > maxRestartTimeout {
-		rm.timeout = maxRestartTimeout
+	if rm.timeout > maxTimeout {
+		rm.timeout = maxTimeout
 	}
 
 	var restart bool
@@ -92,7 +110,12 @@ func (rm *restartManager) ShouldRestart(exitCode uint32, hasBeenManuallyStopped
 		if max := rm.policy.MaximumRetryCount; max == 0 || rm.restartCount < max {
 			restart = exitCode != 0
 		}
+	case rm.policy.IsOnUnhealthy():
+		if max := rm.policy.MaximumRetryCount; max == 0 || rm.restartCount < max {
+			restart = rm.unhealthy || exitCode != 0
+		}
 	}
+	rm.unhealthy = false
 
 	if !restart {
 		rm.active = false
@@ -101,6 +124,12 @@ func (rm *restartManager) ShouldRestart(exitCode uint32, hasBeenManuallyStopped
 
 	rm.restartCount++
 
+	delay := rm.timeout
+	if rm.policy.Jitter > 0 {
+		delay += time.Duration(rand.Float64() * rm.policy.Jitter * float64(rm.timeout))
+	}
+	rm.nextRestart = time.Now().Add(delay)
+
 	unlockOnExit = false
 	rm.active = true
 	rm.Unlock()
@@ -111,10 +140,11 @@ func (rm *restartManager) ShouldRestart(exitCode uint32, hasBeenManuallyStopped
 		case <-rm.cancel:
 			ch <- ErrRestartCanceled
 			close(ch)
-		case <-time.After(rm.timeout):
+		case <-time.After(delay):
 			rm.Lock()
 			close(ch)
 			rm.active = false
+			rm.nextRestart = time.Time{}
 			rm.Unlock()
 		}
 	}()
@@ -122,6 +152,44 @@ func (rm *restartManager) ShouldRestart(exitCode uint32, hasBeenManuallyStopped
 	return true, ch, nil
 }
 
+// ShouldRestartUnhealthy returns whether a container that has been unhealthy
+// for unhealthyDuration should be restarted. If it returns true, the caller
+// is expected to stop the container; the next call to ShouldRestart then
+// restarts it regardless of its exit code.
+func (rm *restartManager) ShouldRestartUnhealthy(unhealthyDuration time.Duration) bool {
+	rm.Lock()
+	defer rm.Unlock()
+
+	if !rm.policy.IsOnUnhealthy() || rm.canceled || rm.active || rm.unhealthy {
+		return false
+	}
+	if unhealthyDuration < rm.policy.UnhealthyPeriod {
+		return false
+	}
+	if max := rm.policy.MaximumRetryCount; max != 0 && rm.restartCount >= max {
+		return false
+	}
+	rm.unhealthy = true
+	return true
+}
+
+// ResetUnhealthy is called when a container could not be stopped after
+// ShouldRestartUnhealthy returned true, so that it is restarted again the
+// next time it is found unhealthy.
+func (rm *restartManager) ResetUnhealthy() {
+	rm.Lock()
+	rm.unhealthy = false
+	rm.Unlock()
+}
+
+// NextRestart returns the time at which the container is scheduled to be
+// restarted, or the zero time if no restart is scheduled.
+func (rm *restartManager) NextRestart() time.Time {
+	rm.Lock()
+	defer rm.Unlock()
+	return rm.nextRestart
+}
+
 func (rm *restartManager) Cancel() error {
 	rm.Do(func() {
 		rm.Lock()
@@ -131,3 +199,11 @@ func (rm *restartManager) Cancel() error {
 	})
 	return nil
 }
+
+// durationWithDefault returns value, or defaultValue if value is zero.
+func durationWithDefault(value, defaultValue time.Duration) time.Duration {
+	if value == 0 {
+		return defaultValue
+	}
+	return value
+}
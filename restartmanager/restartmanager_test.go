@@ -34,3 +34,121 @@ func TestRestartManagerTimeoutReset(t *testing.T) {
 		t.Fatalf("restart manager should have a timeout of 100 ms but has %s", rm.timeout)
 	}
 }
+
+func TestRestartManagerOnUnhealthy(t *testing.T) {
+	rm := New(container.RestartPolicy{Name: "on-unhealthy", MaximumRetryCount: 1, UnhealthyPeriod: time.Minute}, 0).(*restartManager)
+	if rm.ShouldRestartUnhealthy(30 * time.Second) {
+		t.Fatal("container should not be restarted before the unhealthy period")
+	}
+	if !rm.ShouldRestartUnhealthy(time.Minute) {
+		t.Fatal("container should be restarted after the unhealthy period")
+	}
+	if rm.ShouldRestartUnhealthy(2 * time.Minute) {
+		t.Fatal("container restart should only be requested once")
+	}
+
+	// the container could not be stopped
+	rm.ResetUnhealthy()
+	if !rm.ShouldRestartUnhealthy(2 * time.Minute) {
+		t.Fatal("container should be restarted again after it failed to stop")
+	}
+
+	// the container was stopped gracefully, but still needs to be restarted
+	should, _, err := rm.ShouldRestart(0, false, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !should {
+		t.Fatal("unhealthy container should be restarted")
+	}
+	if rm.restartCount != 1 {
+		t.Fatalf("restart count should be 1 but is %d", rm.restartCount)
+	}
+
+	// the maximum retry count was reached
+	rm.active = false
+	if rm.ShouldRestartUnhealthy(time.Hour) {
+		t.Fatal("container should not be restarted more than the maximum retry count")
+	}
+}
+
+func TestRestartManagerOnUnhealthyExit(t *testing.T) {
+	rm := New(container.RestartPolicy{Name: "on-unhealthy"}, 0).(*restartManager)
+	should, _, err := rm.ShouldRestart(0, false, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if should {
+		t.Fatal("container exiting successfully should not be restarted")
+	}
+	should, _, err = rm.ShouldRestart(1, false, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !should {
+		t.Fatal("container exiting with an error should be restarted")
+	}
+}
+
+func TestRestartManagerCustomBackoff(t *testing.T) {
+	rm := New(container.RestartPolicy{
+		Name:         "always",
+		InitialDelay: time.Second,
+		MaxDelay:     5 * time.Second,
+		Multiplier:   3,
+		ResetAfter:   time.Minute,
+	}, 0).(*restartManager)
+
+	for _, expected := range []time.Duration{time.Second, 3 * time.Second, 5 * time.Second, 5 * time.Second} {
+		_, _, err := rm.ShouldRestart(1, false, 30*time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rm.timeout != expected {
+			t.Fatalf("restart manager should have a timeout of %s but has %s", expected, rm.timeout)
+		}
+		if rm.NextRestart().IsZero() {
+			t.Fatal("restart manager should have a next restart time")
+		}
+		rm.active = false
+	}
+
+	// the container ran for longer than ResetAfter
+	_, _, err := rm.ShouldRestart(1, false, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rm.timeout != time.Second {
+		t.Fatalf("restart manager should have a timeout of 1s but has %s", rm.timeout)
+	}
+}
+
+func TestRestartManagerLongInitialDelay(t *testing.T) {
+	rm := New(container.RestartPolicy{Name: "always", InitialDelay: 5 * time.Minute}, 0).(*restartManager)
+	for i := 0; i < 2; i++ {
+		_, _, err := rm.ShouldRestart(1, false, time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rm.timeout != 5*time.Minute {
+			t.Fatalf("restart manager should have a timeout of 5m but has %s", rm.timeout)
+		}
+		rm.active = false
+	}
+}
+
+func TestRestartManagerJitter(t *testing.T) {
+	rm := New(container.RestartPolicy{Name: "always", InitialDelay: time.Second, Jitter: 0.5}, 0).(*restartManager)
+	before := time.Now()
+	_, _, err := rm.ShouldRestart(1, false, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	delay := rm.NextRestart().Sub(before)
+	if delay < time.Second || delay > 1500*time.Millisecond+time.Second {
+		t.Fatalf("restart delay should be between 1s and 1.5s but is %s", delay)
+	}
+	if rm.timeout != time.Second {
+		t.Fatalf("jitter should not change the base timeout, got %s", rm.timeout)
+	}
+}